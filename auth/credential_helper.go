@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+)
+
+// EnvRegistryCredentialHelpers is the environment variable that configures a credential helper for each registry.
+// The value should be a JSON object that maps OCI registry hostnames to the suffix of a `docker-credential-<suffix>` executable
+// found on the PATH, like the `credHelpers` of the docker config.json file (e.g., `{"registry.internal": "vault"}`).
+const EnvRegistryCredentialHelpers = "CNB_REGISTRY_CREDENTIAL_HELPERS"
+
+// CredentialHelperSecrets, if set, is called with the credentials returned by each credential helper when they are fetched,
+// so that they can be redacted from output.
+var CredentialHelperSecrets func(secrets ...string)
+
+// NewCredentialHelperKeychain returns an authn.Keychain that uses the credential helpers configured by the provided environment variable
+// as a source of credentials.
+func NewCredentialHelperKeychain(envVar string) (authn.Keychain, error) {
+	helpers := map[string]string{}
+	rawHelpers, err := ReadEnvVar(envVar)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading credential helpers env var")
+	}
+
+	for reg, helper := range rawHelpers {
+		helpers[canonicalRegistry(convertToHostname(reg))] = helper
+	}
+
+	return &CredentialHelperKeychain{Helpers: helpers}, nil
+}
+
+// canonicalRegistry returns the name of the provided registry as it appears in image references (e.g., `index.docker.io` for `docker.io`).
+func canonicalRegistry(registry string) string {
+	reg, err := name.NewRegistry(registry, name.WeakValidation)
+	if err != nil {
+		return registry
+	}
+	return reg.RegistryStr()
+}
+
+// CredentialHelperKeychain is an implementation of authn.Keychain that gets credentials for each registry
+// from the configured credential helper, using the docker credential helper protocol.
+// Credentials are only requested from a helper once per registry for the lifetime of the keychain,
+// unless the helper fails, in which case they are requested again the next time they are needed.
+type CredentialHelperKeychain struct {
+	Helpers map[string]string // the suffix of the `docker-credential-<suffix>` executable, by registry
+
+	mu      sync.Mutex
+	results map[string]*credentialHelperResult // by helper and server URL
+}
+
+func (k *CredentialHelperKeychain) Resolve(resource authn.Resource) (authn.Authenticator, error) {
+	helper, ok := k.Helpers[resource.RegistryStr()]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+	return authn.NewKeychainFromHelper(&credentialHelper{name: helper, keychain: k}).Resolve(resource)
+}
+
+// result returns the result of the provided helper for the provided server URL, which is shared by concurrent callers.
+func (k *CredentialHelperKeychain) result(helper, serverURL string) *credentialHelperResult {
+	key := helper + "|" + serverURL
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.results == nil {
+		k.results = map[string]*credentialHelperResult{}
+	}
+	result, ok := k.results[key]
+	if !ok {
+		result = &credentialHelperResult{}
+		k.results[key] = result
+	}
+	return result
+}
+
+// forget removes the provided result, if it is still cached, so that the helper is executed again.
+func (k *CredentialHelperKeychain) forget(helper, serverURL string, result *credentialHelperResult) {
+	key := helper + "|" + serverURL
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.results[key] == result {
+		delete(k.results, key)
+	}
+}
+
+type credentialHelperResult struct {
+	once             sync.Once
+	username, secret string
+	err              error
+}
+
+// credentialHelper is an implementation of authn.Helper that executes a `docker-credential-<name>` executable.
+type credentialHelper struct {
+	name     string
+	keychain *CredentialHelperKeychain
+}
+
+// Get returns the username and secret for the provided server URL.
+// A username of `<token>` indicates that the secret is an identity token.
+func (h *credentialHelper) Get(serverURL string) (string, string, error) {
+	result := h.keychain.result(h.name, serverURL)
+	result.once.Do(func() {
+		result.username, result.secret, result.err = h.get(serverURL)
+		if result.err == nil && result.secret != "" && CredentialHelperSecrets != nil {
+			CredentialHelperSecrets(result.secret)
+		}
+	})
+	if result.err != nil {
+		h.keychain.forget(h.name, serverURL, result)
+	}
+	return result.username, result.secret, result.err
+}
+
+func (h *credentialHelper) get(serverURL string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker-credential-"+h.name, "get") // #nosec G204 -- the helper is configured by the platform
+	cmd.Stdin = strings.NewReader(serverURL)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// helpers report errors (e.g., "credentials not found in native keychain") on stdout
+		return "", "", fmt.Errorf("credential helper %q failed for %q: %w: %s", h.name, serverURL, err, strings.TrimSpace(stdout.String()+stderr.String()))
+	}
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", fmt.Errorf("parsing output of credential helper %q: %w", h.name, err)
+	}
+	return creds.Username, creds.Secret, nil
+}
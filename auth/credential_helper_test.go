@@ -0,0 +1,175 @@
+package auth_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/auth"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestCredentialHelperKeychain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper fixtures are shell scripts")
+	}
+	spec.Run(t, "CredentialHelperKeychain", testCredentialHelperKeychain, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testCredentialHelperKeychain(t *testing.T, when spec.G, it spec.S) {
+	var (
+		binDir   string
+		origPath string
+	)
+
+	// writeHelper writes a `docker-credential-<helperName>` executable that records each invocation and prints the provided output,
+	// exiting with a non-zero status if fail is true.
+	writeHelper := func(helperName, output string, fail bool) string {
+		invocations := filepath.Join(binDir, helperName+".invocations")
+		exitCode := 0
+		if fail {
+			exitCode = 1
+		}
+		script := fmt.Sprintf("#!/bin/sh\nread server\necho \"$1 $server\" >> %s\necho '%s'\nexit %d\n", invocations, output, exitCode)
+		h.AssertNil(t, os.WriteFile(filepath.Join(binDir, "docker-credential-"+helperName), []byte(script), 0755)) // #nosec G306
+		return invocations
+	}
+
+	resolve := func(keychain authn.Keychain, registry string) *authn.AuthConfig {
+		reg, err := name.NewRegistry(registry, name.WeakValidation)
+		h.AssertNil(t, err)
+		authenticator, err := keychain.Resolve(reg)
+		h.AssertNil(t, err)
+		authConfig, err := authenticator.Authorization()
+		h.AssertNil(t, err)
+		return authConfig
+	}
+
+	it.Before(func() {
+		binDir = t.TempDir()
+		origPath = os.Getenv("PATH")
+		h.AssertNil(t, os.Setenv("PATH", binDir+string(os.PathListSeparator)+origPath))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.Setenv("PATH", origPath))
+		h.AssertNil(t, os.Unsetenv(auth.EnvRegistryCredentialHelpers))
+	})
+
+	when("#NewCredentialHelperKeychain", func() {
+		it("returns a keychain with the helper for each registry", func() {
+			h.AssertNil(t, os.Setenv(auth.EnvRegistryCredentialHelpers, `{
+	"https://some-registry.com/v1/": "some-helper",
+	"docker.io": "other-helper"
+}`))
+
+			keychain, err := auth.NewCredentialHelperKeychain(auth.EnvRegistryCredentialHelpers)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, keychain.(*auth.CredentialHelperKeychain).Helpers, map[string]string{
+				"some-registry.com": "some-helper",
+				"index.docker.io":   "other-helper",
+			})
+		})
+
+		it("errors when the environment variable is invalid", func() {
+			h.AssertNil(t, os.Setenv(auth.EnvRegistryCredentialHelpers, "NOT -- JSON"))
+
+			_, err := auth.NewCredentialHelperKeychain(auth.EnvRegistryCredentialHelpers)
+			h.AssertError(t, err, "reading credential helpers env var")
+		})
+	})
+
+	when("CredentialHelperKeychain", func() {
+		when("#Resolve", func() {
+			it("gets credentials from the helper configured for the registry", func() {
+				invocations := writeHelper("basic-helper", `{"ServerURL":"some-registry.com","Username":"some-user","Secret":"some-secret"}`, false)
+				keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "basic-helper"}}
+
+				h.AssertEq(t, resolve(keychain, "some-registry.com"), &authn.AuthConfig{Username: "some-user", Password: "some-secret"})
+				contents, err := os.ReadFile(invocations)
+				h.AssertNil(t, err)
+				h.AssertEq(t, string(contents), "get some-registry.com\n")
+			})
+
+			it("returns an identity token when the username is <token>", func() {
+				writeHelper("token-helper", `{"ServerURL":"some-registry.com","Username":"<token>","Secret":"some-token"}`, false)
+				keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "token-helper"}}
+
+				h.AssertEq(t, resolve(keychain, "some-registry.com"), &authn.AuthConfig{Username: "<token>", IdentityToken: "some-token"})
+			})
+
+			it("executes the helper once per registry", func() {
+				invocations := writeHelper("cached-helper", `{"ServerURL":"some-registry.com","Username":"some-user","Secret":"some-secret"}`, false)
+				keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "cached-helper"}}
+
+				resolve(keychain, "some-registry.com")
+				resolve(keychain, "some-registry.com")
+
+				contents, err := os.ReadFile(invocations)
+				h.AssertNil(t, err)
+				h.AssertEq(t, strings.Count(string(contents), "\n"), 1)
+			})
+
+			it("executes the helper again for a new keychain", func() {
+				invocations := writeHelper("cached-helper", `{"ServerURL":"some-registry.com","Username":"some-user","Secret":"some-secret"}`, false)
+
+				resolve(&auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "cached-helper"}}, "some-registry.com")
+				resolve(&auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "cached-helper"}}, "some-registry.com")
+
+				contents, err := os.ReadFile(invocations)
+				h.AssertNil(t, err)
+				h.AssertEq(t, strings.Count(string(contents), "\n"), 2)
+			})
+
+			it("passes the secret to CredentialHelperSecrets", func() {
+				writeHelper("basic-helper", `{"ServerURL":"some-registry.com","Username":"some-user","Secret":"some-secret"}`, false)
+				keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "basic-helper"}}
+				var secrets []string
+				auth.CredentialHelperSecrets = func(s ...string) { secrets = append(secrets, s...) }
+				defer func() { auth.CredentialHelperSecrets = nil }()
+
+				resolve(keychain, "some-registry.com")
+
+				h.AssertEq(t, secrets, []string{"some-secret"})
+			})
+
+			when("the helper fails", func() {
+				it("returns anonymous", func() {
+					writeHelper("failing-helper", "credentials not found in native keychain", true)
+					keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "failing-helper"}}
+
+					h.AssertEq(t, resolve(keychain, "some-registry.com"), &authn.AuthConfig{})
+				})
+
+				it("executes the helper again", func() {
+					invocations := writeHelper("failing-helper", "credentials not found in native keychain", true)
+					keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "failing-helper"}}
+
+					resolve(keychain, "some-registry.com")
+					resolve(keychain, "some-registry.com")
+
+					contents, err := os.ReadFile(invocations)
+					h.AssertNil(t, err)
+					h.AssertEq(t, strings.Count(string(contents), "\n"), 2)
+				})
+			})
+
+			when("no helper is configured for the registry", func() {
+				it("returns anonymous", func() {
+					keychain := &auth.CredentialHelperKeychain{Helpers: map[string]string{"some-registry.com": "some-helper"}}
+
+					h.AssertEq(t, resolve(keychain, "other-registry.com"), &authn.AuthConfig{})
+				})
+			})
+		})
+	})
+}
@@ -29,6 +29,7 @@ var (
 // DefaultKeychain returns a keychain containing authentication configuration for the given images
 // from the following sources, if they exist, in order of precedence:
 // the provided environment variable
+// the credential helpers configured by the platform for each registry
 // the docker config.json file
 // credential helpers for Amazon and Azure
 func DefaultKeychain(images ...string) (authn.Keychain, error) {
@@ -36,9 +37,14 @@ func DefaultKeychain(images ...string) (authn.Keychain, error) {
 	if err != nil {
 		return nil, err
 	}
+	helperKeychain, err := NewCredentialHelperKeychain(EnvRegistryCredentialHelpers)
+	if err != nil {
+		return nil, err
+	}
 
 	keychains := []authn.Keychain{
 		envKeychain,
+		NewResolvedKeychain(helperKeychain, images...),
 		NewResolvedKeychain(authn.DefaultKeychain, images...),
 	}
 	if vendorKeychainEnabled("amazon") {
@@ -104,6 +110,29 @@ func (k *EnvKeychain) Resolve(resource authn.Resource) (authn.Authenticator, err
 	return authn.Anonymous, nil
 }
 
+// Secrets returns the credentials in the keychain, which should not appear in any output:
+// each auth header, the credentials in the header, and the password of basic auth credentials.
+func (k *EnvKeychain) Secrets() []string {
+	var secrets []string
+	for _, header := range k.AuthHeaders {
+		secrets = append(secrets, header)
+		_, credentials, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if credentials == "" {
+			continue
+		}
+		secrets = append(secrets, credentials)
+		if !basicAuthRegExp.MatchString(header) {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(credentials); err == nil {
+			if _, password, ok := strings.Cut(string(decoded), ":"); ok {
+				secrets = append(secrets, password)
+			}
+		}
+	}
+	return secrets
+}
+
 var (
 	basicAuthRegExp     = regexp.MustCompile("(?i)^basic (.*)$")
 	bearerAuthRegExp    = regexp.MustCompile("(?i)^bearer (.*)$")
@@ -2,6 +2,7 @@ package auth_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"os"
 	"testing"
@@ -183,6 +184,26 @@ func testEnvKeychain(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 		})
+
+		when("#Secrets", func() {
+			it("returns each header, its credentials, and the password of basic auth credentials", func() {
+				envKeychain := auth.EnvKeychain{AuthHeaders: map[string]string{
+					"basic-registry.com":  "Basic " + base64.StdEncoding.EncodeToString([]byte("some-user:some-password")),
+					"bearer-registry.com": "Bearer some-bearer-auth=",
+				}}
+
+				secrets := envKeychain.Secrets()
+
+				h.AssertContains(t, secrets,
+					"Basic c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ=",
+					"c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ=",
+					"some-password",
+					"Bearer some-bearer-auth=",
+					"some-bearer-auth=",
+				)
+				h.AssertEq(t, len(secrets), 5)
+			})
+		})
 	})
 
 	when("#NewResolvedKeychain", func() {
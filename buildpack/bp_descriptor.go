@@ -151,6 +151,8 @@ type GroupElement struct {
 	Homepage string `toml:"homepage,omitempty" json:"homepage,omitempty"`
 	// Extension specifies whether the group element is a buildpack or an extension.
 	Extension bool `toml:"extension,omitempty" json:"-"`
+	// Digest specifies the digest of the buildpack or extension directory (see DirDigest), if recorded by the detector.
+	Digest string `toml:"digest,omitempty" json:"digest,omitempty"`
 
 	// Fields that are in order.toml only
 
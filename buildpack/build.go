@@ -1,6 +1,7 @@
 package buildpack
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -38,6 +40,11 @@ type BuildInputs struct {
 	ExecEnv        string
 	Out, Err       io.Writer
 	Plan           Plan
+	Timeout        time.Duration
+	// Context, if provided, stops the process (and any processes it started) when done.
+	Context context.Context
+	// Network, if provided, is the network namespace in which the process is run, so that it cannot reach the network.
+	Network *IsolatedNetwork
 }
 
 type BuildEnv interface {
@@ -55,6 +62,8 @@ type BuildOutputs struct {
 	MetRequires []string
 	Processes   []launch.Process
 	Slices      []layers.Slice
+	// Usage describes the resources used by `./bin/build`; it is provided even if `./bin/build` fails.
+	Usage *Usage
 }
 
 // BuildExecutor executes a single buildpack's `./bin/build` binary,
@@ -83,8 +92,9 @@ func (e *DefaultBuildExecutor) Build(d BpDescriptor, inputs BuildInputs, logger
 	}
 
 	logger.Debug("Running build command")
-	if err := runBuildCmd(d, bpLayersDir, planPath, inputs, inputs.Env); err != nil {
-		return BuildOutputs{}, err
+	usage, err := runBuildCmd(d, bpLayersDir, planPath, inputs, inputs.Env)
+	if err != nil {
+		return BuildOutputs{Usage: usage}, err
 	}
 
 	logger.Debug("Processing layers")
@@ -99,7 +109,9 @@ func (e *DefaultBuildExecutor) Build(d BpDescriptor, inputs BuildInputs, logger
 	}
 
 	logger.Debug("Reading output files")
-	return d.readOutputFilesBp(bpLayersDir, planPath, inputs.Plan, createdLayers, logger)
+	br, err := d.readOutputFilesBp(bpLayersDir, planPath, inputs.Plan, createdLayers, logger)
+	br.Usage = usage
+	return br, err
 }
 
 func prepareInputPaths(bpID string, plan Plan, layersDir, parentPlanDir string) (string, string, error) {
@@ -124,7 +136,7 @@ func prepareInputPaths(bpID string, plan Plan, layersDir, parentPlanDir string)
 	return bpLayersDir, planPath, nil
 }
 
-func runBuildCmd(d BpDescriptor, bpLayersDir, planPath string, inputs BuildInputs, buildEnv BuildEnv) error {
+func runBuildCmd(d BpDescriptor, bpLayersDir, planPath string, inputs BuildInputs, buildEnv BuildEnv) (*Usage, error) {
 	cmd := exec.Command(
 		filepath.Join(d.WithRootDir, "bin", "build"),
 		bpLayersDir,
@@ -142,7 +154,7 @@ func runBuildCmd(d BpDescriptor, bpLayersDir, planPath string, inputs BuildInput
 		cmd.Env, err = buildEnv.WithOverrides(inputs.PlatformDir, inputs.BuildConfigDir)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	cmd.Env = append(cmd.Env, EnvBuildpackDir+"="+d.WithRootDir)
 	if api.MustParse(d.WithAPI).AtLeast("0.8") {
@@ -159,10 +171,13 @@ func runBuildCmd(d BpDescriptor, bpLayersDir, planPath string, inputs BuildInput
 		cmd.Env = append(cmd.Env, "CNB_EXEC_ENV="+inputs.ExecEnv)
 	}
 
-	if err = cmd.Run(); err != nil {
-		return NewError(err, ErrTypeBuildpack)
+	if err = runCmd(inputs.Context, cmd, inputs.Timeout, inputs.Network); err != nil {
+		if isStopped(err) {
+			return usageOf(cmd.ProcessState), err
+		}
+		return usageOf(cmd.ProcessState), NewError(err, ErrTypeBuildpack)
 	}
-	return nil
+	return usageOf(cmd.ProcessState), nil
 }
 
 func (d BpDescriptor) processLayers(bpLayersDir string, logger log.Logger) (map[string]LayerMetadataFile, error) {
@@ -2,12 +2,15 @@ package buildpack_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/apex/log"
@@ -402,6 +405,40 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 					}
 				})
 
+				it("errors with a timeout error when the command exceeds its timeout", func() {
+					h.SkipIf(t, runtime.GOOS == "windows", "timeout fixtures are unix only")
+					h.Mkfile(t, "10", filepath.Join(appDir, "build-sleep"))
+					inputs.Timeout = 100 * time.Millisecond
+
+					_, err := executor.Build(descriptor, inputs, logger)
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeTimeout {
+						t.Fatalf("Incorrect error: %s\n", err)
+					}
+				})
+
+				it("stops the command when the context is done", func() {
+					h.SkipIf(t, runtime.GOOS == "windows", "timeout fixtures are unix only")
+					h.Mkfile(t, "10", filepath.Join(appDir, "build-sleep"))
+					ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+					defer cancel()
+					inputs.Context = ctx
+
+					_, err := executor.Build(descriptor, inputs, logger)
+					if !errors.Is(err, context.DeadlineExceeded) || buildpack.IsTimeout(err) {
+						t.Fatalf("Incorrect error: %s\n", err)
+					}
+				})
+
+				it("reports the resources used by the command", func() {
+					br, err := executor.Build(descriptor, inputs, logger)
+					h.AssertNil(t, err)
+
+					h.AssertNotNil(t, br.Usage)
+					if runtime.GOOS != "windows" && br.Usage.MaxRSS <= 0 {
+						t.Fatalf("Expected max RSS to be reported: %+v", br.Usage)
+					}
+				})
+
 				when("<layer>.toml", func() {
 					when("the launch, cache and build flags are false", func() {
 						when("the flags are specified in <layer>.toml", func() {
@@ -634,6 +671,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 
 							br, err := executor.Build(descriptor, inputs, logger)
 							h.AssertNil(t, err)
+							br.Usage = nil
 
 							h.AssertEq(t, buildpack.BuildOutputs{
 								BOMFiles: []buildpack.BOMFile{
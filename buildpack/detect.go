@@ -2,11 +2,13 @@ package buildpack
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -34,6 +36,11 @@ type DetectInputs struct {
 	Env            BuildEnv
 	TargetEnv      []string
 	ExecEnv        string
+	Timeout        time.Duration
+	// Context, if provided, stops the process (and any processes it started) when done.
+	Context context.Context
+	// Network, if provided, is the network namespace in which the process is run, so that it cannot reach the network.
+	Network *IsolatedNetwork
 }
 
 type DetectOutputs struct {
@@ -41,6 +48,8 @@ type DetectOutputs struct {
 	Output []byte `toml:"-"`
 	Code   int    `toml:"-"`
 	Err    error  `toml:"-"`
+	// Usage describes the resources used by `./bin/detect`, or is nil if `./bin/detect` did not run.
+	Usage *Usage `toml:"-"`
 }
 
 // DetectExecutor executes a single buildpack or image extension's `./bin/detect` binary,
@@ -79,7 +88,7 @@ func detectBp(d BpDescriptor, inputs DetectInputs, _ log.Logger) DetectOutputs {
 	}
 	backupOut := result.Output
 	if _, err := toml.DecodeFile(planPath, &result); err != nil {
-		return DetectOutputs{Code: -1, Err: err, Output: backupOut}
+		return DetectOutputs{Code: -1, Err: err, Output: backupOut, Usage: result.Usage}
 	}
 
 	if result.hasDoublySpecifiedVersions() || result.Or.hasDoublySpecifiedVersions() {
@@ -116,7 +125,7 @@ func detectExt(d ExtDescriptor, inputs DetectInputs, logger log.Logger) DetectOu
 		}
 		backupOut := result.Output
 		if _, err := toml.DecodeFile(planPath, &result); err != nil {
-			return DetectOutputs{Code: -1, Err: err, Output: backupOut}
+			return DetectOutputs{Code: -1, Err: err, Output: backupOut, Usage: result.Usage}
 		}
 	}
 
@@ -189,13 +198,13 @@ func runDetect(d detectable, inputs DetectInputs, planPath string, envRootDirKey
 		cmd.Env = append(cmd.Env, EnvExecEnv+"="+inputs.ExecEnv)
 	}
 
-	if err := cmd.Run(); err != nil {
+	if err := runCmd(inputs.Context, cmd, inputs.Timeout, inputs.Network); err != nil {
 		if err, ok := err.(*exec.ExitError); ok {
 			if status, ok := err.Sys().(syscall.WaitStatus); ok {
-				return DetectOutputs{Code: status.ExitStatus(), Output: out.Bytes()}
+				return DetectOutputs{Code: status.ExitStatus(), Output: out.Bytes(), Usage: usageOf(cmd.ProcessState)}
 			}
 		}
-		return DetectOutputs{Code: -1, Err: err, Output: out.Bytes()}
+		return DetectOutputs{Code: -1, Err: err, Output: out.Bytes(), Usage: usageOf(cmd.ProcessState)}
 	}
-	return DetectOutputs{Code: 0, Err: nil, Output: out.Bytes()}
+	return DetectOutputs{Code: 0, Err: nil, Output: out.Bytes(), Usage: usageOf(cmd.ProcessState)}
 }
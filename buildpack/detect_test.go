@@ -4,7 +4,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -246,6 +248,35 @@ func testDetect(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, err.Error(), `toml: line 2 (last key "bad"): expected value but found "toml" instead`)
 			})
 
+			when("a timeout is provided", func() {
+				it("kills the process group and errors when the timeout is exceeded", func() {
+					h.SkipIf(t, runtime.GOOS == "windows", "timeout fixtures are unix only")
+					toappfile("10", "detect-sleep")
+					mockEnv.EXPECT().WithOverrides(platformDir, buildConfigDir).Return(append(os.Environ(), someEnv), nil)
+					inputs.Timeout = 100 * time.Millisecond
+
+					start := time.Now()
+					detectRun := executor.Detect(descriptor, inputs, logger)
+
+					h.AssertEq(t, detectRun.Code, -1)
+					h.AssertEq(t, buildpack.IsTimeout(detectRun.Err), true)
+					h.AssertStringContains(t, detectRun.Err.Error(), "timed out after 100ms")
+					if elapsed := time.Since(start); elapsed > 5*time.Second {
+						t.Fatalf("Expected detect to be killed, took %s", elapsed)
+					}
+				})
+
+				it("succeeds when the timeout is not exceeded", func() {
+					mockEnv.EXPECT().WithOverrides(platformDir, buildConfigDir).Return(append(os.Environ(), someEnv), nil)
+					inputs.Timeout = time.Minute
+
+					detectRun := executor.Detect(descriptor, inputs, logger)
+
+					h.AssertNil(t, detectRun.Err)
+					h.AssertEq(t, detectRun.Code, 0)
+				})
+			})
+
 			when("plan deprecations", func() {
 				it.Before(func() {
 					mockEnv.EXPECT().WithOverrides(platformDir, buildConfigDir).Return(append(os.Environ(), someEnv), nil)
@@ -0,0 +1,63 @@
+package buildpack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DirDigest returns a digest of the contents of the buildpack or image extension directory at the provided path.
+// The digest covers the relative path and type of each entry, the contents and executable bit of each regular file,
+// and the target of each symlink, so that it is the same for identical directories on any builder.
+func DirDigest(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(h, "dir\x00%s\x00", rel)
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "symlink\x00%s\x00%s\x00", rel, filepath.ToSlash(target))
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "file\x00%s\x00%t\x00%d\x00", rel, info.Mode().Perm()&0111 != 0, info.Size())
+			f, err := os.Open(path) // #nosec G304
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type for '%s'", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compute digest of '%s': %w", dir, err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,97 @@
+package buildpack_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestDigest(t *testing.T) {
+	spec.Run(t, "unit-digest", testDigest, spec.Report(report.Terminal{}))
+}
+
+func testDigest(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.digest")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	writeBuildpack := func(name string) string {
+		dir := filepath.Join(tmpDir, name)
+		h.AssertNil(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		h.AssertNil(t, os.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(`api = "0.10"`), 0600))
+		h.AssertNil(t, os.WriteFile(filepath.Join(dir, "bin", "build"), []byte("#!/bin/sh"), 0755))
+		return dir
+	}
+
+	when("#DirDigest", func() {
+		it("is the same for directories with the same contents", func() {
+			first, err := buildpack.DirDigest(writeBuildpack("first"))
+			h.AssertNil(t, err)
+			second, err := buildpack.DirDigest(writeBuildpack("second"))
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, first, second)
+			h.AssertEq(t, strings.HasPrefix(first, "sha256:"), true)
+		})
+
+		it("changes when a file is modified", func() {
+			dir := writeBuildpack("some-buildpack")
+			before, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertNil(t, os.WriteFile(filepath.Join(dir, "bin", "build"), []byte("#!/bin/bash"), 0755))
+			after, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, before != after, true)
+		})
+
+		it("changes when a file is added", func() {
+			dir := writeBuildpack("some-buildpack")
+			before, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertNil(t, os.WriteFile(filepath.Join(dir, "bin", "detect"), []byte("#!/bin/sh"), 0755))
+			after, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, before != after, true)
+		})
+
+		it("changes when a file is made executable", func() {
+			if runtime.GOOS == "windows" {
+				t.Skip("file modes are not supported on windows")
+			}
+			dir := writeBuildpack("some-buildpack")
+			before, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertNil(t, os.Chmod(filepath.Join(dir, "buildpack.toml"), 0700))
+			after, err := buildpack.DirDigest(dir)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, before != after, true)
+		})
+
+		it("errors when the directory does not exist", func() {
+			_, err := buildpack.DirDigest(filepath.Join(tmpDir, "missing"))
+			h.AssertError(t, err, "failed to compute digest of")
+		})
+	})
+}
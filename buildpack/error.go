@@ -4,10 +4,14 @@ type ErrorType string
 
 const ErrTypeBuildpack ErrorType = "ERR_BUILDPACK"
 const ErrTypeFailedDetection ErrorType = "ERR_FAILED_DETECTION"
+const ErrTypeOrderCycle ErrorType = "ERR_ORDER_CYCLE"
+const ErrTypeTimeout ErrorType = "ERR_TIMEOUT"
 
 type Error struct {
 	RootError error
 	Type      ErrorType
+	// Module is the buildpack or image extension that failed, if known.
+	Module *GroupElement
 }
 
 func (le *Error) Error() string {
@@ -21,6 +25,10 @@ func (le *Error) Cause() error {
 	return le.RootError
 }
 
+func (le *Error) Unwrap() error {
+	return le.RootError
+}
+
 func NewError(cause error, errType ErrorType) *Error {
 	return &Error{RootError: cause, Type: errType}
 }
@@ -1,11 +1,13 @@
 package buildpack
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/internal/extend"
@@ -29,12 +31,20 @@ type GenerateInputs struct {
 	TargetEnv      []string
 	Out, Err       io.Writer
 	Plan           Plan
+	Timeout        time.Duration
+	// Context, if provided, stops the process (and any processes it started) when done.
+	Context context.Context
+	// Network, if provided, is the network namespace in which the process is run, so that it cannot reach the network.
+	Network *IsolatedNetwork
 }
 
 type GenerateOutputs struct {
 	Dockerfiles []DockerfileInfo
 	Contexts    []extend.ContextInfo
 	MetRequires []string
+	// Usage describes the resources used by `./bin/generate`; it is provided even if `./bin/generate` fails,
+	// and is nil for image extensions without `./bin/generate`.
+	Usage *Usage
 }
 
 // GenerateExecutor executes a single image extension's `./bin/generate` binary,
@@ -71,15 +81,18 @@ func (e *DefaultGenerateExecutor) Generate(d ExtDescriptor, inputs GenerateInput
 		}
 		return GenerateOutputs{}, err
 	}
-	if err = runGenerateCmd(d, extOutputDir, planPath, inputs); err != nil {
-		return GenerateOutputs{}, err
+	usage, err := runGenerateCmd(d, extOutputDir, planPath, inputs)
+	if err != nil {
+		return GenerateOutputs{Usage: usage}, err
 	}
 
 	logger.Debug("Reading output files")
-	return readOutputFilesExt(d, extOutputDir, inputs.Plan, logger)
+	gr, err := readOutputFilesExt(d, extOutputDir, inputs.Plan, logger)
+	gr.Usage = usage
+	return gr, err
 }
 
-func runGenerateCmd(d ExtDescriptor, extOutputDir, planPath string, inputs GenerateInputs) error {
+func runGenerateCmd(d ExtDescriptor, extOutputDir, planPath string, inputs GenerateInputs) (*Usage, error) {
 	cmd := exec.Command(
 		filepath.Join(d.WithRootDir, "bin", "generate"),
 		extOutputDir,
@@ -97,7 +110,7 @@ func runGenerateCmd(d ExtDescriptor, extOutputDir, planPath string, inputs Gener
 		cmd.Env, err = inputs.Env.WithOverrides(inputs.PlatformDir, inputs.BuildConfigDir)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	cmd.Env = append(cmd.Env,
 		EnvBpPlanPath+"="+planPath,
@@ -109,10 +122,13 @@ func runGenerateCmd(d ExtDescriptor, extOutputDir, planPath string, inputs Gener
 		cmd.Env = append(cmd.Env, inputs.TargetEnv...)
 	}
 
-	if err := cmd.Run(); err != nil {
-		return NewError(err, ErrTypeBuildpack)
+	if err := runCmd(inputs.Context, cmd, inputs.Timeout, inputs.Network); err != nil {
+		if isStopped(err) {
+			return usageOf(cmd.ProcessState), err
+		}
+		return usageOf(cmd.ProcessState), NewError(err, ErrTypeBuildpack)
 	}
-	return nil
+	return usageOf(cmd.ProcessState), nil
 }
 
 func readOutputFilesExt(d ExtDescriptor, extOutputDir string, extPlanIn Plan, logger log.Logger) (GenerateOutputs, error) {
@@ -0,0 +1,82 @@
+//go:build linux
+
+package buildpack
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// IsolatedNetwork is a network namespace in which only the loopback interface is up.
+// Processes started in it cannot reach any other host.
+type IsolatedNetwork struct {
+	starts chan startRequest
+}
+
+type startRequest struct {
+	cmd *exec.Cmd
+	err chan error
+}
+
+// NewIsolatedNetwork creates a network namespace; it requires the CAP_SYS_ADMIN capability,
+// so it must be called before the lifecycle drops privileges.
+// Once created, processes can be started in it without privileges.
+func NewIsolatedNetwork() (*IsolatedNetwork, error) {
+	n := &IsolatedNetwork{starts: make(chan startRequest)}
+	ready := make(chan error)
+	go n.run(ready)
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// run moves the thread of the calling goroutine into a new network namespace,
+// and starts the commands it receives from that thread, so that they inherit the namespace.
+// The thread is never unlocked, so that it is not reused for other goroutines.
+func (n *IsolatedNetwork) run(ready chan<- error) {
+	runtime.LockOSThread()
+	if err := unix.Unshare(unix.CLONE_NEWNET); err != nil {
+		if errors.Is(err, unix.EPERM) {
+			err = fmt.Errorf("the lifecycle must have the CAP_SYS_ADMIN capability to run buildpacks offline: %w", err)
+		}
+		ready <- fmt.Errorf("creating network namespace: %w", err)
+		return
+	}
+	if err := setLoopbackUp(); err != nil {
+		ready <- fmt.Errorf("bringing up loopback interface: %w", err)
+		return
+	}
+	close(ready)
+	for req := range n.starts {
+		req.err <- req.cmd.Start()
+	}
+}
+
+// Start starts the provided command in the network namespace.
+func (n *IsolatedNetwork) Start(cmd *exec.Cmd) error {
+	req := startRequest{cmd: cmd, err: make(chan error)}
+	n.starts <- req
+	return <-req.err
+}
+
+func setLoopbackUp() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+	ifreq, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err = unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifreq); err != nil {
+		return err
+	}
+	ifreq.SetUint16(ifreq.Uint16() | unix.IFF_UP)
+	return unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifreq)
+}
@@ -0,0 +1,49 @@
+//go:build linux
+
+package buildpack_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestIsolatedNetwork(t *testing.T) {
+	spec.Run(t, "unit-isolated-network", testIsolatedNetwork, spec.Report(report.Terminal{}))
+}
+
+func testIsolatedNetwork(t *testing.T, when spec.G, it spec.S) {
+	when("#Start", func() {
+		it("starts the command in a new network namespace", func() {
+			network, err := buildpack.NewIsolatedNetwork()
+			if err != nil {
+				t.Skipf("Skipping: cannot create a network namespace: %s", err)
+			}
+			// the network namespace is per thread
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			hostNS, err := os.Readlink("/proc/thread-self/ns/net")
+			h.AssertNil(t, err)
+
+			for i := 0; i < 2; i++ {
+				out := &bytes.Buffer{}
+				cmd := exec.Command("readlink", "/proc/self/ns/net")
+				cmd.Stdout = out
+				h.AssertNil(t, network.Start(cmd))
+				h.AssertNil(t, cmd.Wait())
+				if strings.TrimSpace(out.String()) == hostNS {
+					t.Fatalf("Expected command to run outside of the network namespace %s", hostNS)
+				}
+			}
+		})
+	})
+}
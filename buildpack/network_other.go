@@ -0,0 +1,20 @@
+//go:build !linux
+
+package buildpack
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// IsolatedNetwork is a network namespace in which only the loopback interface is up.
+// Network namespaces are only supported on Linux.
+type IsolatedNetwork struct{}
+
+func NewIsolatedNetwork() (*IsolatedNetwork, error) {
+	return nil, errors.New("running buildpacks offline is only supported on Linux")
+}
+
+func (n *IsolatedNetwork) Start(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
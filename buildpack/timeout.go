@@ -0,0 +1,139 @@
+package buildpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timeouts configures how long a buildpack or image extension's `./bin/detect`, `./bin/generate`, or `./bin/build`
+// may run before it (and any processes it started) is killed.
+// A zero duration means no timeout.
+type Timeouts struct {
+	Default time.Duration
+	ByID    map[string]time.Duration
+}
+
+// ParseTimeouts parses a comma-separated list of timeouts, where each entry is either a duration
+// (applied to every buildpack and image extension) or `<id>=<duration>` (applied to the module with the given ID),
+// e.g., `15m,some/buildpack=1h`.
+func ParseTimeouts(s string) (Timeouts, error) {
+	var t Timeouts
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, val, hasID := strings.Cut(entry, "=")
+		if !hasID {
+			val = id
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil {
+			return Timeouts{}, fmt.Errorf("failed to parse timeout '%s': %w", entry, err)
+		}
+		if d < 0 {
+			return Timeouts{}, fmt.Errorf("failed to parse timeout '%s': must not be negative", entry)
+		}
+		if !hasID {
+			t.Default = d
+			continue
+		}
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return Timeouts{}, fmt.Errorf("failed to parse timeout '%s': missing id", entry)
+		}
+		if t.ByID == nil {
+			t.ByID = map[string]time.Duration{}
+		}
+		t.ByID[id] = d
+	}
+	return t, nil
+}
+
+// For returns the timeout for the module with the provided ID.
+func (t Timeouts) For(id string) time.Duration {
+	if d, ok := t.ByID[id]; ok {
+		return d
+	}
+	return t.Default
+}
+
+func (t Timeouts) String() string {
+	var entries []string
+	if t.Default > 0 {
+		entries = append(entries, t.Default.String())
+	}
+	var ids []string
+	for id := range t.ByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		entries = append(entries, id+"="+t.ByID[id].String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// IsTimeout returns true if the provided error was caused by a buildpack or image extension exceeding its timeout.
+func IsTimeout(err error) bool {
+	var bpErr *Error
+	return errors.As(err, &bpErr) && bpErr.Type == ErrTypeTimeout
+}
+
+// runCmd runs the provided command, killing its process group if it does not exit within the provided timeout
+// or if the provided context is done first.
+// If a network is provided, the command is started in it.
+func runCmd(ctx context.Context, cmd *exec.Cmd, timeout time.Duration, network *IsolatedNetwork) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	start := cmd.Start
+	if network != nil {
+		start = func() error { return network.Start(cmd) }
+	}
+	if timeout <= 0 && ctx.Done() == nil {
+		if err := start(); err != nil {
+			return err
+		}
+		return cmd.Wait()
+	}
+	setProcessGroup(cmd)
+	if err := start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-expired:
+		_ = killProcessGroup(cmd.Process)
+		<-done
+		return NewError(fmt.Errorf("%s timed out after %s", cmd.Path, timeout), ErrTypeTimeout)
+	case <-ctx.Done():
+		_ = killProcessGroup(cmd.Process)
+		<-done
+		return fmt.Errorf("%s was stopped: %w", cmd.Path, ctx.Err())
+	}
+}
+
+// isStopped returns true if the provided error was caused by a buildpack or image extension being killed,
+// either because it exceeded its timeout or because its context was done.
+func isStopped(err error) bool {
+	return IsTimeout(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
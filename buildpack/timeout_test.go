@@ -0,0 +1,69 @@
+package buildpack_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestTimeouts(t *testing.T) {
+	spec.Run(t, "unit-timeouts", testTimeouts, spec.Report(report.Terminal{}))
+}
+
+func testTimeouts(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseTimeouts", func() {
+		it("parses a default timeout", func() {
+			timeouts, err := buildpack.ParseTimeouts("15m")
+			h.AssertNil(t, err)
+			h.AssertEq(t, timeouts.For("some/buildpack"), 15*time.Minute)
+		})
+
+		it("parses timeouts by id", func() {
+			timeouts, err := buildpack.ParseTimeouts("15m, some/buildpack=1h,other/buildpack=30s")
+			h.AssertNil(t, err)
+			h.AssertEq(t, timeouts.For("some/buildpack"), time.Hour)
+			h.AssertEq(t, timeouts.For("other/buildpack"), 30*time.Second)
+			h.AssertEq(t, timeouts.For("unknown/buildpack"), 15*time.Minute)
+			h.AssertEq(t, timeouts.String(), "15m0s,other/buildpack=30s,some/buildpack=1h0m0s")
+		})
+
+		it("returns no timeout when empty", func() {
+			timeouts, err := buildpack.ParseTimeouts("")
+			h.AssertNil(t, err)
+			h.AssertEq(t, timeouts.For("some/buildpack"), time.Duration(0))
+		})
+
+		it("errors when a duration is invalid", func() {
+			_, err := buildpack.ParseTimeouts("some/buildpack=forever")
+			h.AssertError(t, err, "failed to parse timeout 'some/buildpack=forever'")
+		})
+
+		it("errors when a duration is negative", func() {
+			_, err := buildpack.ParseTimeouts("-1s")
+			h.AssertError(t, err, "must not be negative")
+		})
+
+		it("errors when an id is missing", func() {
+			_, err := buildpack.ParseTimeouts("=1s")
+			h.AssertError(t, err, "missing id")
+		})
+	})
+
+	when("#IsTimeout", func() {
+		it("returns true for timeout errors", func() {
+			h.AssertEq(t, buildpack.IsTimeout(buildpack.NewError(errors.New("some error"), buildpack.ErrTypeTimeout)), true)
+		})
+
+		it("returns false for other errors", func() {
+			h.AssertEq(t, buildpack.IsTimeout(buildpack.NewError(errors.New("some error"), buildpack.ErrTypeBuildpack)), false)
+			h.AssertEq(t, buildpack.IsTimeout(errors.New("some error")), false)
+			h.AssertEq(t, buildpack.IsTimeout(nil), false)
+		})
+	})
+}
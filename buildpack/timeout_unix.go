@@ -0,0 +1,21 @@
+//go:build unix
+
+package buildpack
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(p *os.Process) error {
+	// a negative pid signals every process in the group
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,12 @@
+package buildpack
+
+import (
+	"os"
+	"os/exec"
+)
+
+func setProcessGroup(_ *exec.Cmd) {}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,26 @@
+package buildpack
+
+import (
+	"os"
+	"time"
+)
+
+// Usage describes the resources used by a buildpack or image extension process.
+type Usage struct {
+	UserTime   time.Duration `json:"userTime"`
+	SystemTime time.Duration `json:"systemTime"`
+	// MaxRSS is the maximum resident set size of the process in bytes, or zero if unknown.
+	MaxRSS int64 `json:"maxRSS"`
+}
+
+// usageOf returns the resources used by the exited process with the provided state.
+func usageOf(state *os.ProcessState) *Usage {
+	if state == nil {
+		return nil
+	}
+	return &Usage{
+		UserTime:   state.UserTime(),
+		SystemTime: state.SystemTime(),
+		MaxRSS:     maxRSS(state),
+	}
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package buildpack
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+func maxRSS(state *os.ProcessState) int64 {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	// ru_maxrss is reported in bytes on macOS, but in kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return int64(rusage.Maxrss)
+	}
+	return int64(rusage.Maxrss) * 1024
+}
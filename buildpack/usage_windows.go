@@ -0,0 +1,7 @@
+package buildpack
+
+import "os"
+
+func maxRSS(_ *os.ProcessState) int64 {
+	return 0
+}
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 )
 
 var errCacheCommitted = errors.New("cache cannot be modified after commit")
@@ -27,3 +30,27 @@ func IsReadErr(err error) (bool, *ReadErr) {
 	isReadErr := errors.As(err, &e)
 	return isReadErr, &e
 }
+
+type layerRetriever interface {
+	RetrieveLayer(diffID string) (io.ReadCloser, error)
+}
+
+// verifyLayer returns an error if the contents of the layer retrieved from the cache do not match the provided sha.
+func verifyLayer(c layerRetriever, diffID string) error {
+	layerRC, err := c.RetrieveLayer(diffID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = layerRC.Close()
+	}()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, layerRC); err != nil {
+		return fmt.Errorf("hashing layer: %w", err)
+	}
+	foundDiffID := fmt.Sprintf("sha256:%x", hasher.Sum(nil))
+	if diffID != foundDiffID {
+		return NewReadErr(fmt.Sprintf("expected layer contents to have SHA '%s'; found '%s'", diffID, foundDiffID))
+	}
+	return nil
+}
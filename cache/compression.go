@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is how the layers in a VolumeCache are compressed on disk.
+// Layers are addressed by diffID regardless of compression, and are decompressed when they are retrieved,
+// so that a cache may hold both compressed layers and the uncompressed layers of older versions of the lifecycle.
+type Compression string
+
+const (
+	// NoCompression stores layers as uncompressed tars.
+	NoCompression Compression = ""
+	// GzipCompression stores layers as gzip-compressed tars.
+	GzipCompression Compression = "gzip"
+	// ZstdCompression stores layers as zstd-compressed tars.
+	ZstdCompression Compression = "zstd"
+)
+
+// layerExtensions are the file extensions of the layers in a VolumeCache, by compression.
+var layerExtensions = map[Compression]string{
+	NoCompression:   ".tar",
+	GzipCompression: ".tar.gz",
+	ZstdCompression: ".tar.zst",
+}
+
+// compressions are the supported compressions, in the order in which layer files are looked up.
+var compressions = []Compression{NoCompression, ZstdCompression, GzipCompression}
+
+func (c Compression) extension() string {
+	return layerExtensions[c]
+}
+
+// compressionOf returns the diffID of the layer stored in the file with the provided name, and how it is compressed.
+func compressionOf(name string) (string, Compression, bool) {
+	for _, compression := range compressions {
+		if diffID, ok := strings.CutSuffix(name, compression.extension()); ok {
+			return diffID, compression, true
+		}
+	}
+	return "", NoCompression, false
+}
+
+// findLayer returns the path of the layer with the provided diffID in the provided directory, and how it is compressed.
+// If the layer is not found, the returned error satisfies os.IsNotExist.
+func findLayer(dir, diffID string) (string, Compression, error) {
+	var firstErr error
+	for _, compression := range compressions {
+		path := filepath.Join(dir, diffID+compression.extension())
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, compression, nil
+		}
+		if firstErr == nil || !os.IsNotExist(err) {
+			firstErr = err
+		}
+	}
+	return "", NoCompression, firstErr
+}
+
+// removeLayer removes every file storing the layer with the provided diffID in the provided directory, except keep.
+func removeLayer(dir, diffID, keep string) error {
+	for _, compression := range compressions {
+		path := filepath.Join(dir, diffID+compression.extension())
+		if path == keep {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLayer writes the uncompressed layer read from r to the file at the provided path, compressed with the provided compression.
+// If the layer cannot be written, the partially written file is removed so that it is not mistaken for the layer.
+func writeLayer(path string, r io.Reader, compression Compression) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = copyLayer(fh, r, compression); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	if err = fh.Close(); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// copyLayer writes the layer read from r to fh, compressed with the provided compression; it does not close fh.
+func copyLayer(fh *os.File, r io.Reader, compression Compression) error {
+	var (
+		w   io.WriteCloser
+		err error
+	)
+	switch compression {
+	case GzipCompression:
+		w = gzip.NewWriter(fh)
+	case ZstdCompression:
+		if w, err = zstd.NewWriter(fh); err != nil {
+			return err
+		}
+	default:
+		_, err = io.Copy(fh, r)
+		return err
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// decompressedLayer is a layer file that is decompressed as it is read.
+type decompressedLayer struct {
+	io.Reader
+	closers []func() error
+}
+
+// Read returns a ReadErr if the layer cannot be decompressed, so that a corrupt layer is not used.
+func (l *decompressedLayer) Read(p []byte) (int, error) {
+	n, err := l.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		return n, NewReadErr(fmt.Sprintf("failed to decompress cache layer: %s", err))
+	}
+	return n, err
+}
+
+func (l *decompressedLayer) Close() error {
+	var err error
+	for _, closer := range l.closers {
+		if closeErr := closer(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// openLayer opens the layer file at the provided path, decompressing it with the provided compression.
+func openLayer(path string, compression Compression) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case GzipCompression:
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("decompressing '%s': %w", path, err)
+		}
+		return &decompressedLayer{Reader: gr, closers: []func() error{gr.Close, file.Close}}, nil
+	case ZstdCompression:
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("decompressing '%s': %w", path, err)
+		}
+		return &decompressedLayer{Reader: zr, closers: []func() error{func() error { zr.Close(); return nil }, file.Close}}, nil
+	default:
+		return file, nil
+	}
+}
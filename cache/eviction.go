@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// accessFile records when each layer in a VolumeCache was last used, as a JSON object mapping diffIDs to times.
+const accessFile = "access.json"
+
+// EvictionPolicy bounds the size and age of the layers in a VolumeCache.
+// Without a policy, committing the cache keeps only the layers added or reused by the build, and those committed by concurrent builds
+// until the next build commits.
+// With a policy, the layers committed by previous builds are kept as well, so that other builds sharing the cache volume can reuse them,
+// until they are evicted when the cache is committed: first any layer that has not been used for longer than MaxAge,
+// then the least recently used layers until the cache is no larger than MaxSize.
+// Layers referenced by the metadata of the cache are never evicted.
+type EvictionPolicy struct {
+	// MaxSize, if greater than zero, is the maximum total size in bytes of the layers in the cache.
+	MaxSize int64
+	// MaxAge, if greater than zero, is how long a layer may go unused before it is evicted.
+	MaxAge time.Duration
+}
+
+func (p EvictionPolicy) enabled() bool {
+	return p.MaxSize > 0 || p.MaxAge > 0
+}
+
+// EvictedLayer describes a layer that was evicted from the cache.
+type EvictedLayer struct {
+	DiffID   string
+	Size     int64
+	LastUsed time.Time
+}
+
+type cachedLayer struct {
+	diffID   string
+	size     int64
+	lastUsed time.Time
+}
+
+// SetEvictionPolicy configures the cache to evict layers according to the provided policy when it is committed.
+func (c *VolumeCache) SetEvictionPolicy(policy EvictionPolicy) {
+	c.eviction = policy
+}
+
+// Evicted returns the layers that were evicted when the cache was committed.
+func (c *VolumeCache) Evicted() []EvictedLayer {
+	return c.evicted
+}
+
+func (c *VolumeCache) recordAccess(diffID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessed == nil {
+		c.accessed = map[string]time.Time{}
+	}
+	c.accessed[diffID] = time.Now()
+}
+
+// evict removes the committed layers that should be evicted according to the eviction policy,
+// and records when each remaining layer was last used.
+// It must be called with an exclusive lock on the cache, after the layers and metadata of this build are committed.
+func (c *VolumeCache) evict() error {
+	accessPath := filepath.Join(c.committedDir, accessFile)
+	lastUsed, err := readAccessTimes(accessPath)
+	if err != nil {
+		return err
+	}
+	referenced, err := c.referencedLayers()
+	if err != nil {
+		return err
+	}
+	layers, err := c.committedLayers(lastUsed)
+	if err != nil {
+		return err
+	}
+
+	var totalSize int64
+	for _, layer := range layers {
+		totalSize += layer.size
+	}
+	var (
+		now      = time.Now()
+		freed    int64
+		accessed = map[string]time.Time{}
+	)
+	for _, layer := range layers {
+		expired := c.eviction.MaxAge > 0 && now.Sub(layer.lastUsed) > c.eviction.MaxAge
+		oversized := c.eviction.MaxSize > 0 && totalSize > c.eviction.MaxSize
+		if referenced[layer.diffID] || !(expired || oversized) {
+			accessed[layer.diffID] = layer.lastUsed
+			continue
+		}
+		if err = removeLayer(c.committedDir, layer.diffID, ""); err != nil {
+			return errors.Wrapf(err, "evicting layer (%s)", layer.diffID)
+		}
+		c.logger.Debugf("Evicted cache layer with SHA '%s', last used %s", layer.diffID, layer.lastUsed.Format(time.RFC3339))
+		c.evicted = append(c.evicted, EvictedLayer{DiffID: layer.diffID, Size: layer.size, LastUsed: layer.lastUsed})
+		totalSize -= layer.size
+		freed += layer.size
+	}
+	if len(c.evicted) > 0 {
+		c.logger.Infof("Evicted %d layer(s) from cache, freeing %d bytes", len(c.evicted), freed)
+	}
+	if c.eviction.MaxSize > 0 && totalSize > c.eviction.MaxSize {
+		c.logger.Warnf("Cache size of %d bytes exceeds the maximum of %d bytes because of layers used by this build", totalSize, c.eviction.MaxSize)
+	}
+	return writeAccessTimes(accessPath, accessed)
+}
+
+// referencedLayers returns the diffIDs of the layers referenced by the committed metadata.
+func (c *VolumeCache) referencedLayers() (map[string]bool, error) {
+	metadata, err := readMetadata(filepath.Join(c.committedDir, MetadataLabel))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	referenced := map[string]bool{metadata.BOM.SHA: true}
+	for _, bpMD := range metadata.Buildpacks {
+		for _, layer := range bpMD.Layers {
+			referenced[layer.SHA] = true
+		}
+	}
+	return referenced, nil
+}
+
+// committedLayers returns the committed layers, least recently used first.
+// A layer was last used at the later of the time recorded by previous builds and the time this build used it,
+// falling back to the modification time of the layer for layers committed before access times were recorded.
+func (c *VolumeCache) committedLayers(lastUsed map[string]time.Time) ([]cachedLayer, error) {
+	entries, err := os.ReadDir(c.committedDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading committed directory '%s'", c.committedDir)
+	}
+	var (
+		layers []cachedLayer
+		index  = map[string]int{} // a layer may be stored both compressed and uncompressed, if a build was interrupted while committing
+	)
+	for _, entry := range entries {
+		if !isLayerFile(entry) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		diffID, _, _ := compressionOf(entry.Name())
+		if i, ok := index[diffID]; ok {
+			layers[i].size += info.Size()
+			continue
+		}
+		layer := cachedLayer{diffID: diffID, size: info.Size(), lastUsed: info.ModTime()}
+		if t, ok := lastUsed[layer.diffID]; ok {
+			layer.lastUsed = t
+		}
+		if t, ok := c.accessed[layer.diffID]; ok && t.After(layer.lastUsed) {
+			layer.lastUsed = t
+		}
+		index[diffID] = len(layers)
+		layers = append(layers, layer)
+	}
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].lastUsed.Before(layers[j].lastUsed)
+	})
+	return layers, nil
+}
+
+func isLayerFile(entry os.DirEntry) bool {
+	_, _, ok := compressionOf(entry.Name())
+	return entry.Type().IsRegular() && ok
+}
+
+func readMetadata(path string) (platform.CacheMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return platform.CacheMetadata{}, err
+	}
+	defer file.Close()
+	var metadata platform.CacheMetadata
+	if err = json.NewDecoder(file).Decode(&metadata); err != nil {
+		return platform.CacheMetadata{}, errors.Wrapf(err, "decoding metadata file '%s'", path)
+	}
+	return metadata, nil
+}
+
+func readAccessTimes(path string) (map[string]time.Time, error) {
+	accessed := map[string]time.Time{}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return accessed, nil
+		}
+		return nil, errors.Wrapf(err, "reading access times '%s'", path)
+	}
+	if err = json.Unmarshal(contents, &accessed); err != nil {
+		// the access times only affect the order in which layers are evicted
+		return map[string]time.Time{}, nil
+	}
+	return accessed, nil
+}
+
+func writeAccessTimes(path string, accessed map[string]time.Time) error {
+	contents, err := json.Marshal(accessed)
+	if err != nil {
+		return errors.Wrap(err, "marshalling access times")
+	}
+	if err = os.WriteFile(path, contents, 0666); err != nil {
+		return errors.Wrapf(err, "writing access times '%s'", path)
+	}
+	return nil
+}
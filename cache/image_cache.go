@@ -150,7 +150,7 @@ func (c *ImageCache) Commit() error {
 		return errCacheCommitted
 	}
 
-	if err := c.newImage.Save(); err != nil {
+	if err := image.Retries.Do(c.logger, "save cache image "+c.newImage.Name(), func() error { return c.newImage.Save() }); err != nil {
 		return errors.Wrapf(err, "saving image '%s'", c.newImage.Name())
 	}
 	c.committed = true
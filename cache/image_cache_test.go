@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -297,5 +298,32 @@ func testImageCache(t *testing.T, when spec.G, it spec.S) {
 				h.AssertError(t, err, "cache cannot be modified after commit")
 			})
 		})
+
+		when("saving the image fails with a transient error", func() {
+			it("retries", func() {
+				newImage := &flakySaveImage{Image: fakeNewImage, failures: 1}
+				fakeImageDeleter := testmockcache.NewMockImageDeleter(gomock.NewController(t))
+				fakeImageDeleter.EXPECT().DeleteOrigImageIfDifferentFromNewImage(gomock.Any(), gomock.Any()).AnyTimes()
+				subject = cache.NewImageCache(fakeOriginalImage, newImage, testLogger, fakeImageDeleter)
+
+				h.AssertNil(t, subject.Commit())
+				h.AssertEq(t, newImage.saves, 2)
+			})
+		})
 	})
 }
+
+// flakySaveImage fails to save the first failures times.
+type flakySaveImage struct {
+	*fakes.Image
+	failures int
+	saves    int
+}
+
+func (i *flakySaveImage) Save(additionalNames ...string) error {
+	i.saves++
+	if i.saves <= i.failures {
+		return errors.New("connection reset by peer")
+	}
+	return i.Image.Save(additionalNames...)
+}
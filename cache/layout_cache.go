@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/layout"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	ggcrlayout "github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/log"
+)
+
+// LayoutCache is a cache stored as an image in OCI layout format in a directory (see platform.OCILayoutCacheScheme).
+// As the layers are stored as compressed blobs, the cache can be inspected with OCI tools,
+// copied between machines as a directory, and pushed to a registry to be used as an ImageCache.
+// Concurrent builds may share the directory: builds commit one at a time under an advisory file lock,
+// and the blobs that are no longer referenced by the image are only removed when the next build commits,
+// so that a build that started before can still restore or reuse them.
+type LayoutCache struct {
+	*ImageCache
+	path  string
+	image *layout.Image
+}
+
+// NewLayoutCache creates a new LayoutCache for the image in OCI layout format at the provided path.
+// If the path does not contain an image, the image is created when the cache is committed.
+func NewLayoutCache(path string, logger log.Logger) (*LayoutCache, error) {
+	platform := layout.WithDefaultPlatform(imgutil.Platform{OS: runtime.GOOS})
+	origImage, err := layout.NewImage(path, layout.FromBaseImagePath(path), platform)
+	if err != nil {
+		logger.Infof("Ignoring cache image %q because it was corrupt: %s", path, err)
+		if origImage, err = layout.NewImage(path, platform); err != nil {
+			return nil, fmt.Errorf("accessing cache image %q: %v", path, err)
+		}
+		// the image is replaced when the cache is committed
+		return newLayoutCache(path, origImage, logger, platform)
+	}
+	if !origImage.Found() {
+		return newLayoutCache(path, origImage, logger, platform)
+	}
+	return newLayoutCache(path, origImage, logger, layout.WithPreviousImage(path), platform)
+}
+
+func newLayoutCache(path string, origImage imgutil.Image, logger log.Logger, ops ...imgutil.ImageOption) (*LayoutCache, error) {
+	newImage, err := layout.NewImage(path, ops...)
+	if err != nil {
+		return nil, fmt.Errorf("creating new cache image %q: %v", path, err)
+	}
+	return &LayoutCache{
+		// the image is replaced in place, so there is never an original image to delete
+		ImageCache: NewImageCache(origImage, newImage, logger, nil),
+		path:       path,
+		image:      newImage,
+	}, nil
+}
+
+func (c *LayoutCache) ReuseLayer(diffID string) error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	if err := c.newImage.ReuseLayer(diffID); err != nil {
+		rc, getErr := c.origImage.GetLayer(diffID)
+		if getErr != nil {
+			return NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+		}
+		_ = rc.Close()
+		return fmt.Errorf("failed to reuse cache layer with SHA '%s': %w", diffID, err)
+	}
+	return nil
+}
+
+// RetrieveLayer retrieves a layer from the cache.
+// Unlike a registry, the layout directory does not guard against corrupt blobs, so any failure to read a layer is a read error.
+func (c *LayoutCache) RetrieveLayer(diffID string) (io.ReadCloser, error) {
+	rc, err := c.origImage.GetLayer(diffID)
+	if err != nil {
+		if isLayerNotFound(err) {
+			return nil, NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+		}
+		return nil, NewReadErr(fmt.Sprintf("failed to read cache layer with SHA '%s': %s", diffID, err))
+	}
+	return rc, nil
+}
+
+// Commit saves the cache image in place, replacing the index of the directory atomically so that a concurrent build
+// never reads a partially written index, and removes the blobs that were already unused when the previous build committed.
+func (c *LayoutCache) Commit() error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	if err := os.MkdirAll(c.path, 0777); err != nil {
+		return errors.Wrapf(err, "creating cache image '%s'", c.path)
+	}
+	lock, err := acquireLock(filepath.Join(c.path, cacheLockFile), true)
+	if err != nil {
+		return errors.Wrapf(err, "locking cache image '%s'", c.path)
+	}
+	defer lock.release()
+
+	if err = saveLayout(c.path, c.image); err != nil {
+		return errors.Wrapf(err, "saving cache image '%s'", c.path)
+	}
+	c.committed = true
+	c.origImage = c.newImage
+
+	if err = removeUnusedBlobs(c.path); err != nil {
+		c.logger.Warnf("Failed to remove unused blobs from cache image '%s': %s", c.path, err)
+	}
+	return nil
+}
+
+// VerifyLayer returns an error if the layer contents do not match the provided sha.
+func (c *LayoutCache) VerifyLayer(diffID string) error {
+	return verifyLayer(c, diffID)
+}
+
+// saveLayout writes the blobs of the image to the OCI layout at the provided path, skipping the blobs it already contains,
+// then writes an index that refers only to the image to a temporary file, and renames it over the index of the layout.
+// It must be called with an exclusive lock on the layout.
+func saveLayout(path string, image *layout.Image) error {
+	if err := image.SetCreatedAtAndHistory(); err != nil {
+		return err
+	}
+	img := image.UnderlyingImage()
+	if _, err := os.Stat(filepath.Join(path, "oci-layout")); os.IsNotExist(err) {
+		if _, err = ggcrlayout.Write(path, empty.Index); err != nil {
+			return err
+		}
+	}
+	if err := ggcrlayout.Path(path).WriteImage(img); err != nil {
+		return err
+	}
+	index, err := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{Add: img}).RawManifest()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(path, "index-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(index); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(path, "index.json"))
+}
+
+// removeUnusedBlobs removes the blobs in the OCI layout at the provided path that are not referenced by the images in its index,
+// and that were already recorded as unused by the previous build to commit, such as the layers of builds before the previous one.
+// It then records the remaining blobs that the index does not reference.
+// It must be called with an exclusive lock on the layout, after the index of this build is saved.
+func removeUnusedBlobs(path string) error {
+	unusedPath := filepath.Join(path, unusedFile)
+	recorded, err := readUnusedLayers(unusedPath)
+	if err != nil {
+		return err
+	}
+	wasUnused := map[string]bool{}
+	for _, digest := range recorded {
+		wasUnused[digest] = true
+	}
+
+	layoutPath, err := ggcrlayout.FromPath(path)
+	if err != nil {
+		return err
+	}
+	index, err := layoutPath.ImageIndex()
+	if err != nil {
+		return err
+	}
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+	referenced := map[string]bool{}
+	for _, desc := range indexManifest.Manifests {
+		referenced[desc.Digest.String()] = true
+		img, err := layoutPath.Image(desc.Digest)
+		if err != nil {
+			return err
+		}
+		manifest, err := img.Manifest()
+		if err != nil {
+			return err
+		}
+		referenced[manifest.Config.Digest.String()] = true
+		for _, layer := range manifest.Layers {
+			referenced[layer.Digest.String()] = true
+		}
+	}
+
+	var unused []string
+	blobsDir := filepath.Join(path, "blobs")
+	algorithms, err := os.ReadDir(blobsDir)
+	if err != nil {
+		return err
+	}
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+		blobs, err := os.ReadDir(filepath.Join(blobsDir, algorithm.Name()))
+		if err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			digest := algorithm.Name() + ":" + blob.Name()
+			switch {
+			case referenced[digest]:
+			case wasUnused[digest]:
+				if err = os.Remove(filepath.Join(blobsDir, algorithm.Name(), blob.Name())); err != nil {
+					return err
+				}
+			default:
+				unused = append(unused, digest)
+			}
+		}
+	}
+	return writeUnusedLayers(unusedPath, unused)
+}
@@ -0,0 +1,248 @@
+package cache_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestLayoutCache(t *testing.T) {
+	spec.Run(t, "LayoutCache", testLayoutCache, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testLayoutCache(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir    string
+		cachePath string
+		subject   *cache.LayoutCache
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.cache.layout_cache")
+		h.AssertNil(t, err)
+		cachePath = filepath.Join(tmpDir, "layout-repo", "cache", "latest")
+
+		subject, err = cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	// build commits a cache with the provided metadata, reusing and adding the provided layers
+	build := func(metadata platform.CacheMetadata, reuse []string, add ...string) {
+		subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+		h.AssertNil(t, err)
+		for _, diffID := range reuse {
+			h.AssertNil(t, subject.ReuseLayer(diffID))
+		}
+		for _, layerPath := range add {
+			diffID := "sha256:" + h.ComputeSHA256ForFile(t, layerPath)
+			h.AssertNil(t, subject.AddLayerFile(layerPath, diffID))
+		}
+		h.AssertNil(t, subject.SetMetadata(metadata))
+		h.AssertNil(t, subject.Commit())
+	}
+
+	blobs := func() []string {
+		entries, err := os.ReadDir(filepath.Join(cachePath, "blobs", "sha256"))
+		h.AssertNil(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	when("#NewLayoutCache", func() {
+		when("the path does not contain an image", func() {
+			it("does not exist and has empty metadata", func() {
+				h.AssertEq(t, subject.Exists(), false)
+				metadata, err := subject.RetrieveMetadata()
+				h.AssertNil(t, err)
+				h.AssertEq(t, metadata, platform.CacheMetadata{})
+			})
+		})
+
+		when("the path contains a corrupt image", func() {
+			it.Before(func() {
+				h.AssertNil(t, os.MkdirAll(cachePath, 0777))
+				h.AssertNil(t, os.WriteFile(filepath.Join(cachePath, "index.json"), []byte("garbage"), 0600))
+			})
+
+			it("ignores the image", func() {
+				var err error
+				subject, err = cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+				h.AssertNil(t, err)
+
+				metadata, err := subject.RetrieveMetadata()
+				h.AssertNil(t, err)
+				h.AssertEq(t, metadata, platform.CacheMetadata{})
+
+				h.AssertNil(t, subject.Commit())
+				_, err = layout.FromPath(cachePath)
+				h.AssertNil(t, err)
+			})
+		})
+	})
+
+	when("#Commit", func() {
+		var (
+			layerPath, layerSHA string
+			layerData           []byte
+			otherPath, otherSHA string
+			thirdPath, thirdSHA string
+			metadata            platform.CacheMetadata
+		)
+
+		it.Before(func() {
+			layerPath, layerSHA, layerData = h.RandomLayer(t, tmpDir)
+			otherPath, otherSHA, _ = h.RandomLayer(t, tmpDir)
+			thirdPath, thirdSHA, _ = h.RandomLayer(t, tmpDir)
+			metadata = platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID:     "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: layerSHA}},
+				}},
+			}
+		})
+
+		it("stores the layers and metadata as an image in OCI layout format", func() {
+			build(metadata, nil, layerPath)
+
+			subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertEq(t, subject.Exists(), true)
+			retrieved, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, retrieved, metadata)
+
+			rc, err := subject.RetrieveLayer(layerSHA)
+			h.AssertNil(t, err)
+			defer rc.Close()
+			contents, err := io.ReadAll(rc)
+			h.AssertNil(t, err)
+			h.AssertEq(t, contents, layerData)
+			h.AssertNil(t, subject.VerifyLayer(layerSHA))
+
+			layoutPath, err := layout.FromPath(cachePath)
+			h.AssertNil(t, err)
+			index, err := layoutPath.ImageIndex()
+			h.AssertNil(t, err)
+			indexManifest, err := index.IndexManifest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(indexManifest.Manifests), 1)
+		})
+
+		it("keeps reused layers and removes the blobs of layers that are no longer used when the next build commits", func() {
+			build(metadata, nil, layerPath, otherPath)
+			blobsBefore := len(blobs())
+
+			build(metadata, []string{layerSHA}, thirdPath)
+			h.AssertEq(t, len(blobs()), blobsBefore+3) // the config, manifest and layer of this build
+
+			build(metadata, []string{layerSHA, thirdSHA})
+			h.AssertEq(t, len(blobs()), blobsBefore)
+			subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.VerifyLayer(layerSHA))
+			h.AssertNil(t, subject.VerifyLayer(thirdSHA))
+			_, err = subject.RetrieveLayer(otherSHA)
+			isReadErr, _ := cache.IsReadErr(err)
+			h.AssertEq(t, isReadErr, true)
+		})
+
+		it("keeps the layers of the image a concurrent build opened until the next build commits", func() {
+			build(metadata, nil, layerPath, otherPath)
+			concurrent, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+
+			build(metadata, []string{layerSHA}, thirdPath)
+
+			rc, err := concurrent.RetrieveLayer(otherSHA)
+			h.AssertNil(t, err)
+			h.AssertNil(t, rc.Close())
+			h.AssertNil(t, concurrent.ReuseLayer(otherSHA))
+			h.AssertNil(t, concurrent.SetMetadata(metadata))
+			h.AssertNil(t, concurrent.Commit())
+
+			subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.VerifyLayer(otherSHA))
+			_, err = subject.RetrieveLayer(thirdSHA)
+			isReadErr, _ := cache.IsReadErr(err)
+			h.AssertEq(t, isReadErr, true)
+		})
+
+		it("replaces the index without leaving temporary files in the directory", func() {
+			build(metadata, nil, layerPath)
+			build(metadata, []string{layerSHA}, otherPath)
+
+			entries, err := os.ReadDir(cachePath)
+			h.AssertNil(t, err)
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			h.AssertEq(t, names, []string{"blobs", "index.json", "lock", "oci-layout", "unused.json"})
+		})
+
+		it("fails with a read error when reusing a layer that is not in the cache", func() {
+			build(metadata, nil, layerPath)
+
+			subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			err = subject.ReuseLayer(otherSHA)
+			isReadErr, _ := cache.IsReadErr(err)
+			h.AssertEq(t, isReadErr, true)
+		})
+
+		it("fails verification when a layer is corrupt", func() {
+			build(metadata, nil, layerPath)
+
+			for _, blob := range blobs() {
+				path := filepath.Join(cachePath, "blobs", "sha256", blob)
+				contents, err := os.ReadFile(path)
+				h.AssertNil(t, err)
+				if !bytes.HasPrefix(contents, []byte{0x1f, 0x8b}) { // not a compressed layer
+					continue
+				}
+				var corrupt bytes.Buffer
+				gw := gzip.NewWriter(&corrupt)
+				_, err = gw.Write([]byte("corrupt"))
+				h.AssertNil(t, err)
+				h.AssertNil(t, gw.Close())
+				h.AssertNil(t, os.WriteFile(path, corrupt.Bytes(), 0600))
+			}
+
+			subject, err := cache.NewLayoutCache(cachePath, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			err = subject.VerifyLayer(layerSHA)
+			isReadErr, _ := cache.IsReadErr(err)
+			h.AssertEq(t, isReadErr, true)
+		})
+
+		it("cannot be modified after commit", func() {
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertError(t, subject.AddLayerFile(layerPath, layerSHA), "cache cannot be modified after commit")
+			h.AssertError(t, subject.ReuseLayer(layerSHA), "cache cannot be modified after commit")
+			h.AssertError(t, subject.Commit(), "cache cannot be modified after commit")
+		})
+	})
+}
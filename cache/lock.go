@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"errors"
+	"os"
+)
+
+// errLocked is returned by tryLock when the lock is held elsewhere.
+var errLocked = errors.New("lock is held by another build")
+
+// fileLock is an advisory lock on a file, held through an open file description,
+// so that it excludes other processes as well as other goroutines of the same process that open the file.
+// The lock is released when the lock is released, the file is closed or the process exits.
+type fileLock struct {
+	file *os.File
+}
+
+// acquireLock creates the file at the provided path if it does not exist,
+// and waits for a shared or an exclusive lock on it.
+func acquireLock(path string, exclusive bool) (*fileLock, error) {
+	return openLock(path, exclusive, true)
+}
+
+// tryLock is like acquireLock for an exclusive lock, but returns errLocked instead of waiting.
+func tryLock(path string) (*fileLock, error) {
+	return openLock(path, true, false)
+}
+
+func openLock(path string, exclusive, wait bool) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err = lockFile(file, exclusive, wait); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) release() {
+	_ = unlockFile(l.file)
+	_ = l.file.Close()
+}
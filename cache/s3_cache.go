@@ -0,0 +1,562 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+const (
+	s3ManifestKey   = "manifest.json"
+	s3DefaultRegion = "us-east-1"
+	// s3DiffIDMetadata is the user-defined metadata of a layer object recording the diffID of its contents,
+	// which the lifecycle verifies before uploading the layer.
+	s3DiffIDMetadata = "diff-id"
+	// s3DefaultPartSize is the default size of the parts in which large layers are uploaded.
+	s3DefaultPartSize = 64 << 20
+	// s3MaxParts is the maximum number of parts in an upload.
+	s3MaxParts = 10000
+)
+
+// S3Cache is a cache stored in a bucket of an S3-compatible object store, below a prefix:
+//
+//	<prefix>/layers/sha256/<hex>   a layer by diffID, as an uncompressed tar
+//	<prefix>/metadata/sha256/<hex> the metadata of a build, by the digest of its contents
+//	<prefix>/manifest.json         the committed metadata and layers
+//
+// Layers and metadata are uploaded before the manifest, which is replaced by a single request when the cache is committed,
+// so a build never observes metadata that refers to layers that have not been uploaded.
+// Concurrent builds sharing a cache are last-writer-wins: the manifest of the last build to commit replaces the others.
+// Objects are removed one commit after they are no longer used (see Commit), so a layer reused by a build is only missing
+// when the build commits if two other builds committed without using it in the meantime; later builds then rebuild the layer.
+// Requests to the object store are cancelled with the context provided to the context-aware variant of each method
+// (see phase.ContextCache).
+type S3Cache struct {
+	committed bool
+	client    *s3.Client
+	bucket    string
+	prefix    string
+	name      string
+	logger    log.Logger
+	partSize  int64
+
+	found    bool
+	manifest s3Manifest // the manifest committed when the cache was opened
+
+	metadata *platform.CacheMetadata // the metadata set by this build
+	layers   map[string]bool         // the layers added or reused by this build
+}
+
+type s3Manifest struct {
+	Metadata string   `json:"metadata,omitempty"`
+	Layers   []string `json:"layers"`
+	// Unused are the keys of the objects used by the manifest this manifest replaced, but not by this manifest,
+	// which are removed when the next build commits unless it uses them.
+	Unused []string `json:"unused,omitempty"`
+}
+
+// S3Location is the location of a cache in an S3-compatible object store.
+type S3Location struct {
+	Bucket string
+	Prefix string
+	// Endpoint, if set, is the URL of an S3-compatible object store to use instead of AWS, with path-style addressing.
+	Endpoint string
+	// Region, if set, overrides the region configured in the environment.
+	Region string
+}
+
+// ParseS3URL parses a cache URL of the form s3://<bucket>/<prefix>?endpoint=<url>&region=<region>,
+// where the prefix, endpoint and region are optional.
+func ParseS3URL(cacheURL string) (S3Location, error) {
+	u, err := url.Parse(cacheURL)
+	if err != nil {
+		return S3Location{}, fmt.Errorf("failed to parse cache URL '%s': %w", cacheURL, err)
+	}
+	if u.Scheme != "s3" {
+		return S3Location{}, fmt.Errorf("failed to parse cache URL '%s': unsupported scheme '%s'", cacheURL, u.Scheme)
+	}
+	if u.Host == "" {
+		return S3Location{}, fmt.Errorf("failed to parse cache URL '%s': missing bucket", cacheURL)
+	}
+	query := u.Query()
+	return S3Location{
+		Bucket:   u.Host,
+		Prefix:   strings.Trim(u.Path, "/"),
+		Endpoint: query.Get("endpoint"),
+		Region:   query.Get("region"),
+	}, nil
+}
+
+// NewS3CacheFromURL creates a new S3Cache from the provided cache URL (see ParseS3URL),
+// using the credentials and configuration for AWS found in the environment.
+func NewS3CacheFromURL(cacheURL string, logger log.Logger) (*S3Cache, error) {
+	location, err := ParseS3URL(cacheURL)
+	if err != nil {
+		return nil, err
+	}
+	opts := []func(*config.LoadOptions) error{
+		// S3-compatible object stores may not support the checksums the SDK adds by default
+		config.WithRequestChecksumCalculation(aws.RequestChecksumCalculationWhenRequired),
+		config.WithResponseChecksumValidation(aws.ResponseChecksumValidationWhenRequired),
+	}
+	if location.Region != "" {
+		opts = append(opts, config.WithRegion(location.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading configuration for cache '%s': %w", cacheURL, err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if location.Endpoint != "" {
+			o.BaseEndpoint = aws.String(location.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return NewS3Cache(client, location.Bucket, location.Prefix, cacheURL, logger)
+}
+
+// NewS3Cache creates a new S3Cache in the provided bucket below the provided prefix, and reads the committed manifest, if any.
+func NewS3Cache(client *s3.Client, bucket, prefix, name string, logger log.Logger) (*S3Cache, error) {
+	c := &S3Cache{
+		client:   client,
+		bucket:   bucket,
+		prefix:   prefix,
+		name:     name,
+		logger:   logger,
+		partSize: s3DefaultPartSize,
+		layers:   map[string]bool{},
+	}
+	body, err := c.get(context.Background(), s3ManifestKey)
+	if err != nil {
+		if isS3NotFound(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("accessing cache %q: %w", name, err)
+	}
+	defer body.Close()
+	c.found = true
+	if err = json.NewDecoder(body).Decode(&c.manifest); err != nil {
+		logger.Infof("Ignoring cache %q because its manifest was corrupt", name)
+		c.manifest = s3Manifest{}
+	}
+	return c, nil
+}
+
+// SetPartSize configures the size of the parts in which layers are uploaded when they are larger than the part size,
+// as a single request cannot upload an object larger than 5 GiB.
+func (c *S3Cache) SetPartSize(size int64) {
+	c.partSize = size
+}
+
+func (c *S3Cache) Exists() bool {
+	return c.found
+}
+
+func (c *S3Cache) Name() string {
+	return c.name
+}
+
+func (c *S3Cache) SetMetadata(metadata platform.CacheMetadata) error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	c.metadata = &metadata
+	return nil
+}
+
+func (c *S3Cache) RetrieveMetadata() (platform.CacheMetadata, error) {
+	return c.RetrieveMetadataContext(context.Background())
+}
+
+// RetrieveMetadataContext is like RetrieveMetadata, but cancels the request when the provided context is done.
+func (c *S3Cache) RetrieveMetadataContext(ctx context.Context) (platform.CacheMetadata, error) {
+	if c.manifest.Metadata == "" {
+		return platform.CacheMetadata{}, nil
+	}
+	body, err := c.get(ctx, c.manifest.Metadata)
+	if err != nil {
+		if isS3NotFound(err) {
+			return platform.CacheMetadata{}, nil
+		}
+		return platform.CacheMetadata{}, errors.Wrapf(err, "retrieving metadata object '%s'", c.manifest.Metadata)
+	}
+	defer body.Close()
+
+	metadata := platform.CacheMetadata{}
+	if json.NewDecoder(body).Decode(&metadata) != nil {
+		return platform.CacheMetadata{}, nil
+	}
+	return metadata, nil
+}
+
+// AddLayerFile uploads the layer, unless the cache already has a layer with the provided diffID.
+// The contents of the layer are verified against the diffID before they are uploaded, and the diffID is stored with the layer,
+// so that a layer that does not match a diffID is rejected without downloading it (see VerifyLayer).
+func (c *S3Cache) AddLayerFile(tarPath string, diffID string) error {
+	return c.AddLayerFileContext(context.Background(), tarPath, diffID)
+}
+
+// AddLayerFileContext is like AddLayerFile, but cancels the upload when the provided context is done.
+func (c *S3Cache) AddLayerFileContext(ctx context.Context, tarPath string, diffID string) error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	key := layerKey(diffID)
+	stored, _, err := c.storedDiffID(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "caching layer (%s)", diffID)
+	}
+	if stored != diffID {
+		if err = c.putLayer(ctx, key, tarPath, diffID); err != nil {
+			return errors.Wrapf(err, "caching layer (%s)", diffID)
+		}
+	}
+	c.layers[diffID] = true
+	return nil
+}
+
+func (c *S3Cache) ReuseLayer(diffID string) error {
+	return c.ReuseLayerContext(context.Background(), diffID)
+}
+
+// ReuseLayerContext is like ReuseLayer, but cancels the request when the provided context is done.
+func (c *S3Cache) ReuseLayerContext(ctx context.Context, diffID string) error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	exists, err := c.exists(ctx, layerKey(diffID))
+	if err != nil {
+		return fmt.Errorf("failed to re-use cache layer with SHA '%s': %w", diffID, err)
+	}
+	if !exists {
+		return NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+	}
+	c.layers[diffID] = true
+	return nil
+}
+
+// RetrieveLayer downloads the layer to a temporary file, which is removed when the returned reader is closed,
+// and returns a read error if its contents do not match the provided diffID.
+func (c *S3Cache) RetrieveLayer(diffID string) (io.ReadCloser, error) {
+	return c.RetrieveLayerContext(context.Background(), diffID)
+}
+
+// RetrieveLayerContext is like RetrieveLayer, but cancels the download when the provided context is done.
+func (c *S3Cache) RetrieveLayerContext(ctx context.Context, diffID string) (io.ReadCloser, error) {
+	body, err := c.get(ctx, layerKey(diffID))
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+		}
+		return nil, fmt.Errorf("failed to get cache layer with SHA '%s': %w", diffID, err)
+	}
+	defer body.Close()
+
+	file, err := os.CreateTemp("", "lifecycle.cache.s3.*.tar")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temporary file for layer")
+	}
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(file, hasher), body); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, NewReadErr(fmt.Sprintf("failed to download cache layer with SHA '%s': %s", diffID, err))
+	}
+	if foundDiffID := fmt.Sprintf("sha256:%x", hasher.Sum(nil)); diffID != foundDiffID {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, NewReadErr(fmt.Sprintf("expected layer contents to have SHA '%s'; found '%s'", diffID, foundDiffID))
+	}
+	return &removeOnClose{File: file}, nil
+}
+
+// VerifyLayer returns a read error if the contents of the layer do not match the provided diffID.
+// The diffID stored with the layer when it was uploaded (see AddLayerFile) is checked first,
+// so that a layer that cannot match is not downloaded; the contents are then hashed as they are downloaded, without being stored.
+func (c *S3Cache) VerifyLayer(diffID string) error {
+	return c.VerifyLayerContext(context.Background(), diffID)
+}
+
+// VerifyLayerContext is like VerifyLayer, but cancels the download when the provided context is done.
+func (c *S3Cache) VerifyLayerContext(ctx context.Context, diffID string) error {
+	key := layerKey(diffID)
+	stored, found, err := c.storedDiffID(ctx, key)
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to verify cache layer with SHA '%s': %w", diffID, err)
+	case !found:
+		return NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+	case stored == "":
+		return NewReadErr(fmt.Sprintf("cache layer with SHA '%s' was not verified when it was uploaded", diffID))
+	case stored != diffID:
+		return NewReadErr(fmt.Sprintf("expected layer contents to have SHA '%s'; found '%s'", diffID, stored))
+	}
+
+	body, err := c.get(ctx, key)
+	if err != nil {
+		if isS3NotFound(err) {
+			return NewReadErr(fmt.Sprintf("failed to find cache layer with SHA '%s'", diffID))
+		}
+		return fmt.Errorf("failed to verify cache layer with SHA '%s': %w", diffID, err)
+	}
+	defer body.Close()
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, body); err != nil {
+		return NewReadErr(fmt.Sprintf("failed to download cache layer with SHA '%s': %s", diffID, err))
+	}
+	if foundDiffID := fmt.Sprintf("sha256:%x", hasher.Sum(nil)); diffID != foundDiffID {
+		return NewReadErr(fmt.Sprintf("expected layer contents to have SHA '%s'; found '%s'", diffID, foundDiffID))
+	}
+	return nil
+}
+
+// Commit uploads the metadata set by this build and replaces the manifest.
+// Objects are removed one commit after they are no longer used, so that a concurrent build that reused them can still commit:
+// the manifest records the objects of the manifest it replaced that this build did not use,
+// and the next build to commit removes them unless it used them.
+func (c *S3Cache) Commit() error {
+	return c.CommitContext(context.Background())
+}
+
+// CommitContext is like Commit, but cancels the requests when the provided context is done.
+func (c *S3Cache) CommitContext(ctx context.Context) error {
+	if c.committed {
+		return errCacheCommitted
+	}
+	c.committed = true
+
+	manifest := s3Manifest{Layers: []string{}}
+	for diffID := range c.layers {
+		manifest.Layers = append(manifest.Layers, diffID)
+	}
+	sort.Strings(manifest.Layers)
+	if c.metadata != nil {
+		data, err := json.Marshal(c.metadata)
+		if err != nil {
+			return errors.Wrap(err, "serializing metadata")
+		}
+		manifest.Metadata = path.Join("metadata", "sha256", fmt.Sprintf("%x", sha256.Sum256(data)))
+		if err = c.put(ctx, manifest.Metadata, bytes.NewReader(data)); err != nil {
+			return errors.Wrap(err, "uploading metadata")
+		}
+	}
+
+	// another build may have committed since the cache was opened, so the manifest is read again
+	replaced, err := c.latestManifest(ctx)
+	if err != nil && !isS3NotFound(err) {
+		c.logger.Warnf("Not removing unused objects from cache: %s", err)
+	}
+	used := map[string]bool{manifest.Metadata: true}
+	for _, diffID := range manifest.Layers {
+		used[layerKey(diffID)] = true
+	}
+	var unused []string
+	for _, diffID := range replaced.Layers {
+		if key := layerKey(diffID); !used[key] {
+			manifest.Unused = append(manifest.Unused, key)
+		}
+	}
+	if replaced.Metadata != "" && !used[replaced.Metadata] {
+		manifest.Unused = append(manifest.Unused, replaced.Metadata)
+	}
+	for _, key := range replaced.Unused {
+		if !used[key] && !slices.Contains(manifest.Unused, key) {
+			unused = append(unused, key)
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "serializing manifest")
+	}
+	if err = c.put(ctx, s3ManifestKey, bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "committing cache")
+	}
+	for _, key := range unused {
+		if err = c.delete(ctx, key); err != nil && !isS3NotFound(err) {
+			c.logger.Warnf("Failed to remove unused object '%s' from cache: %s", key, err)
+		}
+	}
+	c.found = true
+	c.manifest = manifest
+	return nil
+}
+
+// latestManifest reads the committed manifest; a corrupt manifest is read as an empty manifest.
+func (c *S3Cache) latestManifest(ctx context.Context) (s3Manifest, error) {
+	body, err := c.get(ctx, s3ManifestKey)
+	if err != nil {
+		return s3Manifest{}, err
+	}
+	defer body.Close()
+	var manifest s3Manifest
+	if err = json.NewDecoder(body).Decode(&manifest); err != nil {
+		return s3Manifest{}, nil
+	}
+	return manifest, nil
+}
+
+func layerKey(diffID string) string {
+	return path.Join("layers", strings.Replace(diffID, ":", "/", 1))
+}
+
+func (c *S3Cache) key(name string) *string {
+	return aws.String(path.Join(c.prefix, name))
+}
+
+func (c *S3Cache) get(ctx context.Context, name string) (io.ReadCloser, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(c.bucket), Key: c.key(name)})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (c *S3Cache) put(ctx context.Context, name string, body io.Reader) error {
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(c.bucket), Key: c.key(name), Body: body})
+	return err
+}
+
+// putLayer verifies that the contents of the layer file at the provided path match the provided diffID,
+// and uploads it with its diffID, in parts if it is larger than the part size.
+func (c *S3Cache) putLayer(ctx context.Context, name, tarPath, diffID string) error {
+	file, err := os.Open(tarPath) // #nosec G304
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return err
+	}
+	if foundDiffID := fmt.Sprintf("sha256:%x", hasher.Sum(nil)); diffID != foundDiffID {
+		return fmt.Errorf("expected layer contents to have SHA '%s'; found '%s'", diffID, foundDiffID)
+	}
+	metadata := map[string]string{s3DiffIDMetadata: diffID}
+	if size <= c.partSize {
+		_, err = c.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(c.bucket),
+			Key:           c.key(name),
+			Body:          io.NewSectionReader(file, 0, size),
+			ContentLength: aws.Int64(size),
+			Metadata:      metadata,
+		})
+		return err
+	}
+	return c.putParts(ctx, name, file, size, metadata)
+}
+
+// putParts uploads the provided file in parts of at least the part size.
+func (c *S3Cache) putParts(ctx context.Context, name string, file io.ReaderAt, size int64, metadata map[string]string) error {
+	partSize := max(c.partSize, (size+s3MaxParts-1)/s3MaxParts)
+	upload, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{Bucket: aws.String(c.bucket), Key: c.key(name), Metadata: metadata})
+	if err != nil {
+		return err
+	}
+	var parts []types.CompletedPart
+	for offset, number := int64(0), int32(1); offset < size; offset, number = offset+partSize, number+1 {
+		length := min(partSize, size-offset)
+		out, err := c.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(c.bucket),
+			Key:           c.key(name),
+			UploadId:      upload.UploadId,
+			PartNumber:    aws.Int32(number),
+			Body:          io.NewSectionReader(file, offset, length),
+			ContentLength: aws.Int64(length),
+		})
+		if err != nil {
+			c.abortUpload(ctx, name, upload.UploadId)
+			return fmt.Errorf("uploading part %d: %w", number, err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(number)})
+	}
+	_, err = c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.bucket),
+		Key:             c.key(name),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		c.abortUpload(ctx, name, upload.UploadId)
+	}
+	return err
+}
+
+// abortUpload aborts the provided upload, so that the object store removes the parts already uploaded,
+// even if the upload failed because the provided context is done.
+func (c *S3Cache) abortUpload(ctx context.Context, name string, uploadID *string) {
+	_, err := c.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{Bucket: aws.String(c.bucket), Key: c.key(name), UploadId: uploadID})
+	if err != nil {
+		c.logger.Warnf("Failed to abort upload of '%s' to cache: %s", name, err)
+	}
+}
+
+// storedDiffID returns the diffID stored with the layer object with the provided name (see putLayer), and whether the object exists.
+func (c *S3Cache) storedDiffID(ctx context.Context, name string) (string, bool, error) {
+	out, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(c.bucket), Key: c.key(name)})
+	if err != nil {
+		if isS3NotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return out.Metadata[s3DiffIDMetadata], true, nil
+}
+
+func (c *S3Cache) exists(ctx context.Context, name string) (bool, error) {
+	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(c.bucket), Key: c.key(name)})
+	if err != nil {
+		if isS3NotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *S3Cache) delete(ctx context.Context, name string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(c.bucket), Key: c.key(name)})
+	return err
+}
+
+func isS3NotFound(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == 404
+}
+
+// removeOnClose removes a temporary file when it is closed.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.File.Name())
+	return err
+}
@@ -0,0 +1,429 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestS3Cache(t *testing.T) {
+	spec.Run(t, "S3Cache", testS3Cache, spec.Report(report.Terminal{}))
+}
+
+// fakeS3 is an S3-compatible object store with path-style addressing that keeps objects in memory.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	metadata map[string]http.Header       // the user-defined metadata of each object
+	uploads  map[string]map[string][]byte // the parts of each multipart upload, by part number
+	gets     map[string]int               // the number of times each object was downloaded
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:  map[string][]byte{},
+		metadata: map[string]http.Header{},
+		uploads:  map[string]map[string][]byte{},
+		gets:     map[string]int{},
+	}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(s.uploads))
+		s.uploads[uploadID] = map[string][]byte{}
+		s.metadata[uploadID] = userMetadata(r.Header)
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><InitiateMultipartUploadResult><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, key, uploadID)
+	case r.Method == http.MethodPut && uploadID != "":
+		contents, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.uploads[uploadID][query.Get("partNumber")] = contents
+		w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && uploadID != "":
+		var contents []byte
+		for number := 1; number <= len(s.uploads[uploadID]); number++ {
+			contents = append(contents, s.uploads[uploadID][strconv.Itoa(number)]...)
+		}
+		s.objects[key] = contents
+		s.metadata[key] = s.metadata[uploadID]
+		delete(s.uploads, uploadID)
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><CompleteMultipartUploadResult><Key>%s</Key></CompleteMultipartUploadResult>`, key)
+	case r.Method == http.MethodPut:
+		contents, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[key] = contents
+		s.metadata[key] = userMetadata(r.Header)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		contents, ok := s.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+			}
+			return
+		}
+		for name, values := range s.metadata[key] {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			s.gets[key]++
+			_, _ = w.Write(contents)
+		}
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		delete(s.metadata, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func userMetadata(header http.Header) http.Header {
+	metadata := http.Header{}
+	for name, values := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			metadata[name] = values
+		}
+	}
+	return metadata
+}
+
+func (s *fakeS3) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func testS3Cache(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir   string
+		store    *fakeS3
+		server   *httptest.Server
+		cacheURL string
+		subject  *cache.S3Cache
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.cache.s3_cache")
+		h.AssertNil(t, err)
+
+		store = newFakeS3()
+		server = httptest.NewServer(store)
+		cacheURL = "s3://some-bucket/some/prefix?endpoint=" + server.URL
+
+		for key, value := range map[string]string{
+			"AWS_ACCESS_KEY_ID":           "some-access-key",
+			"AWS_SECRET_ACCESS_KEY":       "some-secret-key",
+			"AWS_REGION":                  "",
+			"AWS_CONFIG_FILE":             filepath.Join(tmpDir, "config"),
+			"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(tmpDir, "credentials"),
+			"AWS_EC2_METADATA_DISABLED":   "true",
+		} {
+			t.Setenv(key, value)
+		}
+
+		subject, err = cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		server.Close()
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	// build commits a cache with the provided metadata, reusing and adding the provided layers
+	build := func(metadata platform.CacheMetadata, reuse []string, add ...string) {
+		subject, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+		h.AssertNil(t, err)
+		for _, diffID := range reuse {
+			h.AssertNil(t, subject.ReuseLayer(diffID))
+		}
+		for _, layerPath := range add {
+			h.AssertNil(t, subject.AddLayerFile(layerPath, "sha256:"+h.ComputeSHA256ForFile(t, layerPath)))
+		}
+		h.AssertNil(t, subject.SetMetadata(metadata))
+		h.AssertNil(t, subject.Commit())
+	}
+
+	layerKey := func(diffID string) string {
+		return "some-bucket/some/prefix/layers/" + strings.Replace(diffID, ":", "/", 1)
+	}
+
+	when("#ParseS3URL", func() {
+		it("parses the bucket, prefix, endpoint and region", func() {
+			location, err := cache.ParseS3URL("s3://some-bucket/some/prefix/?endpoint=http://localhost:9000&region=some-region")
+			h.AssertNil(t, err)
+			h.AssertEq(t, location, cache.S3Location{
+				Bucket:   "some-bucket",
+				Prefix:   "some/prefix",
+				Endpoint: "http://localhost:9000",
+				Region:   "some-region",
+			})
+		})
+
+		it("errors for an unsupported scheme", func() {
+			_, err := cache.ParseS3URL("gs://some-bucket")
+			h.AssertError(t, err, "failed to parse cache URL 'gs://some-bucket': unsupported scheme 'gs'")
+		})
+
+		it("errors for a missing bucket", func() {
+			_, err := cache.ParseS3URL("s3:///some/prefix")
+			h.AssertError(t, err, "missing bucket")
+		})
+	})
+
+	when("the cache is empty", func() {
+		it("does not exist and has empty metadata", func() {
+			h.AssertEq(t, subject.Exists(), false)
+			h.AssertEq(t, subject.Name(), cacheURL)
+			metadata, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, metadata, platform.CacheMetadata{})
+		})
+	})
+
+	when("#Commit", func() {
+		var (
+			layerPath, layerSHA string
+			layerData           []byte
+			otherPath, otherSHA string
+			metadata            platform.CacheMetadata
+		)
+
+		it.Before(func() {
+			layerPath, layerSHA, layerData = h.RandomLayer(t, tmpDir)
+			otherPath, otherSHA, _ = h.RandomLayer(t, tmpDir)
+			metadata = platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID:     "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: layerSHA}},
+				}},
+			}
+		})
+
+		it("stores the layers by diffID, and the metadata as an object referenced by the manifest", func() {
+			build(metadata, nil, layerPath)
+
+			keys := store.keys()
+			h.AssertEq(t, len(keys), 3)
+			h.AssertEq(t, keys[0], layerKey(layerSHA))
+			h.AssertEq(t, keys[1], "some-bucket/some/prefix/manifest.json")
+			h.AssertEq(t, strings.HasPrefix(keys[2], "some-bucket/some/prefix/metadata/sha256/"), true)
+
+			subject, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertEq(t, subject.Exists(), true)
+			retrieved, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, retrieved, metadata)
+
+			rc, err := subject.RetrieveLayer(layerSHA)
+			h.AssertNil(t, err)
+			defer rc.Close()
+			contents, err := io.ReadAll(rc)
+			h.AssertNil(t, err)
+			h.AssertEq(t, contents, layerData)
+		})
+
+		it("does not change the cache seen by other builds until the manifest is replaced", func() {
+			build(metadata, nil, layerPath)
+
+			h.AssertNil(t, subject.AddLayerFile(otherPath, otherSHA))
+			h.AssertNil(t, subject.SetMetadata(platform.CacheMetadata{}))
+
+			other, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			retrieved, err := other.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, retrieved, metadata)
+		})
+
+		it("removes the layers and metadata of the previous build that are no longer used when the next build commits", func() {
+			build(metadata, nil, layerPath, otherPath)
+			newMetadata := platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID:     "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{"some-other-layer": {SHA: layerSHA}},
+				}},
+			}
+
+			build(newMetadata, []string{layerSHA})
+			h.AssertEq(t, len(store.keys()), 5)
+
+			build(newMetadata, []string{layerSHA})
+			keys := store.keys()
+			h.AssertEq(t, len(keys), 3)
+			h.AssertEq(t, keys[0], layerKey(layerSHA))
+			subject, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			retrieved, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, retrieved, newMetadata)
+		})
+
+		it("keeps the layers reused by a concurrent build that commits afterwards", func() {
+			build(metadata, nil, layerPath, otherPath)
+			concurrent, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertNil(t, concurrent.ReuseLayer(otherSHA))
+
+			build(metadata, []string{layerSHA})
+			h.AssertNil(t, concurrent.SetMetadata(metadata))
+			h.AssertNil(t, concurrent.Commit())
+
+			subject, err := cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.VerifyLayer(otherSHA))
+		})
+
+		it("uploads large layers in parts", func() {
+			subject.SetPartSize(int64(len(layerData)/3 + 1))
+			h.AssertNil(t, subject.AddLayerFile(layerPath, layerSHA))
+			h.AssertNil(t, subject.Commit())
+
+			store.mu.Lock()
+			h.AssertEq(t, store.objects[layerKey(layerSHA)], layerData)
+			store.mu.Unlock()
+			h.AssertNil(t, subject.VerifyLayer(layerSHA))
+		})
+
+		it("errors when adding a layer that does not match its diffID", func() {
+			err := subject.AddLayerFile(layerPath, otherSHA)
+			h.AssertError(t, err, "expected layer contents to have SHA '"+otherSHA+"'")
+		})
+
+		it("fails with a read error when reusing a layer that is not in the cache", func() {
+			err := subject.ReuseLayer(otherSHA)
+			isReadErr, _ := cache.IsReadErr(err)
+			h.AssertEq(t, isReadErr, true)
+		})
+
+		it("cannot be modified after commit", func() {
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertError(t, subject.AddLayerFile(layerPath, layerSHA), "cache cannot be modified after commit")
+			h.AssertError(t, subject.ReuseLayer(layerSHA), "cache cannot be modified after commit")
+			h.AssertError(t, subject.SetMetadata(metadata), "cache cannot be modified after commit")
+			h.AssertError(t, subject.Commit(), "cache cannot be modified after commit")
+		})
+
+		when("#VerifyLayer", func() {
+			it.Before(func() {
+				build(metadata, nil, layerPath)
+			})
+
+			it("verifies the contents of the layer", func() {
+				h.AssertNil(t, subject.VerifyLayer(layerSHA))
+			})
+
+			it("fails with a read error when the layer is corrupt", func() {
+				store.mu.Lock()
+				store.objects[layerKey(layerSHA)] = []byte("corrupt")
+				store.mu.Unlock()
+
+				err := subject.VerifyLayer(layerSHA)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, true)
+				h.AssertError(t, err, "expected layer contents to have SHA '"+layerSHA+"'")
+			})
+
+			it("fails with a read error without downloading a layer stored with a different diffID", func() {
+				store.mu.Lock()
+				store.metadata[layerKey(layerSHA)].Set("X-Amz-Meta-Diff-Id", otherSHA)
+				store.mu.Unlock()
+
+				err := subject.VerifyLayer(layerSHA)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, true)
+				store.mu.Lock()
+				defer store.mu.Unlock()
+				h.AssertEq(t, store.gets[layerKey(layerSHA)], 0)
+			})
+
+			it("stops when the context is done", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				err := subject.VerifyLayerContext(ctx, layerSHA)
+				h.AssertEq(t, errors.Is(err, context.Canceled), true)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, false)
+			})
+
+			it("fails with a read error when the layer was not verified when it was uploaded", func() {
+				store.mu.Lock()
+				delete(store.metadata, layerKey(layerSHA))
+				store.mu.Unlock()
+
+				err := subject.VerifyLayer(layerSHA)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, true)
+				h.AssertError(t, err, "was not verified when it was uploaded")
+			})
+
+			it("fails with a read error when the layer is not in the cache", func() {
+				err := subject.VerifyLayer(otherSHA)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, true)
+			})
+		})
+
+		when("#RetrieveLayer", func() {
+			it.Before(func() {
+				build(metadata, nil, layerPath)
+			})
+
+			it("fails with a read error when the layer is corrupt", func() {
+				store.mu.Lock()
+				store.objects[layerKey(layerSHA)] = []byte("corrupt")
+				store.mu.Unlock()
+
+				_, err := subject.RetrieveLayer(layerSHA)
+				isReadErr, _ := cache.IsReadErr(err)
+				h.AssertEq(t, isReadErr, true)
+				h.AssertError(t, err, "expected layer contents to have SHA '"+layerSHA+"'")
+			})
+		})
+	})
+}
@@ -1,12 +1,13 @@
 package cache
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -16,13 +17,41 @@ import (
 	"github.com/buildpacks/lifecycle/platform"
 )
 
+const (
+	// cacheLockFile is locked exclusively while the committed layers or metadata change,
+	// and shared by operations that must not observe a change in progress.
+	cacheLockFile = "lock"
+	// buildLockFile is locked by the build that owns a staging directory until the build commits or exits.
+	buildLockFile = ".lock"
+	// decompressedDir holds, in the staging directory of a build, uncompressed copies of the compressed layers retrieved as files.
+	decompressedDir = "decompressed"
+	// unusedFile records, as a JSON array of diffIDs, the committed layers that were not referenced by the metadata of the last build to commit;
+	// in a LayoutCache, it records the digests of the blobs that were not referenced by the index.
+	unusedFile = "unused.json"
+)
+
+// VolumeCache is a cache in a directory that may be shared by concurrent builds.
+// Each build stages its changes in its own directory below staging, and commits them by moving them into committed,
+// which holds the layers of all builds by diffID, and the metadata of the last build to commit.
+// Metadata is not merged: the last build to commit wins, and the layers only referenced by the metadata it replaced,
+// or committed by concurrent builds that lost, are removed when they are no longer used (see Commit).
 type VolumeCache struct {
 	committed    bool
 	dir          string
 	backupDir    string
-	stagingDir   string
+	stagingRoot  string
+	stagingDir   string // the staging directory of this build, created when the build first changes the cache
 	committedDir string
+	compression  Compression
 	logger       log.Logger
+
+	mu             sync.Mutex
+	buildLock      *fileLock
+	previousLayers []string // the layers that were committed when this build started staging changes
+
+	eviction EvictionPolicy
+	accessed map[string]time.Time // when each layer was used by this build
+	evicted  []EvictedLayer
 }
 
 // NewVolumeCache creates a new VolumeCache
@@ -34,26 +63,39 @@ func NewVolumeCache(dir string, logger log.Logger) (*VolumeCache, error) {
 	c := &VolumeCache{
 		dir:          dir,
 		backupDir:    filepath.Join(dir, "committed-backup"),
-		stagingDir:   filepath.Join(dir, "staging"),
+		stagingRoot:  filepath.Join(dir, "staging"),
 		committedDir: filepath.Join(dir, "committed"),
 		logger:       logger,
 	}
 
-	if err := c.setupStagingDir(); err != nil {
-		return nil, errors.Wrapf(err, "initializing staging directory '%s'", c.stagingDir)
-	}
+	err := c.withLock(true, func() error {
+		if err := c.setupStagingDir(); err != nil {
+			return errors.Wrapf(err, "initializing staging directory '%s'", c.stagingRoot)
+		}
 
-	if err := os.RemoveAll(c.backupDir); err != nil {
-		return nil, errors.Wrapf(err, "removing backup directory '%s'", c.backupDir)
-	}
+		// left behind by versions of the lifecycle that committed the cache by swapping directories
+		if err := os.RemoveAll(c.backupDir); err != nil {
+			return errors.Wrapf(err, "removing backup directory '%s'", c.backupDir)
+		}
 
-	if err := os.MkdirAll(c.committedDir, 0777); err != nil {
-		return nil, errors.Wrapf(err, "creating committed directory '%s'", c.committedDir)
+		if err := os.MkdirAll(c.committedDir, 0777); err != nil {
+			return errors.Wrapf(err, "creating committed directory '%s'", c.committedDir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
 
+// SetCompression configures the cache to compress the layers added by this build.
+// Layers already in the cache are used as they are stored, whether compressed or not.
+func (c *VolumeCache) SetCompression(compression Compression) {
+	c.compression = compression
+}
+
 func (c *VolumeCache) Exists() bool {
 	if _, err := os.Stat(c.committedDir); err != nil {
 		return false
@@ -69,6 +111,9 @@ func (c *VolumeCache) SetMetadata(metadata platform.CacheMetadata) error {
 	if c.committed {
 		return errCacheCommitted
 	}
+	if err := c.stage(); err != nil {
+		return err
+	}
 	metadataPath := filepath.Join(c.stagingDir, MetadataLabel)
 	file, err := os.Create(metadataPath)
 	if err != nil {
@@ -105,13 +150,29 @@ func (c *VolumeCache) AddLayerFile(tarPath string, diffID string) error {
 	if c.committed {
 		return errCacheCommitted
 	}
-	layerTar := diffIDPath(c.stagingDir, diffID)
-	if _, err := os.Stat(layerTar); err == nil {
+	if err := c.stage(); err != nil {
+		return err
+	}
+	c.recordAccess(diffID)
+	if _, _, err := findLayer(c.stagingDir, diffID); err == nil {
 		// don't waste time rewriting an identical layer
 		return nil
 	}
 
-	if err := fsutil.Copy(tarPath, layerTar); err != nil {
+	layerTar := diffIDPath(c.stagingDir, diffID, c.compression)
+	if c.compression == NoCompression {
+		if err := fsutil.Copy(tarPath, layerTar); err != nil {
+			_ = os.Remove(layerTar)
+			return errors.Wrapf(err, "caching layer (%s)", diffID)
+		}
+		return nil
+	}
+	file, err := os.Open(tarPath)
+	if err != nil {
+		return errors.Wrapf(err, "caching layer (%s)", diffID)
+	}
+	defer file.Close()
+	if err = writeLayer(layerTar, file, c.compression); err != nil {
 		return errors.Wrapf(err, "caching layer (%s)", diffID)
 	}
 	return nil
@@ -121,14 +182,16 @@ func (c *VolumeCache) AddLayer(rc io.ReadCloser, diffID string) error {
 	if c.committed {
 		return errCacheCommitted
 	}
+	if err := c.stage(); err != nil {
+		return err
+	}
 
-	fh, err := os.Create(diffIDPath(c.stagingDir, diffID))
-	if err != nil {
+	c.recordAccess(diffID)
+	layerTar := diffIDPath(c.stagingDir, diffID, c.compression)
+	if err := removeLayer(c.stagingDir, diffID, layerTar); err != nil {
 		return errors.Wrapf(err, "create layer file in cache")
 	}
-	defer fh.Close()
-
-	if _, err := io.Copy(fh, rc); err != nil {
+	if err := writeLayer(layerTar, rc, c.compression); err != nil {
 		return errors.Wrap(err, "copying layer to tar file")
 	}
 	return nil
@@ -138,39 +201,57 @@ func (c *VolumeCache) ReuseLayer(diffID string) error {
 	if c.committed {
 		return errCacheCommitted
 	}
-	committedPath := diffIDPath(c.committedDir, diffID)
-	stagingPath := diffIDPath(c.stagingDir, diffID)
-
-	if _, err := os.Stat(committedPath); err != nil {
-		if err = handleFileError(err, diffID); errors.Is(err, ReadErr{}) {
-			return err
-		}
-		return fmt.Errorf("failed to re-use cache layer with SHA '%s': %w", diffID, err)
+	if err := c.stage(); err != nil {
+		return err
 	}
+	// another build must not remove the committed layer before it is linked
+	err := c.withLock(false, func() error {
+		committedPath, _, err := findLayer(c.committedDir, diffID)
+		if err != nil {
+			if err = handleFileError(err, diffID); errors.Is(err, ReadErr{}) {
+				return err
+			}
+			return fmt.Errorf("failed to re-use cache layer with SHA '%s': %w", diffID, err)
+		}
+		if _, _, err = findLayer(c.stagingDir, diffID); err == nil {
+			// the layer was already added or reused by this build
+			return nil
+		}
 
-	if err := os.Link(committedPath, stagingPath); err != nil && !os.IsExist(err) {
-		return errors.Wrapf(err, "reusing layer (%s)", diffID)
+		stagingPath := filepath.Join(c.stagingDir, filepath.Base(committedPath))
+		if err := os.Link(committedPath, stagingPath); err != nil && !os.IsExist(err) {
+			return errors.Wrapf(err, "reusing layer (%s)", diffID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	c.recordAccess(diffID)
 	return nil
 }
 
+// RetrieveLayer retrieves a layer from the cache, decompressing it if it is compressed.
 func (c *VolumeCache) RetrieveLayer(diffID string) (io.ReadCloser, error) {
-	path, err := c.RetrieveLayerFile(diffID)
+	path, compression, err := c.committedLayer(diffID)
 	if err != nil {
 		return nil, err
 	}
-	file, err := os.Open(path)
+	layer, err := openLayer(path, compression)
 	if err != nil {
 		if err = handleFileError(err, diffID); errors.Is(err, ReadErr{}) {
 			return nil, err
 		}
+		if compression != NoCompression {
+			return nil, NewReadErr(fmt.Sprintf("failed to read cache layer with SHA '%s': %s", diffID, err))
+		}
 		return nil, fmt.Errorf("failed to get cache layer with SHA '%s'", diffID)
 	}
-	return file, nil
+	return layer, nil
 }
 
 func (c *VolumeCache) HasLayer(diffID string) (bool, error) {
-	if _, err := os.Stat(diffIDPath(c.committedDir, diffID)); err != nil {
+	if _, _, err := findLayer(c.committedDir, diffID); err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
@@ -179,66 +260,326 @@ func (c *VolumeCache) HasLayer(diffID string) (bool, error) {
 	return true, nil
 }
 
+// RetrieveLayerFile returns the path of an uncompressed tar of the layer.
+// An uncompressed layer that this build added or reused is returned from the staging directory of this build,
+// where it cannot be removed by a concurrent build committing or evicting layers;
+// a compressed layer is decompressed into the staging directory. Either cannot be retrieved as a file after commit.
 func (c *VolumeCache) RetrieveLayerFile(diffID string) (string, error) {
-	path := diffIDPath(c.committedDir, diffID)
-	if _, err := os.Stat(path); err != nil {
-		if err = handleFileError(err, diffID); errors.Is(err, ReadErr{}) {
+	path, compression, err := c.committedLayer(diffID)
+	if err != nil {
+		return "", err
+	}
+	if compression == NoCompression {
+		if !c.committed && c.stagingDir != "" {
+			stagingPath := diffIDPath(c.stagingDir, diffID, NoCompression)
+			if _, err = os.Stat(stagingPath); err == nil {
+				return stagingPath, nil
+			}
+		}
+		return path, nil
+	}
+	if c.committed {
+		return "", errCacheCommitted
+	}
+	if err = c.stage(); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(c.stagingDir, decompressedDir)
+	tarPath := diffIDPath(dir, diffID, NoCompression)
+	if _, err = os.Stat(tarPath); err == nil {
+		return tarPath, nil
+	}
+	if err = os.MkdirAll(dir, 0777); err != nil {
+		return "", errors.Wrapf(err, "creating directory '%s'", dir)
+	}
+	layer, err := openLayer(path, compression)
+	if err != nil {
+		return "", NewReadErr(fmt.Sprintf("failed to read cache layer with SHA '%s': %s", diffID, err))
+	}
+	defer layer.Close()
+	if err = writeLayer(tarPath, layer, NoCompression); err != nil {
+		if errors.Is(err, ReadErr{}) {
 			return "", err
 		}
-		return "", errors.Wrapf(err, "retrieving layer with SHA '%s'", diffID)
+		return "", errors.Wrapf(err, "decompressing layer with SHA '%s'", diffID)
+	}
+	return tarPath, nil
+}
+
+// committedLayer returns the path of the committed layer with the provided diffID, and how it is compressed.
+func (c *VolumeCache) committedLayer(diffID string) (string, Compression, error) {
+	path, compression, err := findLayer(c.committedDir, diffID)
+	if err != nil {
+		if err = handleFileError(err, diffID); errors.Is(err, ReadErr{}) {
+			return "", NoCompression, err
+		}
+		return "", NoCompression, errors.Wrapf(err, "retrieving layer with SHA '%s'", diffID)
 	}
-	return path, nil
+	c.recordAccess(diffID)
+	return path, compression, nil
 }
 
+// Commit moves the layers staged by this build into the committed directory,
+// followed by the metadata of this build, which replaces the metadata of any build that committed before.
+// Without an eviction policy, the layers that were committed when this build started staging changes are then removed
+// unless this build added or reused them. Layers committed by concurrent builds in the meantime, which another build may still be reading,
+// are removed by the next build to commit unless it adds or reuses them, or its metadata references them.
 func (c *VolumeCache) Commit() error {
 	if c.committed {
 		return errCacheCommitted
 	}
 	c.committed = true
-	if err := os.Rename(c.committedDir, c.backupDir); err != nil {
-		return errors.Wrap(err, "backing up cache")
+	if err := c.stage(); err != nil {
+		return err
 	}
-	defer os.RemoveAll(c.backupDir)
+	defer c.removeStagingDir()
 
-	if err1 := os.Rename(c.stagingDir, c.committedDir); err1 != nil {
-		if err2 := os.Rename(c.backupDir, c.committedDir); err2 != nil {
-			return errors.Wrap(err2, "rolling back cache")
+	return c.withLock(true, func() error {
+		staged, err := c.commitLayers()
+		if err != nil {
+			return errors.Wrap(err, "committing cache")
+		}
+		if err = c.commitMetadata(); err != nil {
+			return errors.Wrap(err, "committing cache")
+		}
+		if c.eviction.enabled() {
+			if err = c.evict(); err != nil {
+				return errors.Wrap(err, "evicting layers from cache")
+			}
+			return nil
+		}
+		return c.removeUnusedLayers(staged)
+	})
+}
+
+// removeUnusedLayers removes the layers that were committed when this build started staging changes,
+// and the layers recorded as unused by the previous build to commit, unless this build added or reused them
+// or its metadata references them. It then records the remaining layers that the metadata does not reference.
+// It must be called with an exclusive lock on the cache, after the layers and metadata of this build are committed.
+func (c *VolumeCache) removeUnusedLayers(staged map[string]bool) error {
+	unusedPath := filepath.Join(c.committedDir, unusedFile)
+	unused, err := readUnusedLayers(unusedPath)
+	if err != nil {
+		return err
+	}
+	referenced, err := c.referencedLayers()
+	if err != nil {
+		return err
+	}
+	for _, diffID := range append(c.previousLayers, unused...) {
+		if staged[diffID] || referenced[diffID] {
+			continue
+		}
+		if err = removeLayer(c.committedDir, diffID, ""); err != nil {
+			return errors.Wrapf(err, "removing unused layer (%s)", diffID)
 		}
-		return errors.Wrap(err1, "committing cache")
 	}
 
+	diffIDs, err := layerFiles(c.committedDir)
+	if err != nil {
+		return err
+	}
+	unused = nil
+	for _, diffID := range diffIDs {
+		if !referenced[diffID] {
+			unused = append(unused, diffID)
+		}
+	}
+	return writeUnusedLayers(unusedPath, unused)
+}
+
+func readUnusedLayers(path string) ([]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "reading unused layers '%s'", path)
+	}
+	var unused []string
+	if err = json.Unmarshal(contents, &unused); err != nil {
+		// the layers of a corrupt record are removed by the build after the next
+		return nil, nil
+	}
+	return unused, nil
+}
+
+func writeUnusedLayers(path string, unused []string) error {
+	if len(unused) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "removing unused layers '%s'", path)
+		}
+		return nil
+	}
+	contents, err := json.Marshal(unused)
+	if err != nil {
+		return errors.Wrap(err, "marshalling unused layers")
+	}
+	if err = os.WriteFile(path, contents, 0666); err != nil {
+		return errors.Wrapf(err, "writing unused layers '%s'", path)
+	}
 	return nil
 }
 
-func diffIDPath(basePath, diffID string) string {
-	return filepath.Join(basePath, diffID+".tar")
+// commitLayers moves the staged layers into the committed directory and returns their diffIDs.
+// A staged layer replaces a committed layer with the same diffID, which may have been committed by a concurrent build,
+// so that a build can repair a layer that was corrupted; a committed layer stored with a different compression is removed.
+func (c *VolumeCache) commitLayers() (map[string]bool, error) {
+	diffIDs, err := layerFiles(c.stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	staged := map[string]bool{}
+	for _, diffID := range diffIDs {
+		staged[diffID] = true
+		stagingPath, _, err := findLayer(c.stagingDir, diffID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "committing layer (%s)", diffID)
+		}
+		committedPath := filepath.Join(c.committedDir, filepath.Base(stagingPath))
+		if err = os.Rename(stagingPath, committedPath); err != nil {
+			return nil, errors.Wrapf(err, "committing layer (%s)", diffID)
+		}
+		if err = removeLayer(c.committedDir, diffID, committedPath); err != nil {
+			return nil, errors.Wrapf(err, "committing layer (%s)", diffID)
+		}
+	}
+	return staged, nil
 }
 
-func (c *VolumeCache) setupStagingDir() error {
-	if err := os.RemoveAll(c.stagingDir); err != nil {
-		return err
+// commitMetadata replaces the committed metadata with the metadata staged by this build, if any.
+func (c *VolumeCache) commitMetadata() error {
+	committedPath := filepath.Join(c.committedDir, MetadataLabel)
+	err := os.Rename(filepath.Join(c.stagingDir, MetadataLabel), committedPath)
+	if os.IsNotExist(err) {
+		err = os.Remove(committedPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
 	}
-	return os.MkdirAll(c.stagingDir, 0777)
+	return err
 }
 
-// VerifyLayer returns an error if the layer contents do not match the provided sha.
-func (c *VolumeCache) VerifyLayer(diffID string) error {
-	layerRC, err := c.RetrieveLayer(diffID)
+func diffIDPath(basePath, diffID string, compression Compression) string {
+	return filepath.Join(basePath, diffID+compression.extension())
+}
+
+// layerFiles returns the diffIDs of the layers in the provided directory.
+func layerFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
+		return nil, errors.Wrapf(err, "reading directory '%s'", dir)
+	}
+	var (
+		diffIDs []string
+		seen    = map[string]bool{}
+	)
+	for _, entry := range entries {
+		if !isLayerFile(entry) {
+			continue
+		}
+		diffID, _, _ := compressionOf(entry.Name())
+		if !seen[diffID] {
+			seen[diffID] = true
+			diffIDs = append(diffIDs, diffID)
+		}
+	}
+	return diffIDs, nil
+}
+
+// withLock runs fn while holding a shared or exclusive lock on the cache.
+func (c *VolumeCache) withLock(exclusive bool, fn func() error) error {
+	lock, err := acquireLock(filepath.Join(c.dir, cacheLockFile), exclusive)
+	if err != nil {
+		return errors.Wrapf(err, "locking cache '%s'", c.dir)
+	}
+	defer lock.release()
+	return fn()
+}
+
+// setupStagingDir removes the staging directories of builds that are no longer running.
+// It must be called with an exclusive lock on the cache.
+func (c *VolumeCache) setupStagingDir() error {
+	if err := os.MkdirAll(c.stagingRoot, 0777); err != nil {
 		return err
 	}
-	defer func() {
-		_ = layerRC.Close()
-	}()
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, layerRC); err != nil {
-		return errors.Wrap(err, "hashing layer")
+	entries, err := os.ReadDir(c.stagingRoot)
+	if err != nil {
+		return err
 	}
-	foundDiffID := fmt.Sprintf("sha256:%x", hasher.Sum(nil))
-	if diffID != foundDiffID {
-		return NewReadErr(fmt.Sprintf("expected layer contents to have SHA '%s'; found '%s'", diffID, foundDiffID))
+	for _, entry := range entries {
+		path := filepath.Join(c.stagingRoot, entry.Name())
+		if entry.IsDir() {
+			lock, err := tryLock(filepath.Join(path, buildLockFile))
+			if errors.Is(err, errLocked) || os.IsNotExist(err) {
+				// the build is running, or removed the directory after committing
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			lock.release()
+		}
+		if err = os.RemoveAll(path); err != nil {
+			return err
+		}
 	}
-	return err
+	return nil
+}
+
+// stage creates the staging directory of this build if it does not exist,
+// and locks it so that other builds do not remove it.
+func (c *VolumeCache) stage() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stagingDir != "" {
+		return nil
+	}
+	// a shared lock excludes builds that are removing staging directories
+	return c.withLock(false, func() error {
+		dir, err := os.MkdirTemp(c.stagingRoot, "build-")
+		if err != nil {
+			return errors.Wrapf(err, "creating staging directory in '%s'", c.stagingRoot)
+		}
+		lock, err := tryLock(filepath.Join(dir, buildLockFile))
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return errors.Wrapf(err, "locking staging directory '%s'", dir)
+		}
+		if c.previousLayers, err = layerFiles(c.committedDir); err != nil {
+			lock.release()
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		c.stagingDir, c.buildLock = dir, lock
+		return nil
+	})
+}
+
+// Abort removes the changes staged by this build, which can no longer change or commit the cache.
+func (c *VolumeCache) Abort() error {
+	if c.committed {
+		return nil
+	}
+	c.committed = true
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stagingDir != "" {
+		c.removeStagingDir()
+	}
+	return nil
+}
+
+func (c *VolumeCache) removeStagingDir() {
+	c.buildLock.release()
+	if err := os.RemoveAll(c.stagingDir); err != nil {
+		c.logger.Warnf("Failed to remove staging directory '%s': %s", c.stagingDir, err)
+	}
+}
+
+// VerifyLayer returns an error if the layer contents do not match the provided sha.
+func (c *VolumeCache) VerifyLayer(diffID string) error {
+	return verifyLayer(c, diffID)
 }
 
 func handleFileError(err error, diffID string) error {
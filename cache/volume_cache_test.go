@@ -1,14 +1,21 @@
 package cache_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/buildpacks/lifecycle/cmd"
 	"github.com/buildpacks/lifecycle/log"
@@ -81,6 +88,30 @@ func testVolumeCache(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("another build is staging changes", func() {
+			var other *cache.VolumeCache
+
+			it.Before(func() {
+				var err error
+				other, err = cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				tarPath := filepath.Join(tmpDir, "some-layer.tar")
+				h.AssertNil(t, os.WriteFile(tarPath, []byte("some data"), 0600))
+				h.AssertNil(t, other.AddLayerFile(tarPath, "some_sha"))
+			})
+
+			it("keeps the staging dir of the other build", func() {
+				var err error
+
+				subject, err = cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+
+				h.AssertNil(t, other.Commit())
+				_, err = os.Stat(filepath.Join(committedDir, "some_sha.tar"))
+				h.AssertNil(t, err)
+			})
+		})
+
 		when("staging does not exist", func() {
 			it("creates staging dir", func() {
 				var err error
@@ -230,6 +261,19 @@ func testVolumeCache(t *testing.T, when spec.G, it spec.S) {
 					h.AssertNil(t, err)
 					h.AssertEq(t, string(bytes), "dummy data")
 				})
+
+				it("returns the layer staged by this build once it is reused", func() {
+					h.AssertNil(t, subject.ReuseLayer("some_sha"))
+
+					layerPath, err := subject.RetrieveLayerFile("some_sha")
+					h.AssertNil(t, err)
+					// a concurrent build may remove the committed layer
+					h.AssertNil(t, os.Remove(filepath.Join(committedDir, "some_sha.tar")))
+
+					bytes, err := os.ReadFile(layerPath)
+					h.AssertNil(t, err)
+					h.AssertEq(t, string(bytes), "dummy data")
+				})
 			})
 
 			when("layer does not exist", func() {
@@ -242,14 +286,16 @@ func testVolumeCache(t *testing.T, when spec.G, it spec.S) {
 
 		when("#Commit", func() {
 			it("should clear the staging dir", func() {
-				layerTarPath := filepath.Join(stagingDir, "some-layer.tar")
-				h.AssertNil(t, os.WriteFile(layerTarPath, []byte("some data"), 0600))
+				tarPath := filepath.Join(tmpDir, "some-layer.tar")
+				h.AssertNil(t, os.WriteFile(tarPath, []byte("some data"), 0600))
+				h.AssertNil(t, subject.AddLayerFile(tarPath, "some_sha"))
 
 				err := subject.Commit()
 				h.AssertNil(t, err)
 
-				_, err = os.Stat(layerTarPath)
-				if err == nil {
+				entries, err := os.ReadDir(stagingDir)
+				h.AssertNil(t, err)
+				if len(entries) != 0 {
 					t.Fatal("expected staging dir to have been cleared")
 				}
 			})
@@ -540,4 +586,419 @@ func testVolumeCache(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#SetCompression", func() {
+		var (
+			layerPath, layerSHA string
+			layerData           []byte
+		)
+
+		it.Before(func() {
+			layerPath, layerSHA, layerData = h.RandomLayer(t, tmpDir)
+		})
+
+		committedFiles := func() []string {
+			var names []string
+			entries, err := os.ReadDir(committedDir)
+			h.AssertNil(t, err)
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "sha256:") {
+					names = append(names, entry.Name())
+				}
+			}
+			return names
+		}
+
+		for _, tc := range []struct {
+			compression cache.Compression
+			extension   string
+		}{
+			{cache.GzipCompression, ".tar.gz"},
+			{cache.ZstdCompression, ".tar.zst"},
+		} {
+			tc := tc
+			when(string(tc.compression), func() {
+				it("stores compressed layers by diffID and decompresses them when retrieved", func() {
+					subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+					h.AssertNil(t, err)
+					subject.SetCompression(tc.compression)
+					h.AssertNil(t, subject.AddLayerFile(layerPath, layerSHA))
+					h.AssertNil(t, subject.Commit())
+
+					h.AssertEq(t, committedFiles(), []string{layerSHA + tc.extension})
+					compressed, err := os.ReadFile(filepath.Join(committedDir, layerSHA+tc.extension))
+					h.AssertNil(t, err)
+					h.AssertEq(t, bytes.Equal(compressed, layerData), false)
+
+					subject, err = cache.NewVolumeCache(volumeDir, testLogger)
+					h.AssertNil(t, err)
+					h.AssertNil(t, subject.VerifyLayer(layerSHA))
+					rc, err := subject.RetrieveLayer(layerSHA)
+					h.AssertNil(t, err)
+					contents, err := io.ReadAll(rc)
+					h.AssertNil(t, err)
+					h.AssertNil(t, rc.Close())
+					h.AssertEq(t, contents, layerData)
+
+					path, err := subject.RetrieveLayerFile(layerSHA)
+					h.AssertNil(t, err)
+					contents, err = os.ReadFile(path)
+					h.AssertNil(t, err)
+					h.AssertEq(t, contents, layerData)
+				})
+
+				it("does not commit a partially written layer when the layer cannot be read", func() {
+					subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+					h.AssertNil(t, err)
+					subject.SetCompression(tc.compression)
+					layer := io.MultiReader(bytes.NewReader(layerData), iotest.ErrReader(errors.New("some-read-error")))
+					h.AssertError(t, subject.AddLayer(io.NopCloser(layer), layerSHA), "some-read-error")
+					h.AssertNil(t, subject.Commit())
+
+					h.AssertEq(t, len(committedFiles()), 0)
+				})
+
+				it("fails verification with a read error when a compressed layer is corrupt", func() {
+					h.AssertNil(t, os.MkdirAll(committedDir, 0777))
+					h.AssertNil(t, os.WriteFile(filepath.Join(committedDir, layerSHA+tc.extension), []byte("corrupt"), 0600))
+
+					subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+					h.AssertNil(t, err)
+					err = subject.VerifyLayer(layerSHA)
+					isReadErr, _ := cache.IsReadErr(err)
+					h.AssertEq(t, isReadErr, true)
+					_, err = subject.RetrieveLayerFile(layerSHA)
+					isReadErr, _ = cache.IsReadErr(err)
+					h.AssertEq(t, isReadErr, true)
+				})
+			})
+		}
+
+		when("the cache contains uncompressed layers", func() {
+			var otherPath, otherSHA string
+
+			it.Before(func() {
+				otherPath, otherSHA, _ = h.RandomLayer(t, tmpDir)
+				subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				h.AssertNil(t, subject.AddLayerFile(layerPath, layerSHA))
+				h.AssertNil(t, subject.AddLayerFile(otherPath, otherSHA))
+				h.AssertNil(t, subject.Commit())
+			})
+
+			it("reuses them as they are stored", func() {
+				subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				subject.SetCompression(cache.ZstdCompression)
+				h.AssertNil(t, subject.ReuseLayer(layerSHA))
+				newPath, newSHA, newData := h.RandomLayer(t, tmpDir)
+				h.AssertNil(t, subject.AddLayerFile(newPath, newSHA))
+				h.AssertNil(t, subject.Commit())
+
+				h.AssertEq(t, len(committedFiles()), 2)
+				h.AssertContains(t, committedFiles(), layerSHA+".tar", newSHA+".tar.zst")
+
+				subject, err = cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				for diffID, data := range map[string][]byte{layerSHA: layerData, newSHA: newData} {
+					h.AssertNil(t, subject.VerifyLayer(diffID))
+					rc, err := subject.RetrieveLayer(diffID)
+					h.AssertNil(t, err)
+					contents, err := io.ReadAll(rc)
+					h.AssertNil(t, err)
+					h.AssertNil(t, rc.Close())
+					h.AssertEq(t, contents, data)
+				}
+			})
+
+			it("replaces them when the layer is added again", func() {
+				subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				subject.SetCompression(cache.GzipCompression)
+				h.AssertNil(t, subject.AddLayerFile(layerPath, layerSHA))
+				h.AssertNil(t, subject.Commit())
+
+				h.AssertEq(t, committedFiles(), []string{layerSHA + ".tar.gz"})
+			})
+
+			it("evicts them by diffID", func() {
+				subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				subject.SetCompression(cache.ZstdCompression)
+				subject.SetEvictionPolicy(cache.EvictionPolicy{MaxSize: 1})
+				h.AssertNil(t, subject.ReuseLayer(layerSHA))
+				h.AssertNil(t, subject.SetMetadata(platform.CacheMetadata{
+					Buildpacks: []buildpack.LayersMetadata{{
+						ID:     "some/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: layerSHA}},
+					}},
+				}))
+				h.AssertNil(t, subject.Commit())
+
+				h.AssertEq(t, committedFiles(), []string{layerSHA + ".tar"})
+				h.AssertEq(t, len(subject.Evicted()), 1)
+				h.AssertEq(t, subject.Evicted()[0].DiffID, otherSHA)
+			})
+		})
+	})
+
+	when("#Commit with an eviction policy", func() {
+		var now = time.Now()
+
+		it.Before(func() {
+			h.AssertNil(t, os.MkdirAll(committedDir, 0777))
+			for diffID, size := range map[string]int{"sha256:old": 100, "sha256:older": 100, "sha256:oldest": 100, "sha256:used": 100} {
+				h.AssertNil(t, os.WriteFile(filepath.Join(committedDir, diffID+".tar"), make([]byte, size), 0600))
+			}
+			accessed, err := json.Marshal(map[string]time.Time{
+				"sha256:old":    now.Add(-1 * time.Hour),
+				"sha256:older":  now.Add(-2 * time.Hour),
+				"sha256:oldest": now.Add(-48 * time.Hour),
+				"sha256:used":   now.Add(-72 * time.Hour),
+			})
+			h.AssertNil(t, err)
+			h.AssertNil(t, os.WriteFile(filepath.Join(committedDir, "access.json"), accessed, 0600))
+
+			subject, err = cache.NewVolumeCache(volumeDir, testLogger)
+			h.AssertNil(t, err)
+			h.AssertNil(t, subject.ReuseLayer("sha256:used"))
+			h.AssertNil(t, os.WriteFile(filepath.Join(tmpDir, "new.tar"), make([]byte, 100), 0600))
+			h.AssertNil(t, subject.AddLayerFile(filepath.Join(tmpDir, "new.tar"), "sha256:new"))
+			h.AssertNil(t, subject.SetMetadata(platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID: "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{
+						"some-layer":  {SHA: "sha256:used"},
+						"other-layer": {SHA: "sha256:new"},
+					},
+				}},
+			}))
+		})
+
+		committedLayers := func() []string {
+			var diffIDs []string
+			entries, err := os.ReadDir(committedDir)
+			h.AssertNil(t, err)
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), ".tar") {
+					diffIDs = append(diffIDs, strings.TrimSuffix(entry.Name(), ".tar"))
+				}
+			}
+			return diffIDs
+		}
+
+		it("keeps layers from previous builds within the limits", func() {
+			subject.SetEvictionPolicy(cache.EvictionPolicy{MaxSize: 1000})
+
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertEq(t, committedLayers(), []string{"sha256:new", "sha256:old", "sha256:older", "sha256:oldest", "sha256:used"})
+			h.AssertEq(t, len(subject.Evicted()), 0)
+		})
+
+		it("evicts the least recently used layers until the cache is no larger than the maximum size", func() {
+			subject.SetEvictionPolicy(cache.EvictionPolicy{MaxSize: 300})
+
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertEq(t, committedLayers(), []string{"sha256:new", "sha256:old", "sha256:used"})
+			h.AssertEq(t, len(subject.Evicted()), 2)
+			h.AssertEq(t, subject.Evicted()[0].DiffID, "sha256:oldest")
+			h.AssertEq(t, subject.Evicted()[0].Size, int64(100))
+			h.AssertEq(t, subject.Evicted()[1].DiffID, "sha256:older")
+		})
+
+		it("evicts layers that have not been used for longer than the maximum age", func() {
+			subject.SetEvictionPolicy(cache.EvictionPolicy{MaxAge: 24 * time.Hour})
+
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertEq(t, committedLayers(), []string{"sha256:new", "sha256:old", "sha256:older", "sha256:used"})
+		})
+
+		it("never evicts layers referenced by the metadata", func() {
+			subject.SetEvictionPolicy(cache.EvictionPolicy{MaxSize: 1, MaxAge: time.Nanosecond})
+
+			h.AssertNil(t, subject.Commit())
+
+			h.AssertEq(t, committedLayers(), []string{"sha256:new", "sha256:used"})
+		})
+
+		it("records when each layer was last used", func() {
+			subject.SetEvictionPolicy(cache.EvictionPolicy{MaxSize: 400})
+
+			h.AssertNil(t, subject.Commit())
+
+			contents, err := os.ReadFile(filepath.Join(committedDir, "access.json"))
+			h.AssertNil(t, err)
+			var accessed map[string]time.Time
+			h.AssertNil(t, json.Unmarshal(contents, &accessed))
+			h.AssertEq(t, len(accessed), 4)
+			h.AssertEq(t, accessed["sha256:old"].Equal(now.Add(-1*time.Hour)), true)
+			if !accessed["sha256:used"].After(now) {
+				t.Fatalf("Expected reused layer to be recorded as used by this build, got %s", accessed["sha256:used"])
+			}
+		})
+	})
+
+	when("concurrent builds", func() {
+		const (
+			builds = 8
+			rounds = 3
+		)
+
+		// build simulates the exporter of a build that reuses the layers in the cache, adds a layer of its own, and commits
+		build := func(id, round int, policy cache.EvictionPolicy) error {
+			subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+			if err != nil {
+				return err
+			}
+			subject.SetEvictionPolicy(policy)
+			previous, err := subject.RetrieveMetadata()
+			if err != nil {
+				return err
+			}
+			for _, bpMD := range previous.Buildpacks {
+				for _, layer := range bpMD.Layers {
+					if err = subject.ReuseLayer(layer.SHA); err != nil {
+						if isReadErr, _ := cache.IsReadErr(err); !isReadErr {
+							return err
+						}
+					}
+				}
+			}
+			ownSHA := fmt.Sprintf("sha256:build-%d-%d", id, round)
+			if err = subject.AddLayer(io.NopCloser(strings.NewReader(ownSHA)), ownSHA); err != nil {
+				return err
+			}
+			if err = subject.AddLayer(io.NopCloser(strings.NewReader("sha256:shared")), "sha256:shared"); err != nil {
+				return err
+			}
+			if err = subject.SetMetadata(platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID: "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{
+						"own":    {SHA: ownSHA},
+						"shared": {SHA: "sha256:shared"},
+					},
+				}},
+			}); err != nil {
+				return err
+			}
+			return subject.Commit()
+		}
+
+		runBuilds := func(policy cache.EvictionPolicy) {
+			var g errgroup.Group
+			for id := 0; id < builds; id++ {
+				id := id
+				g.Go(func() error {
+					for round := 0; round < rounds; round++ {
+						if err := build(id, round, policy); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			}
+			h.AssertNil(t, g.Wait())
+		}
+
+		assertConsistent := func() []string {
+			subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+			h.AssertNil(t, err)
+			metadata, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(metadata.Buildpacks), 1)
+			for _, layer := range metadata.Buildpacks[0].Layers {
+				_, err = os.Stat(filepath.Join(committedDir, layer.SHA+".tar"))
+				h.AssertNil(t, err)
+			}
+
+			var diffIDs []string
+			entries, err := os.ReadDir(committedDir)
+			h.AssertNil(t, err)
+			for _, entry := range entries {
+				if !strings.HasSuffix(entry.Name(), ".tar") {
+					continue
+				}
+				diffID := strings.TrimSuffix(entry.Name(), ".tar")
+				contents, err := os.ReadFile(filepath.Join(committedDir, entry.Name()))
+				h.AssertNil(t, err)
+				h.AssertEq(t, string(contents), diffID)
+				diffIDs = append(diffIDs, diffID)
+			}
+
+			entries, err = os.ReadDir(stagingDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(entries), 0)
+			return diffIDs
+		}
+
+		it("commits a consistent cache", func() {
+			runBuilds(cache.EvictionPolicy{})
+
+			assertConsistent()
+		})
+
+		it("records every layer that the metadata does not reference as unused", func() {
+			runBuilds(cache.EvictionPolicy{})
+
+			diffIDs := assertConsistent()
+			subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+			h.AssertNil(t, err)
+			metadata, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			var unused []string
+			contents, err := os.ReadFile(filepath.Join(committedDir, "unused.json"))
+			if !os.IsNotExist(err) {
+				h.AssertNil(t, err)
+				h.AssertNil(t, json.Unmarshal(contents, &unused))
+			}
+			h.AssertEq(t, len(diffIDs), len(metadata.Buildpacks[0].Layers)+len(unused))
+		})
+
+		it("removes the layers of a build that lost to a concurrent build when the next build commits", func() {
+			// addLayer starts a build that adds a layer, which the build references in its metadata
+			addLayer := func(diffID string) *cache.VolumeCache {
+				subject, err := cache.NewVolumeCache(volumeDir, testLogger)
+				h.AssertNil(t, err)
+				h.AssertNil(t, subject.AddLayer(io.NopCloser(strings.NewReader(diffID)), diffID))
+				h.AssertNil(t, subject.SetMetadata(platform.CacheMetadata{
+					Buildpacks: []buildpack.LayersMetadata{{
+						ID:     "some/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: diffID}},
+					}},
+				}))
+				return subject
+			}
+			committedLayers := func() []string {
+				var diffIDs []string
+				entries, err := os.ReadDir(committedDir)
+				h.AssertNil(t, err)
+				for _, entry := range entries {
+					if diffID, ok := strings.CutSuffix(entry.Name(), ".tar"); ok {
+						diffIDs = append(diffIDs, diffID)
+					}
+				}
+				return diffIDs
+			}
+			winner, next := addLayer("sha256:winner"), addLayer("sha256:next")
+
+			h.AssertNil(t, addLayer("sha256:loser").Commit())
+			h.AssertNil(t, winner.Commit())
+			h.AssertEq(t, committedLayers(), []string{"sha256:loser", "sha256:winner"})
+
+			h.AssertNil(t, next.Commit())
+			h.AssertEq(t, committedLayers(), []string{"sha256:next", "sha256:winner"})
+		})
+
+		when("layers are kept until they are evicted", func() {
+			it("keeps the layers of every build", func() {
+				runBuilds(cache.EvictionPolicy{MaxAge: time.Hour})
+
+				h.AssertEq(t, len(assertConsistent()), builds*rounds+1)
+			})
+		})
+	})
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/buildpacks/lifecycle/platform/event"
+)
+
+var (
+	// Events receives the events emitted by the lifecycle phases; it discards all events until SetEvents is called.
+	Events event.Sink = event.NopSink{}
+	// Recorder summarizes the events emitted by the lifecycle phases for report.toml.
+	Recorder = &event.Recorder{}
+
+	eventsSink event.SinkCloser = event.NopSink{}
+)
+
+// SetEvents configures Events to write to the file at the provided path, or else to the provided file descriptor,
+// as well as to Recorder.
+func SetEvents(path string, fd int) error {
+	sink, err := event.NewSink(path, fd)
+	if err != nil {
+		return err
+	}
+	eventsSink = sink
+	Events = event.Tee(sink, Recorder)
+	return nil
+}
+
+// CloseEvents closes the file or file descriptor configured by SetEvents; events emitted afterward are discarded.
+func CloseEvents() {
+	Events = Recorder
+	if err := eventsSink.Close(); err != nil {
+		DefaultLogger.Debugf("Failed to close events: %s", err)
+	}
+	eventsSink = event.NopSink{}
+}
@@ -32,6 +32,10 @@ func (e *ErrorFail) Error() string {
 	return fmt.Sprintf("%s: %s", message, e.Err)
 }
 
+func (e *ErrorFail) Unwrap() error {
+	return e.Err
+}
+
 func FailCode(code int, action ...string) *ErrorFail {
 	return FailErrCode(nil, code, action...)
 }
@@ -28,6 +28,10 @@ func (a *analyzeCmd) DefineFlags() {
 	switch {
 	case a.PlatformAPI.AtLeast("0.13"):
 		cli.FlagInsecureRegistries(&a.InsecureRegistries)
+		cli.FlagRegistriesPath(&a.RegistriesPath)
+		cli.FlagRegistryRetries(&a.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&a.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&a.RegistryRetryMaxDelay)
 		fallthrough
 	case a.PlatformAPI.AtLeast("0.12"):
 		cli.FlagLayoutDir(&a.LayoutDir)
@@ -41,13 +45,18 @@ func (a *analyzeCmd) DefineFlags() {
 	default:
 		cli.FlagAnalyzedPath(&a.AnalyzedPath)
 		cli.FlagCacheImage(&a.CacheImageRef)
+		cli.FlagErrorReportPath(&a.ErrorReportPath)
+		cli.FlagEventsFD(&a.EventsFD)
+		cli.FlagEventsPath(&a.EventsPath)
 		cli.FlagGID(&a.GID)
 		cli.FlagLayersDir(&a.LayersDir)
 		cli.FlagLogLevel(&a.LogLevel)
 		cli.FlagNoColor(&a.NoColor)
 		cli.FlagPreviousImage(&a.PreviousImageRef)
+		cli.FlagReportPath(&a.ReportPath)
 		cli.FlagRunImage(&a.RunImageRef)
 		cli.FlagTags(&a.AdditionalTags)
+		cli.FlagTracesPath(&a.TracesPath)
 		cli.FlagUID(&a.UID)
 		cli.FlagUseDaemon(&a.UseDaemon)
 	}
@@ -104,14 +113,16 @@ func (a *analyzeCmd) Exec() error {
 		&cmd.BuildpackAPIVerifier{},
 		NewCacheHandler(a.keychain, a.InsecureRegistries),
 		files.Handler,
-		image.NewHandler(a.docker, a.keychain, a.LayoutDir, a.UseLayout, a.InsecureRegistries),
-		image.NewRegistryHandler(a.keychain, a.InsecureRegistries),
+		image.NewHandler(a.docker, a.keychain, a.LayoutDir, a.UseLayout, a.InsecureRegistries, a.RegistryMirrors),
+		image.NewRegistryHandler(a.keychain, a.InsecureRegistries, cmd.DefaultLogger),
 	)
 	analyzer, err := factory.NewAnalyzer(a.Inputs(), cmd.DefaultLogger)
 	if err != nil {
 		return unwrapErrorFailWithCode(err, a.CodeFor(platform.AnalyzeError), "initialize analyzer")
 	}
-	analyzedMD, err := analyzer.Analyze()
+	analyzer.Events = cmd.Events
+	analyzedMD, err := analyzer.AnalyzeContext(cmd.Context)
+	recordStats(a.ReportPath, true)
 	if err != nil {
 		return cmd.FailErrCode(err, a.CodeFor(platform.AnalyzeError), "analyze")
 	}
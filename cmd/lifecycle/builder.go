@@ -15,6 +15,8 @@ import (
 
 type buildCmd struct {
 	*platform.Platform
+
+	network *buildpack.IsolatedNetwork
 }
 
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
@@ -29,12 +31,20 @@ func (b *buildCmd) DefineFlags() {
 	default:
 		cli.FlagAppDir(&b.AppDir)
 		cli.FlagBuildpacksDir(&b.BuildpacksDir)
+		cli.FlagBuildpackTimeout(&b.BuildpackTimeout)
+		cli.FlagErrorReportPath(&b.ErrorReportPath)
+		cli.FlagEventsFD(&b.EventsFD)
+		cli.FlagEventsPath(&b.EventsPath)
 		cli.FlagGroupPath(&b.GroupPath)
 		cli.FlagLayersDir(&b.LayersDir)
 		cli.FlagLogLevel(&b.LogLevel)
 		cli.FlagNoColor(&b.NoColor)
+		cli.FlagOffline(&b.Offline)
 		cli.FlagPlanPath(&b.PlanPath)
 		cli.FlagPlatformDir(&b.PlatformDir)
+		cli.FlagReportPath(&b.ReportPath)
+		cli.FlagTracesPath(&b.TracesPath)
+		cli.FlagVerifyDigests(&b.VerifyDigests)
 	}
 }
 
@@ -54,7 +64,9 @@ func (b *buildCmd) Privileges() error {
 	if priv.IsPrivileged() {
 		return cmd.FailErr(errors.New("refusing to run as root"), "build")
 	}
-	return nil
+	var err error
+	b.network, err = isolateNetwork(b.Offline)
+	return err
 }
 
 func (b *buildCmd) Exec() error {
@@ -73,6 +85,14 @@ func (b *buildCmd) Exec() error {
 }
 
 func (b *buildCmd) build(group buildpack.Group, plan files.Plan, analyzedMD files.Analyzed) error {
+	timeouts, err := buildpack.ParseTimeouts(b.BuildpackTimeout)
+	if err != nil {
+		return cmd.FailErrCode(err, cmd.CodeForInvalidArgs, "parse buildpack timeout")
+	}
+	out, errOut, flushOutput := cmd.RedactedOutput()
+	defer flushOutput()
+	dirStore := platform.NewDirStore(b.BuildpacksDir, "")
+	defer dirStore.Close()
 	builder := &phase.Builder{
 		AppDir:         b.AppDir,
 		BuildConfigDir: b.BuildConfigDir,
@@ -80,16 +100,21 @@ func (b *buildCmd) build(group buildpack.Group, plan files.Plan, analyzedMD file
 		PlatformDir:    b.PlatformDir,
 		ExecEnv:        b.ExecEnv,
 		BuildExecutor:  &buildpack.DefaultBuildExecutor{},
-		DirStore:       platform.NewDirStore(b.BuildpacksDir, ""),
+		DirStore:       dirStore,
 		Group:          group,
 		Logger:         cmd.DefaultLogger,
-		Out:            cmd.Stdout,
-		Err:            cmd.Stderr,
+		Out:            out,
+		Err:            errOut,
+		Events:         cmd.Events,
 		Plan:           plan,
 		PlatformAPI:    b.PlatformAPI,
 		AnalyzeMD:      analyzedMD,
+		Timeouts:       timeouts,
+		VerifyDigests:  b.VerifyDigests,
+		Network:        b.network,
 	}
-	md, err := builder.Build()
+	md, err := builder.BuildContext(cmd.Context)
+	recordStats(b.ReportPath, false)
 	if err != nil {
 		return b.unwrapBuildFail(err)
 	}
@@ -98,8 +123,11 @@ func (b *buildCmd) build(group buildpack.Group, plan files.Plan, analyzedMD file
 
 func (b *buildCmd) unwrapBuildFail(err error) error {
 	if err, ok := err.(*buildpack.Error); ok {
-		if err.Type == buildpack.ErrTypeBuildpack {
-			return cmd.FailErrCode(err.Cause(), b.CodeFor(platform.FailedBuildWithErrors), "build")
+		switch err.Type {
+		case buildpack.ErrTypeBuildpack:
+			return cmd.FailErrCode(err, b.CodeFor(platform.FailedBuildWithErrors), "build")
+		case buildpack.ErrTypeTimeout:
+			return cmd.FailErrCode(err, b.CodeFor(platform.BuildTimeout), "build")
 		}
 	}
 	return cmd.FailErrCode(err, b.CodeFor(platform.BuildError), "build")
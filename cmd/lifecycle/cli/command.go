@@ -5,8 +5,12 @@ import (
 	"log"
 	"os"
 
+	"github.com/BurntSushi/toml"
+
 	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/files"
 )
 
 // Command defines the interface for running the lifecycle phases
@@ -17,6 +21,9 @@ type Command interface {
 	// Inputs returns the platform inputs
 	Inputs() platform.LifecycleInputs
 
+	// ApplyConfigFile sets the platform inputs provided in the lifecycle config file
+	ApplyConfigFile(path string) error
+
 	// Args validates arguments and flags, and fills in default values
 	Args(nargs int, args []string) error
 
@@ -25,39 +32,67 @@ type Command interface {
 
 	// Exec executes the command
 	Exec() error
+
+	// Exiter describes the exit codes for the platform, e.g., for the error report
+	platform.Exiter
 }
 
 func Run(c Command, withPhaseName string, asSubcommand bool) {
 	log.SetOutput(io.Discard)
 
-	var printVersion bool
+	var printVersion, printConfig bool
 	FlagVersion(&printVersion)
+	configPath := c.Inputs().ConfigPath
+	FlagConfigPath(&configPath)
+	FlagPrintConfig(&printConfig)
 
 	// DefineFlags (along with any function FlagXXX) defines the flags that are considered valid,
 	// but does not read the provided values; this is done by `flagSet.Parse`.
 	// The command `c` (e.g., detectCmd) is at this point already populated with platform inputs from the environment and/or default values,
 	// so command-line flags always take precedence.
 	c.DefineFlags()
+	args := os.Args[1:]
 	if asSubcommand {
-		if err := flagSet.Parse(os.Args[2:]); err != nil {
-			// flagSet exits on error, we shouldn't get here
-			cmd.Exit(err)
-		}
-	} else {
-		if err := flagSet.Parse(os.Args[1:]); err != nil {
-			// flagSet exits on error, we shouldn't get here
-			cmd.Exit(err)
+		args = os.Args[2:]
+	}
+	// The config file is applied after flags are defined but before they are parsed,
+	// so that it takes precedence over default values (but not over the environment) and command-line flags take precedence over it.
+	if path, ok := configPathFromArgs(args); ok {
+		configPath = path
+	}
+	if configPath != "" {
+		if err := c.ApplyConfigFile(configPath); err != nil {
+			cmd.Exit(cmd.FailErrCode(err, cmd.CodeForInvalidArgs, "apply config file"))
 		}
 	}
+	if err := flagSet.Parse(args); err != nil {
+		// flagSet exits on error, we shouldn't get here
+		cmd.Exit(err)
+	}
 
 	if printVersion {
 		cmd.ExitWithVersion()
 	}
+	// exit writes the error report if the command fails
+	exit := func(err error) {
+		if err != nil {
+			writeErrorReport(c, withPhaseName, err)
+		}
+		cmd.CloseEvents()
+		cmd.Exit(err)
+	}
 
 	cmd.DisableColor(c.Inputs().NoColor)
 	if err := cmd.DefaultLogger.SetLevel(c.Inputs().LogLevel); err != nil {
-		cmd.Exit(err)
+		exit(err)
 	}
+	// Secrets are known before anything else is logged, so that they are redacted from all output.
+	inputs := c.Inputs()
+	secrets, err := inputs.Secrets()
+	if err != nil {
+		exit(cmd.FailErr(err, "read secrets"))
+	}
+	cmd.Redactor.Add(secrets...)
 
 	// We print a warning here, so we should disable color if needed and set the log level before exercising this logic.
 	for _, arg := range flagSet.Args() {
@@ -79,12 +114,54 @@ func Run(c Command, withPhaseName string, asSubcommand bool) {
 
 	cmd.DefaultLogger.Debugf("Parsing inputs...")
 	if err := c.Args(flagSet.NArg(), flagSet.Args()); err != nil {
-		cmd.Exit(err)
+		exit(err)
+	}
+	if printConfig {
+		if err := toml.NewEncoder(cmd.Stdout).Encode(c.Inputs()); err != nil {
+			exit(cmd.FailErr(err, "print config"))
+		}
+		exit(nil)
+	}
+	if err := image.ConfigureTLS(c.Inputs().RegistryTLS); err != nil {
+		exit(cmd.FailErr(err, "configure registry TLS"))
+	}
+	image.ConfigureRetries(image.RetryPolicy{
+		MaxAttempts: c.Inputs().RegistryRetries + 1,
+		Delay:       c.Inputs().RegistryRetryDelay,
+		MaxDelay:    c.Inputs().RegistryRetryMaxDelay,
+		Jitter:      image.DefaultRetryPolicy.Jitter,
+	})
+	image.ConfigureTraffic()
+	// Events are configured before privileges are dropped, so that the lifecycle can write to a file owned by the platform.
+	if err := cmd.SetEvents(c.Inputs().EventsPath, c.Inputs().EventsFD); err != nil {
+		exit(cmd.FailErr(err, "configure events"))
+	}
+	endTrace, err := cmd.StartTracing(c.Inputs().TracesPath, c.Inputs().Traceparent, withPhaseName)
+	if err != nil {
+		exit(cmd.FailErr(err, "configure tracing"))
 	}
 	cmd.DefaultLogger.Debugf("Ensuring privileges...")
 	if err := c.Privileges(); err != nil {
-		cmd.Exit(err)
+		endTrace(err)
+		exit(err)
 	}
 	cmd.DefaultLogger.Debugf("Executing command...")
-	cmd.Exit(c.Exec())
+	err = c.Exec()
+	endTrace(err)
+	exit(err)
+}
+
+// writeErrorReport writes the report of the provided error, with which the command failed, at the path in the command inputs.
+func writeErrorReport(c Command, phase string, err error) {
+	inputs := c.Inputs()
+	if inputs.ErrorReportPath == "" {
+		return
+	}
+	// the command may have failed before its inputs were resolved
+	_ = platform.UpdatePlaceholderPaths(&inputs, cmd.DefaultLogger)
+	report := platform.NewErrorReport(phase, err, c)
+	report.Redact(cmd.Redactor.Redact)
+	if err := files.Handler.WriteErrorReport(inputs.ErrorReportPath, &report); err != nil {
+		cmd.DefaultLogger.Warnf("Failed to write error report: %s", err)
+	}
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"flag"
+	"strings"
 	"time"
 
 	"github.com/buildpacks/lifecycle/internal/str"
@@ -37,6 +38,14 @@ func FlagBuildpacksDir(buildpacksDir *string) {
 	flagSet.StringVar(buildpacksDir, "buildpacks", *buildpacksDir, "path to buildpacks directory")
 }
 
+func FlagBuildpackTimeout(buildpackTimeout *string) {
+	flagSet.StringVar(buildpackTimeout, "buildpack-timeout", *buildpackTimeout, "comma-separated timeouts for buildpack and extension processes, as <duration> or <id>=<duration>")
+}
+
+func FlagCacheCompression(cacheCompression *string) {
+	flagSet.StringVar(cacheCompression, "cache-compression", *cacheCompression, "compression of the layers in the cache directory, gzip or zstd")
+}
+
 func FlagCacheDir(cacheDir *string) {
 	flagSet.StringVar(cacheDir, "cache-dir", *cacheDir, "path to cache directory")
 }
@@ -45,6 +54,42 @@ func FlagCacheImage(cacheImage *string) {
 	flagSet.StringVar(cacheImage, "cache-image", *cacheImage, "cache image tag name")
 }
 
+func FlagCacheMaxAge(cacheMaxAge *time.Duration) {
+	flagSet.DurationVar(cacheMaxAge, "cache-max-age", *cacheMaxAge, "how long a layer in the cache directory may go unused before it is evicted")
+}
+
+func FlagCacheMaxSize(cacheMaxSize *string) {
+	flagSet.StringVar(cacheMaxSize, "cache-max-size", *cacheMaxSize, "maximum size of the layers in the cache directory, e.g., 10G")
+}
+
+func FlagCacheURL(cacheURL *string) {
+	flagSet.StringVar(cacheURL, "cache-url", *cacheURL, "URL of a cache in an S3-compatible object store (s3://<bucket>/<prefix>) or in OCI layout format (oci:<path>)")
+}
+
+func FlagConfigPath(configPath *string) {
+	flagSet.StringVar(configPath, "config", *configPath, "path to the lifecycle config file")
+}
+
+func FlagDetectParallelism(detectParallelism *int) {
+	flagSet.IntVar(detectParallelism, "detect-parallelism", *detectParallelism, "maximum number of buildpacks to detect concurrently across all groups (0 to detect each group in turn)")
+}
+
+func FlagDetectReportPath(detectReportPath *string) {
+	flagSet.StringVar(detectReportPath, "detect-report", *detectReportPath, "path to detect-report.toml")
+}
+
+func FlagErrorReportPath(errorReportPath *string) {
+	flagSet.StringVar(errorReportPath, "error-report", *errorReportPath, "path to the error report written if the phase fails")
+}
+
+func FlagEventsFD(eventsFD *int) {
+	flagSet.IntVar(eventsFD, "events-fd", *eventsFD, "file descriptor to write events to")
+}
+
+func FlagEventsPath(eventsPath *string) {
+	flagSet.StringVar(eventsPath, "events", *eventsPath, "path to file to append events to")
+}
+
 func FlagExtendKind(extendKind *string) {
 	flagSet.StringVar(extendKind, "kind", *extendKind, "kind of image to extend")
 }
@@ -57,6 +102,10 @@ func FlagExtensionsDir(extensionsDir *string) {
 	flagSet.StringVar(extensionsDir, "extensions", *extensionsDir, "path to extensions directory")
 }
 
+func FlagFormat(format *string) {
+	flagSet.StringVar(format, "format", *format, "format of the validation report (text or json)")
+}
+
 func FlagGID(gid *int) {
 	flagSet.IntVar(gid, "gid", *gid, "GID of user's group in the stack's build and run images")
 }
@@ -97,6 +146,10 @@ func FlagNoColor(noColor *bool) {
 	flagSet.BoolVar(noColor, "no-color", *noColor, "disable color output")
 }
 
+func FlagOffline(offline *bool) {
+	flagSet.BoolVar(offline, "offline", *offline, "run buildpacks without network access")
+}
+
 func FlagOrderPath(orderPath *string) {
 	flagSet.StringVar(orderPath, "order", *orderPath, "path to order.toml")
 }
@@ -105,6 +158,10 @@ func FlagPlanPath(planPath *string) {
 	flagSet.StringVar(planPath, "plan", *planPath, "path to plan.toml")
 }
 
+func FlagPhase(phase *string) {
+	flagSet.StringVar(phase, "phase", *phase, "phase to validate the inputs for")
+}
+
 func FlagPlatformDir(platformDir *string) {
 	flagSet.StringVar(platformDir, "platform", *platformDir, "path to platform directory")
 }
@@ -118,6 +175,10 @@ func FlagPreviousImage(previousImage *string) {
 	flagSet.StringVar(previousImage, "previous-image", *previousImage, "reference to previous image")
 }
 
+func FlagPrintConfig(printConfig *bool) {
+	flagSet.BoolVar(printConfig, "print-config", false, "print the resolved inputs in the format of the config file, and exit")
+}
+
 func FlagProcessType(processType *string) {
 	flagSet.StringVar(processType, "process-type", *processType, "default process type")
 }
@@ -126,6 +187,26 @@ func FlagProjectMetadataPath(projectMetadataPath *string) {
 	flagSet.StringVar(projectMetadataPath, "project-metadata", *projectMetadataPath, "path to project-metadata.toml")
 }
 
+func FlagRecordDigests(recordDigests *bool) {
+	flagSet.BoolVar(recordDigests, "record-digests", *recordDigests, "record the digest of each buildpack and extension directory in group.toml")
+}
+
+func FlagRegistriesPath(registriesPath *string) {
+	flagSet.StringVar(registriesPath, "registries", *registriesPath, "path to registries.toml")
+}
+
+func FlagRegistryRetries(registryRetries *int) {
+	flagSet.IntVar(registryRetries, "registry-retries", *registryRetries, "maximum number of times to retry a registry operation that fails with a transient error")
+}
+
+func FlagRegistryRetryDelay(registryRetryDelay *time.Duration) {
+	flagSet.DurationVar(registryRetryDelay, "registry-retry-delay", *registryRetryDelay, "delay before the first retry of a registry operation")
+}
+
+func FlagRegistryRetryMaxDelay(registryRetryMaxDelay *time.Duration) {
+	flagSet.DurationVar(registryRetryMaxDelay, "registry-retry-max-delay", *registryRetryMaxDelay, "maximum delay before a retry of a registry operation, including delays requested by the registry")
+}
+
 func FlagReportPath(reportPath *string) {
 	flagSet.StringVar(reportPath, "report", *reportPath, "path to report.toml")
 }
@@ -159,6 +240,10 @@ func FlagTags(tags *str.Slice) {
 	flagSet.Var(tags, "tag", "additional tags")
 }
 
+func FlagTracesPath(tracesPath *string) {
+	flagSet.StringVar(tracesPath, "traces", *tracesPath, "path to file to append trace spans to")
+}
+
 func FlagUID(uid *int) {
 	flagSet.IntVar(uid, "uid", *uid, "UID of user in the stack's build and run images")
 }
@@ -167,6 +252,10 @@ func FlagUseDaemon(useDaemon *bool) {
 	flagSet.BoolVar(useDaemon, "daemon", *useDaemon, "export to docker daemon")
 }
 
+func FlagVerifyDigests(verifyDigests *bool) {
+	flagSet.BoolVar(verifyDigests, "verify-digests", *verifyDigests, "fail if a buildpack directory does not match the digest in group.toml")
+}
+
 func FlagVersion(showVersion *bool) {
 	flagSet.BoolVar(showVersion, "version", false, "show version")
 }
@@ -186,3 +275,38 @@ func FlagInsecureRegistries(insecureRegistries *str.Slice) {
 func DeprecatedFlagRunImage(deprecatedRunImage *string) {
 	flagSet.StringVar(deprecatedRunImage, "image", "", "[deprecated] reference to run image")
 }
+
+// configPathFromArgs returns the value of the -config flag in the provided command-line arguments, if it is provided,
+// so that the config file can be applied before the command-line flags are parsed (and take precedence over it).
+// Flags must be defined before calling configPathFromArgs, in order to tell flags with values from boolean flags.
+func configPathFromArgs(args []string) (string, bool) {
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return "", false
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "config" {
+			if hasValue {
+				return value, true
+			}
+			if idx+1 < len(args) {
+				return args[idx+1], true
+			}
+			return "", false
+		}
+		if !hasValue && !isBoolFlag(name) {
+			idx++ // skip the value of the flag
+		}
+	}
+	return "", false
+}
+
+func isBoolFlag(name string) bool {
+	f := flagSet.Lookup(name)
+	if f == nil {
+		return false
+	}
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
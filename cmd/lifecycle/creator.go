@@ -23,8 +23,9 @@ import (
 type createCmd struct {
 	*platform.Platform
 
-	docker   client.APIClient // construct if necessary before dropping privileges
-	keychain authn.Keychain   // construct if necessary before dropping privileges
+	docker   client.APIClient           // construct if necessary before dropping privileges
+	keychain authn.Keychain             // construct if necessary before dropping privileges
+	network  *buildpack.IsolatedNetwork // construct if necessary before dropping privileges
 }
 
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
@@ -34,6 +35,10 @@ func (c *createCmd) DefineFlags() {
 	}
 	if c.PlatformAPI.AtLeast("0.13") {
 		cli.FlagInsecureRegistries(&c.InsecureRegistries)
+		cli.FlagRegistriesPath(&c.RegistriesPath)
+		cli.FlagRegistryRetries(&c.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&c.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&c.RegistryRetryMaxDelay)
 	}
 	if c.PlatformAPI.AtLeast("0.12") {
 		cli.FlagLayoutDir(&c.LayoutDir)
@@ -46,27 +51,41 @@ func (c *createCmd) DefineFlags() {
 	}
 	cli.FlagAppDir(&c.AppDir)
 	cli.FlagBuildpacksDir(&c.BuildpacksDir)
+	cli.FlagBuildpackTimeout(&c.BuildpackTimeout)
+	cli.FlagCacheCompression(&c.CacheCompression)
 	cli.FlagCacheDir(&c.CacheDir)
 	cli.FlagCacheImage(&c.CacheImageRef)
+	cli.FlagCacheMaxAge(&c.CacheMaxAge)
+	cli.FlagCacheMaxSize(&c.CacheMaxSize)
+	cli.FlagCacheURL(&c.CacheURL)
+	cli.FlagDetectParallelism(&c.DetectParallelism)
+	cli.FlagDetectReportPath(&c.DetectReportPath)
+	cli.FlagErrorReportPath(&c.ErrorReportPath)
+	cli.FlagEventsFD(&c.EventsFD)
+	cli.FlagEventsPath(&c.EventsPath)
 	cli.FlagGID(&c.GID)
 	cli.FlagLaunchCacheDir(&c.LaunchCacheDir)
 	cli.FlagLauncherPath(&c.LauncherPath)
 	cli.FlagLayersDir(&c.LayersDir)
 	cli.FlagLogLevel(&c.LogLevel)
 	cli.FlagNoColor(&c.NoColor)
+	cli.FlagOffline(&c.Offline)
 	cli.FlagOrderPath(&c.OrderPath)
 	cli.FlagParallelExport(&c.ParallelExport)
 	cli.FlagPlatformDir(&c.PlatformDir)
 	cli.FlagPreviousImage(&c.PreviousImageRef)
 	cli.FlagProcessType(&c.DefaultProcessType)
 	cli.FlagProjectMetadataPath(&c.ProjectMetadataPath)
+	cli.FlagRecordDigests(&c.RecordDigests)
 	cli.FlagReportPath(&c.ReportPath)
 	cli.FlagRunImage(&c.RunImageRef)
 	cli.FlagSkipRestore(&c.SkipLayers)
 	cli.FlagStackPath(&c.StackPath)
 	cli.FlagTags(&c.AdditionalTags)
+	cli.FlagTracesPath(&c.TracesPath)
 	cli.FlagUID(&c.UID)
 	cli.FlagUseDaemon(&c.UseDaemon)
+	cli.FlagVerifyDigests(&c.VerifyDigests)
 }
 
 // Args validates arguments and flags, and fills in default values.
@@ -98,6 +117,9 @@ func (c *createCmd) Privileges() error {
 			return cmd.FailErr(err, "initialize docker client")
 		}
 	}
+	if c.network, err = isolateNetwork(c.Offline); err != nil {
+		return err
+	}
 	if err = priv.EnsureOwner(c.UID, c.GID, c.CacheDir, c.LaunchCacheDir, c.LayersDir); err != nil {
 		return cmd.FailErr(err, "chown volumes")
 	}
@@ -111,11 +133,12 @@ func (c *createCmd) Privileges() error {
 }
 
 func (c *createCmd) Exec() error {
-	cacheStore, err := initCache(c.CacheImageRef, c.CacheDir, c.keychain, c.PlatformAPI.LessThan("0.13"), c.InsecureRegistries...)
+	cacheStore, err := initCache(c.LifecycleInputs, c.keychain)
 	if err != nil {
 		return err
 	}
 	dirStore := platform.NewDirStore(c.BuildpacksDir, c.ExtensionsDir)
+	defer dirStore.Close()
 
 	// Analyze
 	var (
@@ -129,14 +152,16 @@ func (c *createCmd) Exec() error {
 		&cmd.BuildpackAPIVerifier{},
 		NewCacheHandler(c.keychain, c.InsecureRegistries),
 		files.NewHandler(),
-		image.NewHandler(c.docker, c.keychain, c.LayoutDir, c.UseLayout, c.InsecureRegistries),
-		image.NewRegistryHandler(c.keychain, c.InsecureRegistries),
+		image.NewHandler(c.docker, c.keychain, c.LayoutDir, c.UseLayout, c.InsecureRegistries, c.RegistryMirrors),
+		image.NewRegistryHandler(c.keychain, c.InsecureRegistries, cmd.DefaultLogger),
 	)
 	analyzer, err := analyzerFactory.NewAnalyzer(c.Inputs(), cmd.DefaultLogger)
 	if err != nil {
 		return unwrapErrorFailWithCode(err, c.CodeFor(platform.AnalyzeError), "initialize analyzer")
 	}
-	analyzedMD, err = analyzer.Analyze()
+	analyzer.Events = cmd.Events
+	analyzedMD, err = analyzer.AnalyzeContext(cmd.Context)
+	recordStats(c.ReportPath, true)
 	if err != nil {
 		return cmd.FailErrCode(err, c.CodeFor(platform.AnalyzeError), "analyze")
 	}
@@ -156,7 +181,10 @@ func (c *createCmd) Exec() error {
 	if err != nil {
 		return unwrapErrorFailWithMessage(err, "initialize detector")
 	}
+	detector.Events = cmd.Events
+	detector.Network = c.network
 	group, plan, err = doDetect(detector, c.Platform)
+	recordStats(c.ReportPath, false)
 	if err != nil {
 		return err // pass through error
 	}
@@ -183,7 +211,7 @@ func (c *createCmd) Exec() error {
 	// Build
 	stopPinging := startPinging(c.docker) // send pings to docker daemon while building to prevent connection closure
 	cmd.DefaultLogger.Phase("BUILDING")
-	buildCmd := &buildCmd{Platform: c.Platform}
+	buildCmd := &buildCmd{Platform: c.Platform, network: c.network}
 	err = buildCmd.build(group, plan, analyzedMD)
 	stopPinging()
 	if err != nil {
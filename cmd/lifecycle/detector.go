@@ -14,6 +14,8 @@ import (
 
 type detectCmd struct {
 	*platform.Platform
+
+	network *buildpack.IsolatedNetwork
 }
 
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
@@ -34,13 +36,23 @@ func (d *detectCmd) DefineFlags() {
 	}
 	cli.FlagAppDir(&d.AppDir)
 	cli.FlagBuildpacksDir(&d.BuildpacksDir)
+	cli.FlagBuildpackTimeout(&d.BuildpackTimeout)
+	cli.FlagDetectParallelism(&d.DetectParallelism)
+	cli.FlagDetectReportPath(&d.DetectReportPath)
+	cli.FlagErrorReportPath(&d.ErrorReportPath)
+	cli.FlagEventsFD(&d.EventsFD)
+	cli.FlagEventsPath(&d.EventsPath)
 	cli.FlagGroupPath(&d.GroupPath)
 	cli.FlagLayersDir(&d.LayersDir)
 	cli.FlagLogLevel(&d.LogLevel)
 	cli.FlagNoColor(&d.NoColor)
+	cli.FlagOffline(&d.Offline)
 	cli.FlagOrderPath(&d.OrderPath)
 	cli.FlagPlanPath(&d.PlanPath)
 	cli.FlagPlatformDir(&d.PlatformDir)
+	cli.FlagRecordDigests(&d.RecordDigests)
+	cli.FlagReportPath(&d.ReportPath)
+	cli.FlagTracesPath(&d.TracesPath)
 }
 
 // Args validates arguments and flags, and fills in default values.
@@ -59,11 +71,14 @@ func (d *detectCmd) Privileges() error {
 	if priv.IsPrivileged() {
 		return cmd.FailErr(errors.New("refusing to run as root"), "detect")
 	}
-	return nil
+	var err error
+	d.network, err = isolateNetwork(d.Offline)
+	return err
 }
 
 func (d *detectCmd) Exec() error {
 	dirStore := platform.NewDirStore(d.BuildpacksDir, d.ExtensionsDir)
+	defer dirStore.Close()
 	detectorFactory := phase.NewHermeticFactory(
 		d.PlatformAPI,
 		&cmd.BuildpackAPIVerifier{},
@@ -77,6 +92,9 @@ func (d *detectCmd) Exec() error {
 	if err != nil {
 		return unwrapErrorFailWithMessage(err, "initialize detector")
 	}
+	detector.Events = cmd.Events
+	detector.Network = d.network
+	defer recordStats(d.ReportPath, false)
 	if detector.HasExtensions && detector.PlatformAPI.LessThan("0.13") {
 		if err = platform.GuardExperimental(platform.FeatureDockerfiles, cmd.DefaultLogger); err != nil {
 			return err
@@ -93,17 +111,21 @@ func (d *detectCmd) Exec() error {
 			files.Handler,
 			dirStore,
 		)
+		out, errOut, flushOutput := cmd.RedactedOutput()
+		defer flushOutput()
 		var generator *phase.Generator
 		generator, err = generatorFactory.NewGenerator(
 			d.Inputs(),
-			cmd.Stdout, cmd.Stderr,
+			out, errOut,
 			cmd.DefaultLogger,
 		)
 		if err != nil {
 			return unwrapErrorFailWithMessage(err, "initialize generator")
 		}
+		generator.Events = cmd.Events
+		generator.Network = d.network
 		var result phase.GenerateResult
-		result, err = generator.Generate()
+		result, err = generator.GenerateContext(cmd.Context)
 		if err != nil {
 			return d.unwrapGenerateFail(err)
 		}
@@ -133,15 +155,25 @@ func unwrapErrorFailWithCode(err error, code int, msg string) error {
 
 func (d *detectCmd) unwrapGenerateFail(err error) error {
 	if err, ok := err.(*buildpack.Error); ok {
-		if err.Type == buildpack.ErrTypeBuildpack {
-			return cmd.FailErrCode(err.Cause(), d.CodeFor(platform.FailedGenerateWithErrors), "build")
+		switch err.Type {
+		case buildpack.ErrTypeBuildpack:
+			return cmd.FailErrCode(err, d.CodeFor(platform.FailedGenerateWithErrors), "build")
+		case buildpack.ErrTypeTimeout:
+			return cmd.FailErrCode(err, d.CodeFor(platform.GenerateTimeout), "build")
 		}
 	}
 	return cmd.FailErrCode(err, d.CodeFor(platform.GenerateError), "build")
 }
 
 func doDetect(detector *phase.Detector, p *platform.Platform) (buildpack.Group, files.Plan, error) {
-	group, plan, err := detector.Detect()
+	group, plan, err := detector.DetectContext(cmd.Context)
+	if p.DetectReportPath != "" && detector.Report != nil {
+		// the report is most useful when detection fails, so it is written before handling the error
+		detector.Report.Redact(cmd.Redactor.Redact)
+		if err := files.Handler.WriteDetectReport(p.DetectReportPath, detector.Report); err != nil {
+			return buildpack.Group{}, files.Plan{}, err
+		}
+	}
 	if err != nil {
 		switch err := err.(type) {
 		case *buildpack.Error:
@@ -153,6 +185,9 @@ func doDetect(detector *phase.Detector, p *platform.Platform) (buildpack.Group,
 			case buildpack.ErrTypeBuildpack:
 				cmd.DefaultLogger.Error("No buildpack groups passed detection.")
 				return buildpack.Group{}, files.Plan{}, cmd.FailErrCode(err, p.CodeFor(platform.FailedDetectWithErrors), "detect")
+			case buildpack.ErrTypeTimeout:
+				cmd.DefaultLogger.Error("No buildpack groups passed detection; at least one buildpack timed out.")
+				return buildpack.Group{}, files.Plan{}, cmd.FailErrCode(err, p.CodeFor(platform.DetectTimeout), "detect")
 			default:
 				return buildpack.Group{}, files.Plan{}, cmd.FailErrCode(err, p.CodeFor(platform.DetectError), "detect")
 			}
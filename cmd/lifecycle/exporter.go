@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -51,6 +50,10 @@ type exportData struct {
 func (e *exportCmd) DefineFlags() {
 	if e.PlatformAPI.AtLeast("0.13") {
 		cli.FlagInsecureRegistries(&e.InsecureRegistries)
+		cli.FlagRegistriesPath(&e.RegistriesPath)
+		cli.FlagRegistryRetries(&e.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&e.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&e.RegistryRetryMaxDelay)
 	}
 	if e.PlatformAPI.AtLeast("0.12") {
 		cli.FlagExtendedDir(&e.ExtendedDir)
@@ -63,8 +66,16 @@ func (e *exportCmd) DefineFlags() {
 	}
 	cli.FlagAnalyzedPath(&e.AnalyzedPath)
 	cli.FlagAppDir(&e.AppDir)
+	cli.FlagBuildpacksDir(&e.BuildpacksDir)
+	cli.FlagCacheCompression(&e.CacheCompression)
 	cli.FlagCacheDir(&e.CacheDir)
 	cli.FlagCacheImage(&e.CacheImageRef)
+	cli.FlagCacheMaxAge(&e.CacheMaxAge)
+	cli.FlagCacheMaxSize(&e.CacheMaxSize)
+	cli.FlagCacheURL(&e.CacheURL)
+	cli.FlagErrorReportPath(&e.ErrorReportPath)
+	cli.FlagEventsFD(&e.EventsFD)
+	cli.FlagEventsPath(&e.EventsPath)
 	cli.FlagGID(&e.GID)
 	cli.FlagGroupPath(&e.GroupPath)
 	cli.FlagLaunchCacheDir(&e.LaunchCacheDir)
@@ -77,8 +88,10 @@ func (e *exportCmd) DefineFlags() {
 	cli.FlagProjectMetadataPath(&e.ProjectMetadataPath)
 	cli.FlagReportPath(&e.ReportPath)
 	cli.FlagRunImage(&e.RunImageRef) // FIXME: this flag isn't valid on Platform 0.7 and later
+	cli.FlagTracesPath(&e.TracesPath)
 	cli.FlagUID(&e.UID)
 	cli.FlagUseDaemon(&e.UseDaemon)
+	cli.FlagVerifyDigests(&e.VerifyDigests)
 
 	// deprecated
 	cli.DeprecatedFlagRunImage(&e.DeprecatedRunImageRef) // FIXME: this flag isn't valid on Platform 0.7 and later
@@ -141,7 +154,7 @@ func (e *exportCmd) Exec() error {
 	if err = verifyBuildpackApis(group); err != nil {
 		return err
 	}
-	cacheStore, err := initCache(e.CacheImageRef, e.CacheDir, e.keychain, e.PlatformAPI.LessThan("0.13"), e.InsecureRegistries...)
+	cacheStore, err := initCache(e.LifecycleInputs, e.keychain)
 	if err != nil {
 		return err
 	}
@@ -164,6 +177,13 @@ func (e *exportCmd) registryImages() []string {
 }
 
 func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyzedMD files.Analyzed) error {
+	if e.VerifyDigests {
+		dirStore := platform.NewDirStore(e.BuildpacksDir, "")
+		defer dirStore.Close()
+		if err := phase.VerifyDigests(dirStore, buildpack.KindBuildpack, group.Group); err != nil {
+			return cmd.FailErrCode(err, e.CodeFor(platform.ExportError), "verify buildpack digests")
+		}
+	}
 	artifactsDir, err := os.MkdirTemp("", "lifecycle.exporter.layer")
 	if err != nil {
 		return cmd.FailErr(err, "create temp directory")
@@ -176,10 +196,10 @@ func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyz
 	}
 
 	g := new(errgroup.Group)
-	var ctx context.Context
+	ctx := cmd.Context
 
 	if e.ParallelExport {
-		g, ctx = errgroup.WithContext(context.Background())
+		g, ctx = errgroup.WithContext(ctx)
 	}
 	exporter := &phase.Exporter{
 		Buildpacks: group.Group,
@@ -192,6 +212,7 @@ func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyz
 		},
 		Logger:      cmd.DefaultLogger,
 		PlatformAPI: e.PlatformAPI,
+		Events:      cmd.Events,
 	}
 
 	var (
@@ -216,7 +237,7 @@ func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyz
 	}
 
 	g.Go(func() error {
-		report, err := exporter.Export(phase.ExportOptions{
+		report, err := exporter.ExportContext(ctx, phase.ExportOptions{
 			AdditionalNames:    e.AdditionalTags,
 			AppDir:             e.AppDir,
 			DefaultProcessType: e.DefaultProcessType,
@@ -233,6 +254,12 @@ func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyz
 		if err != nil {
 			return cmd.FailErrCode(err, e.CodeFor(platform.ExportError), "export")
 		}
+		if prev, err := files.Handler.ReadReport(e.ReportPath); err == nil {
+			report.Timings, report.Resources, report.Network = prev.Timings, prev.Resources, prev.Network
+		} else {
+			cmd.DefaultLogger.Warnf("Failed to read timings and resources from report: %s", err)
+		}
+		cmd.Recorder.Flush(&report)
 		if err = files.Handler.WriteReport(e.ReportPath, &report); err != nil {
 			return cmd.FailErrCode(err, e.CodeFor(platform.ExportError), "write export report")
 		}
@@ -241,24 +268,23 @@ func (e *exportCmd) export(group buildpack.Group, cacheStore phase.Cache, analyz
 
 	if !e.ParallelExport {
 		if err := g.Wait(); err != nil {
+			recordStats(e.ReportPath, false)
 			return err
 		}
 	}
 
 	g.Go(func() error {
 		if cacheStore != nil {
-			if cacheErr := exporter.Cache(e.LayersDir, cacheStore); cacheErr != nil {
+			if cacheErr := exporter.CacheContext(cmd.Context, e.LayersDir, cacheStore); cacheErr != nil {
 				cmd.DefaultLogger.Warnf("Failed to export cache: %v\n", cacheErr)
 			}
 		}
 		return nil
 	})
 
-	if err = g.Wait(); err != nil {
-		return err
-	}
-
-	return nil
+	err = g.Wait()
+	recordStats(e.ReportPath, false)
+	return err
 }
 
 func (e *exportCmd) initDaemonAppImage(analyzedMD files.Analyzed, logger log.Logger) (imgutil.Image, string, error) {
@@ -314,8 +340,9 @@ func (e *exportCmd) initDaemonAppImage(analyzedMD files.Analyzed, logger log.Log
 }
 
 func (e *exportCmd) initRemoteAppImage(analyzedMD files.Analyzed) (imgutil.Image, string, error) {
+	insecureOpts := image.GetInsecureOptions(e.InsecureRegistries)
 	var appOpts = []imgutil.ImageOption{
-		remote.FromBaseImage(e.RunImageRef),
+		remote.FromBaseImage(e.RegistryMirrors.Resolve(e.RunImageRef, e.keychain, insecureOpts...)),
 	}
 
 	if e.supportsRunImageExtension() {
@@ -333,18 +360,18 @@ func (e *exportCmd) initRemoteAppImage(analyzedMD files.Analyzed) (imgutil.Image
 		appOpts = append(appOpts, remote.WithHistory())
 	}
 
-	appOpts = append(appOpts, image.GetInsecureOptions(e.InsecureRegistries)...)
+	appOpts = append(appOpts, insecureOpts...)
 
 	if analyzedMD.PreviousImageRef() != "" {
 		cmd.DefaultLogger.Infof("Reusing layers from image '%s'", analyzedMD.PreviousImageRef())
-		appOpts = append(appOpts, remote.WithPreviousImage(analyzedMD.PreviousImageRef()))
+		appOpts = append(appOpts, remote.WithPreviousImage(e.RegistryMirrors.Resolve(analyzedMD.PreviousImageRef(), e.keychain, insecureOpts...)))
 	}
 
 	if !e.customSourceDateEpoch().IsZero() {
 		appOpts = append(appOpts, remote.WithCreatedAt(e.customSourceDateEpoch()))
 	}
 
-	appImage, err := phase.OpenRemoteImage(cmd.DefaultLogger, func() (imgutil.Image, error) {
+	appImage, err := phase.OpenRemoteImageContext(cmd.Context, cmd.DefaultLogger, func() (imgutil.Image, error) {
 		return remote.NewImage(
 			e.OutputImageRef,
 			e.keychain,
@@ -356,14 +383,7 @@ func (e *exportCmd) initRemoteAppImage(analyzedMD files.Analyzed) (imgutil.Image
 	}
 
 	runImageID, err := func() (string, error) {
-		runImage, err := remote.NewImage(
-			e.RunImageRef,
-			e.keychain,
-			append(
-				image.GetInsecureOptions(e.InsecureRegistries),
-				remote.FromBaseImage(e.RunImageRef),
-			)...,
-		)
+		runImage, err := e.RegistryMirrors.NewImage(e.RunImageRef, e.keychain, insecureOpts...)
 		if err != nil {
 			return "", fmt.Errorf("failed to access run image: %w", err)
 		}
@@ -27,6 +27,7 @@ func (e *extendCmd) DefineFlags() {
 	cli.FlagAnalyzedPath(&e.AnalyzedPath)
 	cli.FlagAppDir(&e.AppDir)
 	cli.FlagBuildpacksDir(&e.BuildpacksDir)
+	cli.FlagErrorReportPath(&e.ErrorReportPath)
 	cli.FlagGID(&e.GID)
 	cli.FlagGeneratedDir(&e.GeneratedDir)
 	cli.FlagGroupPath(&e.GroupPath)
@@ -36,6 +37,7 @@ func (e *extendCmd) DefineFlags() {
 	cli.FlagNoColor(&e.NoColor)
 	cli.FlagPlanPath(&e.PlanPath)
 	cli.FlagPlatformDir(&e.PlatformDir)
+	cli.FlagRegistriesPath(&e.RegistriesPath)
 	cli.FlagUID(&e.UID)
 }
 
@@ -12,8 +12,11 @@ import (
 	"github.com/buildpacks/lifecycle/cache"
 	"github.com/buildpacks/lifecycle/cmd"
 	"github.com/buildpacks/lifecycle/cmd/lifecycle/cli"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/internal/str"
 	"github.com/buildpacks/lifecycle/phase"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/files"
 )
 
 func main() {
@@ -73,8 +76,10 @@ func subcommand(platformAPI string) {
 		cli.Run(&createCmd{Platform: platform.NewPlatformFor(platformAPI)}, phase, true)
 	case "extend":
 		cli.Run(&extendCmd{Platform: platform.NewPlatformFor(platformAPI)}, phase, true)
+	case "validate":
+		cli.Run(&validateCmd{Platform: platform.NewPlatformFor(platformAPI), phaseName: platform.Create.String(), format: formatText}, phase, true)
 	default:
-		cmd.Exit(cmd.FailCode(cmd.CodeForInvalidArgs, "recognize phase:", phase, "\nValid phases: detect, analyze, restore, build, export, rebase, create, extend"))
+		cmd.Exit(cmd.FailCode(cmd.CodeForInvalidArgs, "recognize phase:", phase, "\nValid phases: detect, analyze, restore, build, export, rebase, create, extend, validate"))
 	}
 }
 
@@ -115,26 +120,91 @@ func (ch *DefaultCacheHandler) InitCache(cacheImageRef string, cacheDir string,
 
 // helpers
 
-func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain, deletionEnabled bool, insecureRegistries ...string) (phase.Cache, error) {
+// initCache returns the cache image, cache URL (object store or OCI layout), or cache directory provided in the inputs,
+// which must already be resolved.
+func initCache(inputs *platform.LifecycleInputs, keychain authn.Keychain) (phase.Cache, error) {
 	var (
 		cacheStore phase.Cache
 		err        error
 	)
 	logger := cmd.DefaultLogger
-	if cacheImageTag != "" {
-		cacheStore, err = cache.NewImageCacheFromName(cacheImageTag, keychain, logger, cache.NewImageDeleter(cache.NewImageComparer(), logger, deletionEnabled), insecureRegistries...)
+	if inputs.CacheImageRef != "" {
+		deleter := cache.NewImageDeleter(cache.NewImageComparer(), logger, inputs.PlatformAPI.LessThan("0.13"))
+		cacheStore, err = cache.NewImageCacheFromName(inputs.CacheImageRef, keychain, logger, deleter, inputs.InsecureRegistries...)
 		if err != nil {
 			return nil, cmd.FailErr(err, "create image cache")
 		}
-	} else if cacheDir != "" {
-		cacheStore, err = cache.NewVolumeCache(cacheDir, logger)
+	} else if inputs.CacheURL != "" {
+		cacheStore, err = cacheFromURL(inputs.CacheURL)
+		if err != nil {
+			return nil, cmd.FailErr(err, "create cache from URL")
+		}
+	} else if inputs.CacheDir != "" {
+		volumeCache, err := cache.NewVolumeCache(inputs.CacheDir, logger)
 		if err != nil {
 			return nil, cmd.FailErr(err, "create volume cache")
 		}
+		volumeCache.SetEvictionPolicy(evictionPolicy(inputs))
+		volumeCache.SetCompression(cacheCompression(inputs))
+		cacheStore = volumeCache
 	}
 	return cacheStore, nil
 }
 
+// recordStats adds the timings, resources and registry traffic recorded since the last call to the report at the provided path.
+// If fresh is true, any existing report is replaced, as it describes a previous build.
+// It is called whether or not the phase succeeds, so that the report describes failed builds.
+func recordStats(reportPath string, fresh bool) {
+	var (
+		report files.Report
+		err    error
+	)
+	if !fresh {
+		report, err = files.Handler.ReadReport(reportPath)
+	}
+	if err == nil {
+		cmd.Recorder.Flush(&report)
+		read, written := image.Traffic.Take()
+		report.Resources.Registry.BytesRead += read
+		report.Resources.Registry.BytesWritten += written
+		err = files.Handler.WriteReport(reportPath, &report)
+	}
+	if err != nil {
+		cmd.DefaultLogger.Warnf("Failed to record timings and resources: %s", err)
+	}
+}
+
+// cacheFromURL returns the cache in OCI layout format for an `oci:<path>` cache URL, or the object store cache for any other cache URL.
+func cacheFromURL(cacheURL string) (phase.Cache, error) {
+	if path, ok := strings.CutPrefix(cacheURL, platform.OCILayoutCacheScheme); ok {
+		return cache.NewLayoutCache(path, cmd.DefaultLogger)
+	}
+	return cache.NewS3CacheFromURL(cacheURL, cmd.DefaultLogger)
+}
+
+// evictionPolicy returns the policy for evicting layers from the cache directory; the inputs must already be resolved.
+func evictionPolicy(inputs *platform.LifecycleInputs) cache.EvictionPolicy {
+	maxSize, _ := str.ParseSize(inputs.CacheMaxSize) // validated when resolving inputs
+	return cache.EvictionPolicy{MaxSize: maxSize, MaxAge: inputs.CacheMaxAge}
+}
+
+// cacheCompression returns how layers added to the cache directory are compressed; the inputs must already be resolved.
+func cacheCompression(inputs *platform.LifecycleInputs) cache.Compression {
+	return cache.Compression(inputs.CacheCompression)
+}
+
+// isolateNetwork creates the network namespace in which buildpacks are run if the build is offline.
+func isolateNetwork(offline bool) (*buildpack.IsolatedNetwork, error) {
+	if !offline {
+		return nil, nil
+	}
+	network, err := buildpack.NewIsolatedNetwork()
+	if err != nil {
+		return nil, cmd.FailErr(err, "isolate network")
+	}
+	return network, nil
+}
+
 func verifyBuildpackApis(group buildpack.Group) error {
 	for _, bp := range group.Group {
 		if err := cmd.VerifyBuildpackAPI(buildpack.KindBuildpack, bp.String(), bp.API, cmd.DefaultLogger); err != nil { // FIXME: when exporter is extensions-aware, this function call should be modified to provide the right module kind
@@ -34,6 +34,10 @@ type rebaseCmd struct {
 func (r *rebaseCmd) DefineFlags() {
 	if r.PlatformAPI.AtLeast("0.13") {
 		cli.FlagInsecureRegistries(&r.InsecureRegistries)
+		cli.FlagRegistriesPath(&r.RegistriesPath)
+		cli.FlagRegistryRetries(&r.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&r.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&r.RegistryRetryMaxDelay)
 	}
 	if r.PlatformAPI.AtLeast("0.12") {
 		cli.FlagForceRebase(&r.ForceRebase)
@@ -42,11 +46,15 @@ func (r *rebaseCmd) DefineFlags() {
 		cli.FlagPreviousImage(&r.PreviousImageRef)
 	}
 	cli.DeprecatedFlagRunImage(&r.DeprecatedRunImageRef)
+	cli.FlagErrorReportPath(&r.ErrorReportPath)
+	cli.FlagEventsFD(&r.EventsFD)
+	cli.FlagEventsPath(&r.EventsPath)
 	cli.FlagGID(&r.GID)
 	cli.FlagLogLevel(&r.LogLevel)
 	cli.FlagNoColor(&r.NoColor)
 	cli.FlagReportPath(&r.ReportPath)
 	cli.FlagRunImage(&r.RunImageRef)
+	cli.FlagTracesPath(&r.TracesPath)
 	cli.FlagUID(&r.UID)
 	cli.FlagUseDaemon(&r.UseDaemon)
 }
@@ -113,13 +121,8 @@ func (r *rebaseCmd) Exec() error {
 			local.FromBaseImage(r.RunImageRef),
 		)
 	} else {
-		newBaseImage, err = phase.OpenRemoteImage(cmd.DefaultLogger, func() (imgutil.Image, error) {
-			opts := append(image.GetInsecureOptions(r.InsecureRegistries), remote.FromBaseImage(r.RunImageRef))
-			return remote.NewImage(
-				r.RunImageRef,
-				r.keychain,
-				opts...,
-			)
+		newBaseImage, err = phase.OpenRemoteImageContext(cmd.Context, cmd.DefaultLogger, func() (imgutil.Image, error) {
+			return r.RegistryMirrors.NewImage(r.RunImageRef, r.keychain, image.GetInsecureOptions(r.InsecureRegistries)...)
 		})
 	}
 	if err != nil || !newBaseImage.Found() {
@@ -130,8 +133,9 @@ func (r *rebaseCmd) Exec() error {
 		Logger:      cmd.DefaultLogger,
 		PlatformAPI: r.PlatformAPI,
 		Force:       r.ForceRebase,
+		Events:      cmd.Events,
 	}
-	report, err := rebaser.Rebase(r.appImage, newBaseImage, r.OutputImageRef, r.AdditionalTags)
+	report, err := rebaser.RebaseContext(cmd.Context, r.appImage, newBaseImage, r.OutputImageRef, r.AdditionalTags)
 	if err != nil {
 		return cmd.FailErrCode(err, r.CodeFor(platform.RebaseError), "rebase")
 	}
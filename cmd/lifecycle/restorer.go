@@ -9,7 +9,6 @@ import (
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/layout"
 
-	"github.com/buildpacks/imgutil/remote"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/moby/moby/client"
 
@@ -39,6 +38,10 @@ type restoreCmd struct {
 func (r *restoreCmd) DefineFlags() {
 	if r.PlatformAPI.AtLeast("0.13") {
 		cli.FlagInsecureRegistries(&r.InsecureRegistries)
+		cli.FlagRegistriesPath(&r.RegistriesPath)
+		cli.FlagRegistryRetries(&r.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&r.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&r.RegistryRetryMaxDelay)
 	}
 	if r.PlatformAPI.AtLeast("0.12") {
 		cli.FlagUseDaemon(&r.UseDaemon)
@@ -55,12 +58,18 @@ func (r *restoreCmd) DefineFlags() {
 	}
 	cli.FlagCacheDir(&r.CacheDir)
 	cli.FlagCacheImage(&r.CacheImageRef)
+	cli.FlagCacheURL(&r.CacheURL)
+	cli.FlagErrorReportPath(&r.ErrorReportPath)
+	cli.FlagEventsFD(&r.EventsFD)
+	cli.FlagEventsPath(&r.EventsPath)
 	cli.FlagGID(&r.GID)
 	cli.FlagGroupPath(&r.GroupPath)
 	cli.FlagLayersDir(&r.LayersDir)
 	cli.FlagLogLevel(&r.LogLevel)
 	cli.FlagNoColor(&r.NoColor)
+	cli.FlagReportPath(&r.ReportPath)
 	cli.FlagSkipLayers(&r.SkipLayers)
+	cli.FlagTracesPath(&r.TracesPath)
 	cli.FlagUID(&r.UID)
 }
 
@@ -149,7 +158,7 @@ func (r *restoreCmd) Exec() error {
 			}
 		} else if r.needsUpdating(analyzedMD.RunImage, group) {
 			cmd.DefaultLogger.Debugf("Updating run image info in analyzed metadata...")
-			h := image.NewHandler(r.docker, r.keychain, r.LayoutDir, r.UseLayout, r.InsecureRegistries)
+			h := image.NewHandler(r.docker, r.keychain, r.LayoutDir, r.UseLayout, r.InsecureRegistries, r.RegistryMirrors)
 			runImage, err = h.InitImage(accessibleRunImage)
 			if err != nil || !runImage.Found() {
 				return cmd.FailErr(err, fmt.Sprintf("get run image %s", accessibleRunImage))
@@ -165,7 +174,7 @@ func (r *restoreCmd) Exec() error {
 		cmd.DefaultLogger.Warnf("Not using analyzed data, usable file not found: %s", err)
 	}
 
-	cacheStore, err := initCache(r.CacheImageRef, r.CacheDir, r.keychain, r.PlatformAPI.LessThan("0.13"), r.InsecureRegistries...)
+	cacheStore, err := initCache(r.LifecycleInputs, r.keychain)
 	if err != nil {
 		return err
 	}
@@ -258,11 +267,8 @@ func (r *restoreCmd) pullSparse(imageRef string) (imgutil.Image, error) {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	var opts []imgutil.ImageOption
-	opts = append(opts, append(image.GetInsecureOptions(r.InsecureRegistries), remote.FromBaseImage(imageRef))...)
-
 	// get remote image
-	remoteImage, err := remote.NewImage(imageRef, r.keychain, opts...)
+	remoteImage, err := r.RegistryMirrors.NewImage(imageRef, r.keychain, image.GetInsecureOptions(r.InsecureRegistries)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize remote image: %w", err)
 	}
@@ -309,13 +315,16 @@ func (r *restoreCmd) restore(layerMetadata files.LayersMetadata, group buildpack
 		PlatformAPI:           r.PlatformAPI,
 		LayerMetadataRestorer: layer.NewDefaultMetadataRestorer(r.LayersDir, r.SkipLayers, cmd.DefaultLogger, r.PlatformAPI),
 		LayersMetadata:        layerMetadata,
+		Events:                cmd.Events,
 		SBOMRestorer: layer.NewSBOMRestorer(layer.SBOMRestorerOpts{
 			LayersDir: r.LayersDir,
 			Logger:    cmd.DefaultLogger,
 			Nop:       r.SkipLayers,
 		}, r.PlatformAPI),
 	}
-	if err := restorer.Restore(cacheStore); err != nil {
+	err := restorer.RestoreContext(cmd.Context, cacheStore)
+	recordStats(r.ReportPath, false)
+	if err != nil {
 		return cmd.FailErrCode(err, r.CodeFor(platform.RestoreError), "restore")
 	}
 	return nil
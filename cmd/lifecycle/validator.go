@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/auth"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/cmd/lifecycle/cli"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/phase"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/files"
+	"github.com/buildpacks/lifecycle/priv"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// validateCmd checks the inputs for another phase without running it, so that a platform can reject a build
+// that would fail before scheduling it. It runs with the privileges it is invoked with, and doesn't drop them.
+type validateCmd struct {
+	*platform.Platform
+
+	phaseName string
+	format    string
+
+	targetPhase platform.LifecyclePhase
+	report      phase.ValidationReport
+	keychain    authn.Keychain
+}
+
+// DefineFlags defines the flags that are considered valid and reads their values (if provided).
+func (v *validateCmd) DefineFlags() {
+	if v.PlatformAPI.AtLeast("0.15") {
+		cli.FlagSystemPath(&v.SystemPath)
+	}
+	if v.PlatformAPI.AtLeast("0.13") {
+		cli.FlagInsecureRegistries(&v.InsecureRegistries)
+		cli.FlagRegistriesPath(&v.RegistriesPath)
+		cli.FlagRegistryRetries(&v.RegistryRetries)
+		cli.FlagRegistryRetryDelay(&v.RegistryRetryDelay)
+		cli.FlagRegistryRetryMaxDelay(&v.RegistryRetryMaxDelay)
+	}
+	if v.PlatformAPI.AtLeast("0.12") {
+		cli.FlagLayoutDir(&v.LayoutDir)
+		cli.FlagUseLayout(&v.UseLayout)
+		cli.FlagRunPath(&v.RunPath)
+	}
+	if v.PlatformAPI.AtLeast("0.10") {
+		cli.FlagExtensionsDir(&v.ExtensionsDir)
+	}
+	cli.FlagAnalyzedPath(&v.AnalyzedPath)
+	cli.FlagBuildpacksDir(&v.BuildpacksDir)
+	cli.FlagBuildpackTimeout(&v.BuildpackTimeout)
+	cli.FlagCacheDir(&v.CacheDir)
+	cli.FlagCacheImage(&v.CacheImageRef)
+	cli.FlagErrorReportPath(&v.ErrorReportPath)
+	cli.FlagFormat(&v.format)
+	cli.FlagGID(&v.GID)
+	cli.FlagGroupPath(&v.GroupPath)
+	cli.FlagLaunchCacheDir(&v.LaunchCacheDir)
+	cli.FlagLayersDir(&v.LayersDir)
+	cli.FlagLogLevel(&v.LogLevel)
+	cli.FlagNoColor(&v.NoColor)
+	cli.FlagOrderPath(&v.OrderPath)
+	cli.FlagPhase(&v.phaseName)
+	cli.FlagPreviousImage(&v.PreviousImageRef)
+	cli.FlagRunImage(&v.RunImageRef)
+	cli.FlagStackPath(&v.StackPath)
+	cli.FlagUID(&v.UID)
+	cli.FlagUseDaemon(&v.UseDaemon)
+}
+
+// Args validates arguments and flags, and fills in default values.
+// Problems with the inputs for the target phase are added to the report, rather than returned.
+func (v *validateCmd) Args(_ int, args []string) error {
+	var err error
+	if v.targetPhase, err = platform.ParseLifecyclePhase(v.phaseName); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeForInvalidArgs, "parse arguments")
+	}
+	if v.format != formatText && v.format != formatJSON {
+		return cmd.FailErrCode(fmt.Errorf("unknown format '%s', must be '%s' or '%s'", v.format, formatText, formatJSON), cmd.CodeForInvalidArgs, "parse arguments")
+	}
+	if len(args) > 0 {
+		v.OutputImageRef = args[0]
+		v.AdditionalTags = args[1:]
+	}
+	v.report = phase.ValidationReport{Phase: v.phaseName, Problems: []phase.ValidationProblem{}}
+	v.report.Add(phase.CheckInputs, platform.ValidateInputs(v.targetPhase, v.LifecycleInputs, cmd.DefaultLogger)...)
+	return nil
+}
+
+func (v *validateCmd) Privileges() error {
+	var err error
+	v.keychain, err = auth.DefaultKeychain(v.RegistryImages()...)
+	if err != nil {
+		return cmd.FailErr(err, "resolve keychain")
+	}
+	return nil
+}
+
+func (v *validateCmd) Exec() error {
+	dirStore := platform.NewDirStore(v.BuildpacksDir, v.ExtensionsDir)
+	defer dirStore.Close()
+	validator := &phase.Validator{
+		PlatformAPI:     v.PlatformAPI,
+		APIVerifier:     &cmd.BuildpackAPIVerifier{},
+		ConfigHandler:   files.NewHandler(),
+		DirStore:        dirStore,
+		RegistryHandler: image.NewRegistryHandler(v.keychain, v.InsecureRegistries, cmd.DefaultLogger),
+		Logger:          cmd.DefaultLogger,
+	}
+	validator.Validate(v.targetPhase, v.Inputs(), &v.report)
+	if v.targetPhase != platform.Rebase {
+		for _, dir := range []string{v.LayersDir, v.CacheDir, v.LaunchCacheDir} {
+			if dir != "" {
+				v.report.Add(phase.CheckOwnership, priv.CheckOwner(v.UID, v.GID, dir))
+			}
+		}
+	}
+
+	if err := v.printReport(); err != nil {
+		return cmd.FailErr(err, "print report")
+	}
+	if len(v.report.Problems) > 0 {
+		return cmd.FailErrCode(fmt.Errorf("found %d problem(s) with the inputs for the %s phase", len(v.report.Problems), v.phaseName), cmd.CodeForInvalidArgs, "validate")
+	}
+	return nil
+}
+
+func (v *validateCmd) printReport() error {
+	if v.format == formatJSON {
+		encoder := json.NewEncoder(cmd.Stdout)
+		encoder.SetIndent("", "  ")
+		return errors.Wrap(encoder.Encode(v.report), "encoding report")
+	}
+	if len(v.report.Problems) == 0 {
+		cmd.DefaultLogger.Infof("No problems found with the inputs for the %s phase", v.phaseName)
+		return nil
+	}
+	cmd.DefaultLogger.Infof("Found %d problem(s) with the inputs for the %s phase:", len(v.report.Problems), v.phaseName)
+	for _, problem := range v.report.Problems {
+		cmd.DefaultLogger.Infof("  [%s] %s", problem.Check, problem.Message)
+	}
+	return nil
+}
@@ -1,24 +1,41 @@
 package cmd
 
 import (
+	"io"
 	"os"
 
 	"github.com/heroku/color"
 
+	"github.com/buildpacks/lifecycle/auth"
 	"github.com/buildpacks/lifecycle/log"
 )
 
 func init() {
 	// Uncomment when https://github.com/buildpacks/pack/issues/493 (lifecycle containers with a tty) is implemented
 	// color.Disable(!terminal.IsTerminal(int(os.Stdout.Fd())))
+	DefaultLogger.SetRedactor(Redactor)
+	auth.CredentialHelperSecrets = Redactor.Add
 }
 
 var (
 	DefaultLogger = log.NewDefaultLogger(Stdout)
 	Stdout        = color.NewConsole(os.Stdout)
 	Stderr        = color.NewConsole(os.Stderr)
+	// Redactor masks secrets (see platform.LifecycleInputs.Secrets and auth.CredentialHelperSecrets) in the output of DefaultLogger,
+	// and in the output of buildpacks and extensions (see RedactedOutput).
+	Redactor = log.NewRedactor()
 )
 
+// RedactedOutput returns writers for the output of buildpacks and extensions that mask secrets before writing to Stdout and Stderr,
+// and a function to flush any output that was held back.
+func RedactedOutput() (stdout, stderr io.Writer, flush func()) {
+	out, errOut := Redactor.Writer(Stdout), Redactor.Writer(Stderr)
+	return out, errOut, func() {
+		_ = out.Flush()
+		_ = errOut.Flush()
+	}
+}
+
 func DisableColor(noColor bool) {
 	Stdout.DisableColors(noColor)
 	Stderr.DisableColors(noColor)
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/buildpacks/lifecycle/internal/tracing"
+)
+
+// Context carries the span of the running command once StartTracing is called;
+// phases started with it are recorded as child spans.
+var Context = context.Background()
+
+// StartTracing configures spans to be written to the file at the provided path, as part of the trace identified by traceparent,
+// and starts a span for the named command.
+// The returned function ends the span and flushes any recorded spans; it should be called before the process exits.
+func StartTracing(path, traceparent, name string) (func(err error), error) {
+	ctx, shutdown, err := tracing.Init(path, traceparent, buildVersion())
+	if err != nil {
+		return nil, err
+	}
+	ctx, span := tracing.Start(ctx, name)
+	Context = ctx
+	return func(err error) {
+		tracing.End(span, err)
+		if err := shutdown(); err != nil {
+			DefaultLogger.Warnf("Failed to write trace spans: %s", err)
+		}
+	}, nil
+}
@@ -20,9 +20,9 @@ type Handler interface {
 // NewHandler creates a new Handler according to the arguments provided, following these rules:
 // - WHEN layoutDir is defined and useLayout is true then it returns a LayoutHandler
 // - WHEN a docker client is provided then it returns a LocalHandler
-// - WHEN an auth.Keychain is provided then it returns a RemoteHandler
+// - WHEN an auth.Keychain is provided then it returns a RemoteHandler, which pulls images from the provided mirrors if possible
 // - Otherwise nil is returned
-func NewHandler(docker client.APIClient, keychain authn.Keychain, layoutDir string, useLayout bool, insecureRegistries []string) Handler {
+func NewHandler(docker client.APIClient, keychain authn.Keychain, layoutDir string, useLayout bool, insecureRegistries []string, mirrors Mirrors) Handler {
 	if layoutDir != "" && useLayout {
 		return &LayoutHandler{
 			layoutDir: layoutDir,
@@ -37,6 +37,7 @@ func NewHandler(docker client.APIClient, keychain authn.Keychain, layoutDir stri
 		return &RemoteHandler{
 			keychain:           keychain,
 			insecureRegistries: insecureRegistries,
+			mirrors:            mirrors,
 		}
 	}
 	return nil
@@ -38,7 +38,7 @@ func testHandler(t *testing.T, when spec.G, it spec.S) {
 
 	when("Remote handler", func() {
 		it("returns a remote handler", func() {
-			handler := NewHandler(nil, mockKeychain, "", false, []string{"insecure-registry"})
+			handler := NewHandler(nil, mockKeychain, "", false, []string{"insecure-registry"}, nil)
 
 			_, ok := handler.(*RemoteHandler)
 
@@ -48,7 +48,7 @@ func testHandler(t *testing.T, when spec.G, it spec.S) {
 
 	when("Local handler", func() {
 		it("returns a local handler", func() {
-			handler := NewHandler(dockerClient, mockKeychain, "", false, []string{})
+			handler := NewHandler(dockerClient, mockKeychain, "", false, []string{}, nil)
 
 			_, ok := handler.(*LocalHandler)
 
@@ -58,7 +58,7 @@ func testHandler(t *testing.T, when spec.G, it spec.S) {
 
 	when("Layout handler", func() {
 		it("returns a layout handler", func() {
-			handler := NewHandler(nil, mockKeychain, "random-dir", true, []string{})
+			handler := NewHandler(nil, mockKeychain, "random-dir", true, []string{}, nil)
 
 			_, ok := handler.(*LayoutHandler)
 
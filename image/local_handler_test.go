@@ -24,7 +24,7 @@ func testLocalImageHandler(t *testing.T, when spec.G, it spec.S) {
 	when("Local handler", func() {
 		it.Before(func() {
 			dockerClient = h.DockerCli(t)
-			imageHandler = image.NewHandler(dockerClient, nil, "", false, []string{})
+			imageHandler = image.NewHandler(dockerClient, nil, "", false, []string{}, nil)
 			h.AssertNotNil(t, imageHandler)
 		})
 
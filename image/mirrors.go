@@ -0,0 +1,134 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/remote"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// Mirrors maps a registry (e.g., `docker.io`) or repository prefix (e.g., `docker.io/library`)
+// to the registries or repositories that mirror it (e.g., `mirror.internal/dockerhub`), in the order they should be tried.
+// Images are pulled from the first mirror that has them, falling back to the registry in the image reference (the origin).
+type Mirrors map[string][]string
+
+// Candidates returns the references to try, in order, when pulling the image with the provided reference:
+// the reference rewritten for each mirror of the longest prefix that matches it, followed by the reference itself.
+// A reference that cannot be parsed, or that does not match any prefix, is returned as is.
+func (m Mirrors) Candidates(imageRef string) []string {
+	if len(m) == 0 {
+		return []string{imageRef}
+	}
+	ref, err := name.ParseReference(imageRef, name.WeakValidation)
+	if err != nil {
+		return []string{imageRef}
+	}
+	repo := ref.Context().Name()
+	var (
+		matched string
+		mirrors []string
+	)
+	for prefix, prefixMirrors := range m {
+		normalized := normalizePrefix(prefix)
+		if (repo == normalized || strings.HasPrefix(repo, normalized+"/")) && len(normalized) > len(matched) {
+			matched, mirrors = normalized, prefixMirrors
+		}
+	}
+	var candidates []string
+	for _, mirror := range mirrors {
+		mirrored := strings.TrimSuffix(mirror, "/") + strings.TrimPrefix(repo, matched)
+		if _, ok := ref.(name.Digest); ok {
+			mirrored += "@" + ref.Identifier()
+		} else {
+			mirrored += ":" + ref.Identifier()
+		}
+		candidates = append(candidates, mirrored)
+	}
+	return append(candidates, imageRef)
+}
+
+// normalizePrefix returns the provided prefix with its registry in canonical form (e.g., `docker.io` becomes `index.docker.io`),
+// so that it can be compared to the repository of a parsed reference.
+func normalizePrefix(prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	registry, rest, _ := strings.Cut(prefix, "/")
+	reg, err := name.NewRegistry(registry, name.WeakValidation)
+	if err != nil {
+		return prefix
+	}
+	if rest == "" {
+		return reg.Name()
+	}
+	return reg.Name() + "/" + rest
+}
+
+// Resolve returns the first of the candidates for the provided image reference (see Candidates) that exists in its registry,
+// or else the reference itself.
+func (m Mirrors) Resolve(imageRef string, keychain authn.Keychain, opts ...imgutil.ImageOption) string {
+	candidates := m.Candidates(imageRef)
+	for _, candidate := range candidates[:len(candidates)-1] {
+		img, err := remote.NewImage(candidate, keychain, opts...)
+		if err == nil && img.Found() {
+			return candidate
+		}
+	}
+	return imageRef
+}
+
+// NewImage returns the remote image with the provided reference, pulled from its first mirror that has it (see Resolve).
+// When pulled from a mirror, the image's name and identifier still refer to the provided reference,
+// and the mirror can be obtained with MirrorOf.
+func (m Mirrors) NewImage(imageRef string, keychain authn.Keychain, opts ...imgutil.ImageOption) (imgutil.Image, error) {
+	pullRef := m.Resolve(imageRef, keychain, opts...)
+	img, err := remote.NewImage(pullRef, keychain, append(opts, remote.FromBaseImage(pullRef))...)
+	if err != nil {
+		return nil, err
+	}
+	if pullRef == imageRef {
+		return img, nil
+	}
+	return &mirroredImage{Image: img, origin: imageRef, mirror: pullRef}, nil
+}
+
+// MirrorOf returns the reference that the provided image was pulled from, if it was pulled from a mirror (see Mirrors.NewImage);
+// otherwise it returns an empty string.
+func MirrorOf(img imgutil.Image) string {
+	if mirrored, ok := img.(*mirroredImage); ok {
+		return mirrored.mirror
+	}
+	return ""
+}
+
+// mirroredImage is a remote image pulled from a mirror, that is named for the reference it mirrors.
+type mirroredImage struct {
+	imgutil.Image
+	origin string
+	mirror string
+}
+
+func (i *mirroredImage) Name() string {
+	return i.origin
+}
+
+func (i *mirroredImage) Identifier() (imgutil.Identifier, error) {
+	identifier, err := i.Image.Identifier()
+	if err != nil {
+		return nil, err
+	}
+	digestIdentifier, ok := identifier.(remote.DigestIdentifier)
+	if !ok {
+		return identifier, nil
+	}
+	ref, err := name.ParseReference(i.origin, name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reference for image %q: %w", i.origin, err)
+	}
+	digest, err := name.NewDigest(ref.Context().Name()+"@"+digestIdentifier.Digest.DigestStr(), name.WeakValidation)
+	if err != nil {
+		return nil, fmt.Errorf("creating digest reference: %w", err)
+	}
+	return remote.DigestIdentifier{Digest: digest}, nil
+}
@@ -0,0 +1,85 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/buildpacks/imgutil/fakes"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestMirrors(t *testing.T) {
+	spec.Run(t, "Mirrors", testMirrors, spec.Report(report.Terminal{}))
+}
+
+func testMirrors(t *testing.T, when spec.G, it spec.S) {
+	when("#Candidates", func() {
+		var mirrors Mirrors
+
+		it.Before(func() {
+			mirrors = Mirrors{
+				"docker.io":                        {"mirror.internal/dockerhub", "other-mirror.internal/dockerhub/"},
+				"some.registry":                    {"mirror.internal/some-registry"},
+				"some.registry/some-org/some-repo": {"mirror.internal/some-repo"},
+			}
+		})
+
+		it("returns the reference for each mirror followed by the reference", func() {
+			h.AssertEq(t, mirrors.Candidates("docker.io/library/ubuntu:22.04"), []string{
+				"mirror.internal/dockerhub/library/ubuntu:22.04",
+				"other-mirror.internal/dockerhub/library/ubuntu:22.04",
+				"docker.io/library/ubuntu:22.04",
+			})
+		})
+
+		it("matches references that omit the registry or the tag", func() {
+			h.AssertEq(t, mirrors.Candidates("ubuntu"), []string{
+				"mirror.internal/dockerhub/library/ubuntu:latest",
+				"other-mirror.internal/dockerhub/library/ubuntu:latest",
+				"ubuntu",
+			})
+		})
+
+		it("keeps the digest of digest references", func() {
+			digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			h.AssertEq(t, mirrors.Candidates("some.registry/some-org/some-other-repo@"+digest), []string{
+				"mirror.internal/some-registry/some-org/some-other-repo@" + digest,
+				"some.registry/some-org/some-other-repo@" + digest,
+			})
+		})
+
+		it("uses the longest matching prefix", func() {
+			h.AssertEq(t, mirrors.Candidates("some.registry/some-org/some-repo:some-tag"), []string{
+				"mirror.internal/some-repo:some-tag",
+				"some.registry/some-org/some-repo:some-tag",
+			})
+		})
+
+		it("does not match a prefix that is not a whole path component", func() {
+			h.AssertEq(t, mirrors.Candidates("some.registry/some-org/some-repo-2:some-tag"), []string{
+				"mirror.internal/some-registry/some-org/some-repo-2:some-tag",
+				"some.registry/some-org/some-repo-2:some-tag",
+			})
+		})
+
+		it("returns only the reference when no prefix matches", func() {
+			h.AssertEq(t, mirrors.Candidates("some-other.registry/some-repo:some-tag"), []string{"some-other.registry/some-repo:some-tag"})
+			h.AssertEq(t, Mirrors(nil).Candidates("some.registry/some-repo"), []string{"some.registry/some-repo"})
+		})
+	})
+
+	when("#MirrorOf", func() {
+		it("returns the mirror the image was pulled from", func() {
+			img := &mirroredImage{Image: fakes.NewImage("mirror.internal/some-repo", "", nil), origin: "some.registry/some-repo", mirror: "mirror.internal/some-repo"}
+
+			h.AssertEq(t, MirrorOf(img), "mirror.internal/some-repo")
+			h.AssertEq(t, img.Name(), "some.registry/some-repo")
+		})
+
+		it("returns an empty string for images that were not pulled from a mirror", func() {
+			h.AssertEq(t, MirrorOf(fakes.NewImage("some.registry/some-repo", "", nil)), "")
+		})
+	})
+}
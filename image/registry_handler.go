@@ -1,11 +1,16 @@
 package image
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/remote"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/buildpacks/lifecycle/log"
 )
 
 // RegistryHandler takes care of the registry settings and checks
@@ -20,20 +25,23 @@ type RegistryHandler interface {
 type DefaultRegistryHandler struct {
 	keychain         authn.Keychain
 	insecureRegistry []string
+	logger           log.Logger
 }
 
-// NewRegistryHandler creates a new DefaultRegistryHandler
-func NewRegistryHandler(keychain authn.Keychain, insecureRegistries []string) *DefaultRegistryHandler {
+// NewRegistryHandler creates a new DefaultRegistryHandler;
+// access checks that fail with a transient error are retried according to Retries.
+func NewRegistryHandler(keychain authn.Keychain, insecureRegistries []string, logger log.Logger) *DefaultRegistryHandler {
 	return &DefaultRegistryHandler{
 		keychain:         keychain,
 		insecureRegistry: insecureRegistries,
+		logger:           logger,
 	}
 }
 
 // EnsureReadAccess ensures that we can read from the registry
 func (rv *DefaultRegistryHandler) EnsureReadAccess(imageRefs ...string) error {
 	for _, imageRef := range imageRefs {
-		if err := verifyReadAccess(imageRef, rv.keychain, GetInsecureOptions(rv.insecureRegistry)); err != nil {
+		if err := verifyReadAccess(imageRef, rv.keychain, GetInsecureOptions(rv.insecureRegistry), rv.logger); err != nil {
 			return err
 		}
 	}
@@ -43,13 +51,41 @@ func (rv *DefaultRegistryHandler) EnsureReadAccess(imageRefs ...string) error {
 // EnsureWriteAccess ensures that we can write to the registry
 func (rv *DefaultRegistryHandler) EnsureWriteAccess(imageRefs ...string) error {
 	for _, imageRef := range imageRefs {
-		if err := verifyReadWriteAccess(imageRef, rv.keychain, GetInsecureOptions(rv.insecureRegistry)); err != nil {
+		if err := verifyReadWriteAccess(imageRef, rv.keychain, GetInsecureOptions(rv.insecureRegistry), rv.logger); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// AccessError is returned when the registry access needed for an image cannot be ensured.
+type AccessError struct {
+	ImageRef string
+	Access   string // "read" or "read/write"
+	Err      error
+}
+
+func (e *AccessError) Error() string {
+	message := fmt.Sprintf("failed to ensure registry %s access to %s", e.Access, e.ImageRef)
+	if e.Err == nil {
+		return message
+	}
+	return fmt.Sprintf("%s: %s", message, e.Err)
+}
+
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// Denied returns true if the registry rejected the credentials for the image (or the absence of credentials).
+func (e *AccessError) Denied() bool {
+	var transportErr *transport.Error
+	if !errors.As(e.Err, &transportErr) {
+		return false
+	}
+	return transportErr.StatusCode == http.StatusUnauthorized || transportErr.StatusCode == http.StatusForbidden
+}
+
 // GetInsecureOptions returns a list of WithRegistrySetting imageOptions matching the specified imageRef prefix
 /*
 TODO: This is a temporary solution in order to get insecure registries in other components too
@@ -64,29 +100,39 @@ func GetInsecureOptions(insecureRegistries []string) []imgutil.ImageOption {
 	return opts
 }
 
-func verifyReadAccess(imageRef string, keychain authn.Keychain, opts []imgutil.ImageOption) error {
+func verifyReadAccess(imageRef string, keychain authn.Keychain, opts []imgutil.ImageOption, logger log.Logger) error {
 	if imageRef == "" {
 		return nil
 	}
 
 	img, _ := remote.NewImage(imageRef, keychain, opts...)
-	canRead, err := img.CheckReadAccess()
+	var canRead bool
+	err := Retries.Do(logger, "check registry read access to "+imageRef, func() error {
+		var err error
+		canRead, err = img.CheckReadAccess()
+		return err
+	})
 	if !canRead {
-		return fmt.Errorf("failed to ensure registry read access to %s: %w", imageRef, err)
+		return &AccessError{ImageRef: imageRef, Access: "read", Err: err}
 	}
 
 	return nil
 }
 
-func verifyReadWriteAccess(imageRef string, keychain authn.Keychain, opts []imgutil.ImageOption) error {
+func verifyReadWriteAccess(imageRef string, keychain authn.Keychain, opts []imgutil.ImageOption, logger log.Logger) error {
 	if imageRef == "" {
 		return nil
 	}
 
 	img, _ := remote.NewImage(imageRef, keychain, opts...)
-	canReadWrite, err := img.CheckReadWriteAccess()
+	var canReadWrite bool
+	err := Retries.Do(logger, "check registry read/write access to "+imageRef, func() error {
+		var err error
+		canReadWrite, err = img.CheckReadWriteAccess()
+		return err
+	})
 	if !canReadWrite {
-		return fmt.Errorf("failed to ensure registry read/write access to %s: %w", imageRef, err)
+		return &AccessError{ImageRef: imageRef, Access: "read/write", Err: err}
 	}
 	return nil
 }
@@ -2,7 +2,6 @@ package image
 
 import (
 	"github.com/buildpacks/imgutil"
-	"github.com/buildpacks/imgutil/remote"
 	"github.com/google/go-containerregistry/pkg/authn"
 )
 
@@ -11,6 +10,7 @@ const RemoteKind = "remote"
 type RemoteHandler struct {
 	keychain           authn.Keychain
 	insecureRegistries []string
+	mirrors            Mirrors
 }
 
 func (h *RemoteHandler) InitImage(imageRef string) (imgutil.Image, error) {
@@ -18,16 +18,10 @@ func (h *RemoteHandler) InitImage(imageRef string) (imgutil.Image, error) {
 		return nil, nil
 	}
 
-	options := []imgutil.ImageOption{
-		remote.FromBaseImage(imageRef),
-	}
-
-	options = append(options, GetInsecureOptions(h.insecureRegistries)...)
-
-	return remote.NewImage(
+	return h.mirrors.NewImage(
 		imageRef,
 		h.keychain,
-		options...,
+		GetInsecureOptions(h.insecureRegistries)...,
 	)
 }
 
@@ -26,7 +26,7 @@ func testRemoteImageHandler(t *testing.T, when spec.G, it spec.S) {
 		it.Before(func() {
 			auth = authn.DefaultKeychain
 			insecureRegistries = []string{"host.docker.internal", "another.host.internal"}
-			imageHandler = image.NewHandler(nil, auth, "", false, insecureRegistries)
+			imageHandler = image.NewHandler(nil, auth, "", false, insecureRegistries, nil)
 			h.AssertNotNil(t, imageHandler)
 		})
 
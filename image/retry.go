@@ -0,0 +1,202 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+
+	"github.com/buildpacks/lifecycle/log"
+)
+
+// RetryPolicy describes how registry reads and writes are retried when they fail with a transient error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an operation is attempted; values less than 1 are treated as 1.
+	MaxAttempts int
+	// Delay is the delay before the first retry; each subsequent delay is doubled, up to MaxDelay.
+	Delay time.Duration
+	// MaxDelay is the maximum delay before a retry,
+	// including the delay requested by a registry through the `Retry-After` header (see Transport).
+	MaxDelay time.Duration
+	// Jitter is the fraction (between 0 and 1) of each delay that is randomized,
+	// so that concurrent operations throttled by the same registry do not retry in lockstep.
+	Jitter float64
+	// Sleep waits between attempts; if nil, time.Sleep is used.
+	Sleep func(time.Duration)
+}
+
+// DefaultRetryPolicy retries an operation up to 5 times, waiting 100ms before the first retry.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	Delay:       100 * time.Millisecond,
+	MaxDelay:    time.Minute,
+	Jitter:      0.2,
+}
+
+// Retries is the policy used for every registry read and write; see ConfigureRetries.
+var Retries = DefaultRetryPolicy
+
+// ConfigureRetries sets the policy used for registry reads and writes,
+// and configures the default HTTP transport (used for every registry that is not insecure) to honor `Retry-After` (see Transport).
+func ConfigureRetries(policy RetryPolicy) {
+	Retries = policy
+	if t, ok := http.DefaultTransport.(*retryAfterTransport); ok {
+		t.mu.Lock()
+		t.maxDelay = policy.MaxDelay
+		t.mu.Unlock()
+		return
+	}
+	http.DefaultTransport = policy.Transport(http.DefaultTransport)
+}
+
+// Do calls fn until it succeeds, it fails with an error that is not retryable (see IsRetryable),
+// or MaxAttempts is reached, returning the last error.
+// The operation (e.g., "open remote image") is used to describe failed attempts in the logs.
+func (p RetryPolicy) Do(logger log.Logger, operation string, fn func() error) error {
+	return p.DoContext(context.Background(), logger, operation, fn)
+}
+
+// DoContext is like Do, but stops retrying once the provided context is done, returning the error of the context.
+// An attempt that is in progress is not interrupted unless fn itself observes the context.
+func (p RetryPolicy) DoContext(ctx context.Context, logger log.Logger, operation string, fn func() error) error {
+	attempts := max(p.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn()
+		if err == nil {
+			if attempt > 1 {
+				logger.Infof("Able to %s after %d retries", operation, attempt-1)
+			}
+			return nil
+		}
+		if attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+		delay := p.delay(attempt)
+		logger.Warnf("Failed to %s (attempt %d/%d): %v, retrying in %v", operation, attempt, attempts, err, delay)
+		if p.Sleep != nil {
+			p.Sleep(delay)
+			continue
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the delay before retrying after the provided (1-based) attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration(p.Jitter * float64(delay) * (2*rand.Float64() - 1)) // #nosec G404 -- jitter does not need to be secure
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return max(delay, 0)
+}
+
+// IsRetryable returns true if the error is likely transient and the operation that caused it should be retried.
+// 400 Bad Request, 401 Unauthorized, 403 Forbidden, and 405 Method Not Allowed are not retryable as they indicate auth/config issues,
+// while 429 Too Many Requests and 503 Service Unavailable are retried once the registry allows it.
+// An imgutil.SaveError is retryable only if the error for every tag is retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if saveErr, ok := errors.AsType[imgutil.SaveError](err); ok {
+		for _, diagnostic := range saveErr.Errors {
+			if !IsRetryable(diagnostic.Cause) {
+				return false
+			}
+		}
+		return true
+	}
+	if tErr, ok := errors.AsType[*transport.Error](err); ok {
+		return tErr.StatusCode != http.StatusBadRequest &&
+			tErr.StatusCode != http.StatusUnauthorized &&
+			tErr.StatusCode != http.StatusForbidden &&
+			tErr.StatusCode != http.StatusMethodNotAllowed
+	}
+	return true
+}
+
+// Transport returns a transport that delays each request to a registry until the period requested by the registry
+// through the `Retry-After` header of an earlier 429 Too Many Requests or 503 Service Unavailable response has elapsed
+// (up to MaxDelay), so that retries, including those made by go-containerregistry itself, honor `Retry-After`.
+func (p RetryPolicy) Transport(base http.RoundTripper) http.RoundTripper {
+	return &retryAfterTransport{base: base, maxDelay: p.MaxDelay, notBefore: map[string]time.Time{}}
+}
+
+type retryAfterTransport struct {
+	base     http.RoundTripper
+	maxDelay time.Duration
+
+	mu        sync.Mutex
+	notBefore map[string]time.Time // by host
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.wait(req.URL.Host); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			t.mu.Lock()
+			if t.maxDelay > 0 && delay > t.maxDelay {
+				delay = t.maxDelay
+			}
+			if until := time.Now().Add(delay); until.After(t.notBefore[req.URL.Host]) {
+				t.notBefore[req.URL.Host] = until
+			}
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+func (t *retryAfterTransport) wait(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Until(t.notBefore[host])
+}
+
+// parseRetryAfter returns the delay requested by the value of a `Retry-After` header,
+// which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
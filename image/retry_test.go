@@ -0,0 +1,249 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRetry(t *testing.T) {
+	spec.Run(t, "Retry", testRetry, spec.Report(report.Terminal{}))
+}
+
+func testRetry(t *testing.T, when spec.G, it spec.S) {
+	var (
+		logger *log.Logger
+		sleeps []time.Duration
+		policy RetryPolicy
+	)
+
+	it.Before(func() {
+		logger = &log.Logger{Handler: memory.New()}
+		sleeps = nil
+		policy = RetryPolicy{
+			MaxAttempts: 4,
+			Delay:       100 * time.Millisecond,
+			MaxDelay:    300 * time.Millisecond,
+			Sleep:       func(d time.Duration) { sleeps = append(sleeps, d) },
+		}
+	})
+
+	when("#Do", func() {
+		it("retries with exponential backoff up to the maximum delay", func() {
+			var calls int
+			err := policy.Do(logger, "some operation", func() error {
+				calls++
+				return errors.New("some transient error")
+			})
+
+			h.AssertError(t, err, "some transient error")
+			h.AssertEq(t, calls, 4)
+			h.AssertEq(t, sleeps, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond})
+		})
+
+		it("stops retrying once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			var calls int
+			err := policy.DoContext(ctx, logger, "some operation", func() error {
+				calls++
+				cancel()
+				return errors.New("some transient error")
+			})
+
+			h.AssertEq(t, errors.Is(err, context.Canceled), true)
+			h.AssertEq(t, calls, 1)
+		})
+
+		it("stops waiting to retry once the context is done", func() {
+			policy.Sleep = nil
+			policy.Delay = time.Hour
+			policy.MaxDelay = time.Hour
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := policy.DoContext(ctx, logger, "some operation", func() error {
+				return errors.New("some transient error")
+			})
+
+			h.AssertEq(t, errors.Is(err, context.DeadlineExceeded), true)
+		})
+
+		it("stops retrying once the operation succeeds", func() {
+			var calls int
+			err := policy.Do(logger, "some operation", func() error {
+				calls++
+				if calls < 2 {
+					return &transport.Error{StatusCode: http.StatusTooManyRequests}
+				}
+				return nil
+			})
+
+			h.AssertNil(t, err)
+			h.AssertEq(t, calls, 2)
+			h.AssertEq(t, len(sleeps), 1)
+		})
+
+		it("does not retry errors that are not retryable", func() {
+			var calls int
+			err := policy.Do(logger, "some operation", func() error {
+				calls++
+				return &transport.Error{StatusCode: http.StatusUnauthorized}
+			})
+
+			h.AssertNotNil(t, err)
+			h.AssertEq(t, calls, 1)
+			h.AssertEq(t, len(sleeps), 0)
+		})
+
+		it("makes a single attempt when MaxAttempts is less than 1", func() {
+			policy.MaxAttempts = 0
+			var calls int
+			_ = policy.Do(logger, "some operation", func() error {
+				calls++
+				return errors.New("some transient error")
+			})
+
+			h.AssertEq(t, calls, 1)
+		})
+
+		it("randomizes each delay by the jitter", func() {
+			policy.Jitter = 0.5
+			policy.MaxDelay = time.Minute
+			_ = policy.Do(logger, "some operation", func() error {
+				return errors.New("some transient error")
+			})
+
+			for i, delay := range sleeps {
+				base := policy.Delay << i
+				h.AssertEq(t, delay >= base/2 && delay <= base+base/2, true)
+			}
+		})
+	})
+
+	when("#IsRetryable", func() {
+		it("returns true for non-transport errors", func() {
+			h.AssertEq(t, IsRetryable(errors.New("generic error")), true)
+		})
+
+		it("returns true for transient transport errors", func() {
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusInternalServerError}), true)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusBadGateway}), true)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusTooManyRequests}), true)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusServiceUnavailable}), true)
+		})
+
+		it("returns false for auth and config errors", func() {
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusBadRequest}), false)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusUnauthorized}), false)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusForbidden}), false)
+			h.AssertEq(t, IsRetryable(&transport.Error{StatusCode: http.StatusMethodNotAllowed}), false)
+		})
+
+		it("returns false when the context is canceled", func() {
+			h.AssertEq(t, IsRetryable(context.Canceled), false)
+		})
+
+		it("returns true for save errors only if every tag failed with a retryable error", func() {
+			h.AssertEq(t, IsRetryable(imgutil.SaveError{Errors: []imgutil.SaveDiagnostic{
+				{ImageName: "some-image", Cause: &transport.Error{StatusCode: http.StatusTooManyRequests}},
+				{ImageName: "some-other-image", Cause: errors.New("connection reset")},
+			}}), true)
+			h.AssertEq(t, IsRetryable(imgutil.SaveError{Errors: []imgutil.SaveDiagnostic{
+				{ImageName: "some-image", Cause: &transport.Error{StatusCode: http.StatusTooManyRequests}},
+				{ImageName: "some-other-image", Cause: &transport.Error{StatusCode: http.StatusForbidden}},
+			}}), false)
+		})
+	})
+
+	when("#Transport", func() {
+		var (
+			server   *httptest.Server
+			requests []time.Time
+		)
+
+		it.Before(func() {
+			requests = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests = append(requests, time.Now())
+				if len(requests) == 1 {
+					w.Header().Set("Retry-After", "120")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("delays requests to the registry until Retry-After has elapsed, up to the maximum delay", func() {
+			policy.MaxDelay = 50 * time.Millisecond
+			client := &http.Client{Transport: policy.Transport(http.DefaultTransport)}
+
+			resp, err := client.Get(server.URL)
+			h.AssertNil(t, err)
+			_ = resp.Body.Close()
+			h.AssertEq(t, resp.StatusCode, http.StatusTooManyRequests)
+
+			resp, err = client.Get(server.URL)
+			h.AssertNil(t, err)
+			_ = resp.Body.Close()
+			h.AssertEq(t, resp.StatusCode, http.StatusOK)
+
+			h.AssertEq(t, len(requests), 2)
+			h.AssertEq(t, requests[1].Sub(requests[0]) >= 50*time.Millisecond, true)
+		})
+
+		it("stops waiting when the request is canceled", func() {
+			policy.MaxDelay = time.Minute
+			client := &http.Client{Transport: policy.Transport(http.DefaultTransport)}
+
+			resp, err := client.Get(server.URL)
+			h.AssertNil(t, err)
+			_ = resp.Body.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			h.AssertNil(t, err)
+			_, err = client.Do(req)
+			h.AssertNotNil(t, err)
+			h.AssertEq(t, errors.Is(err, context.DeadlineExceeded), true)
+			h.AssertEq(t, len(requests), 1)
+		})
+	})
+
+	when("#parseRetryAfter", func() {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		it("parses a number of seconds", func() {
+			delay, ok := parseRetryAfter("30", now)
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, delay, 30*time.Second)
+		})
+
+		it("parses an HTTP date", func() {
+			delay, ok := parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, delay, time.Minute)
+		})
+
+		it("ignores invalid values", func() {
+			_, ok := parseRetryAfter("soon", now)
+			h.AssertEq(t, ok, false)
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package image
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// TLSConfig configures the TLS connections to a registry.
+type TLSConfig struct {
+	// CAFile is the path to a PEM bundle of certificate authorities to trust for the registry, in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are the paths to the PEM certificate and key presented to the registry,
+	// when the registry requires client certificates (mTLS).
+	CertFile string
+	KeyFile  string
+}
+
+// RegistryTLS maps a registry (e.g., `registry.internal:5000`) to the TLS configuration for connections to it.
+type RegistryTLS map[string]TLSConfig
+
+// ConfigureTLS configures the default HTTP transport (used for every registry that is not insecure)
+// to connect to each registry in the provided configuration according to its TLS configuration.
+// It should be called before ConfigureRetries, so that requests to these registries also honor `Retry-After`.
+func ConfigureTLS(registries RegistryTLS) error {
+	if len(registries) == 0 {
+		return nil
+	}
+	transport, err := registries.Transport(http.DefaultTransport)
+	if err != nil {
+		return err
+	}
+	http.DefaultTransport = transport
+	return nil
+}
+
+// Transport returns a transport that sends requests to each registry in the configuration using its TLS configuration,
+// and every other request using the provided transport.
+func (r RegistryTLS) Transport(base http.RoundTripper) (http.RoundTripper, error) {
+	byHost := map[string]http.RoundTripper{}
+	for registry, config := range r {
+		reg, err := name.NewRegistry(registry, name.WeakValidation)
+		if err != nil {
+			return nil, fmt.Errorf("parsing registry %q: %w", registry, err)
+		}
+		tlsConfig, err := config.load()
+		if err != nil {
+			return nil, fmt.Errorf("loading TLS configuration for registry %q: %w", registry, err)
+		}
+		transport := cloneTransport(base)
+		transport.TLSClientConfig = tlsConfig
+		byHost[reg.RegistryStr()] = transport
+	}
+	return &registryTLSTransport{base: base, byHost: byHost}, nil
+}
+
+func (c TLSConfig) load() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		bundle, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %q", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("client certificate and key must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// cloneTransport returns a copy of the provided transport to be configured for a registry,
+// or a new transport that uses the proxy configured in the environment if the provided transport cannot be copied.
+func cloneTransport(base http.RoundTripper) *http.Transport {
+	if t, ok := base.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{Proxy: http.ProxyFromEnvironment, ForceAttemptHTTP2: true}
+}
+
+type registryTLSTransport struct {
+	base   http.RoundTripper
+	byHost map[string]http.RoundTripper
+}
+
+func (t *registryTLSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport, ok := t.byHost[req.URL.Host]; ok {
+		return transport.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
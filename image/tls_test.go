@@ -0,0 +1,83 @@
+package image
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestTLS(t *testing.T) {
+	spec.Run(t, "TLS", testTLS, spec.Report(report.Terminal{}))
+}
+
+func testTLS(t *testing.T, when spec.G, it spec.S) {
+	var (
+		server *httptest.Server
+		host   string
+		tmpDir string
+		caFile string
+	)
+
+	it.Before(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		serverURL, err := url.Parse(server.URL)
+		h.AssertNil(t, err)
+		host = serverURL.Host
+
+		tmpDir = t.TempDir()
+		caFile = filepath.Join(tmpDir, "ca.pem")
+		h.AssertNil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	when("#Transport", func() {
+		it("trusts the CA bundle configured for the registry", func() {
+			transport, err := RegistryTLS{host: {CAFile: caFile}}.Transport(http.DefaultTransport)
+			h.AssertNil(t, err)
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			h.AssertNil(t, err)
+			_ = resp.Body.Close()
+			h.AssertEq(t, resp.StatusCode, http.StatusOK)
+		})
+
+		it("sends requests to other registries with the provided transport", func() {
+			transport, err := RegistryTLS{"some.registry": {CAFile: caFile}}.Transport(http.DefaultTransport)
+			h.AssertNil(t, err)
+
+			_, err = (&http.Client{Transport: transport}).Get(server.URL)
+			h.AssertNotNil(t, err)
+		})
+
+		it("errors when the CA bundle has no certificates", func() {
+			h.AssertNil(t, os.WriteFile(caFile, []byte("not a certificate"), 0600))
+
+			_, err := RegistryTLS{host: {CAFile: caFile}}.Transport(http.DefaultTransport)
+			h.AssertError(t, err, "no certificates found in CA bundle")
+		})
+
+		it("errors when a client certificate is provided without a key", func() {
+			_, err := RegistryTLS{host: {CertFile: filepath.Join(tmpDir, "client.pem")}}.Transport(http.DefaultTransport)
+			h.AssertError(t, err, "client certificate and key must be provided together")
+		})
+
+		it("errors when the client certificate cannot be loaded", func() {
+			_, err := RegistryTLS{host: {CertFile: filepath.Join(tmpDir, "client.pem"), KeyFile: filepath.Join(tmpDir, "client-key.pem")}}.Transport(http.DefaultTransport)
+			h.AssertError(t, err, "loading client certificate")
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package image
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// Traffic counts the bytes read from and written to registries through the default HTTP transport (see ConfigureTraffic).
+var Traffic = &TrafficCounter{}
+
+// TrafficCounter counts the bytes of the bodies of the requests sent and the responses received through its Transport.
+type TrafficCounter struct {
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+// ConfigureTraffic configures the default HTTP transport (used for every registry that is not insecure) to count its traffic in Traffic.
+// Layers exported to a daemon or in OCI layout format are not sent to a registry, and so are not counted.
+func ConfigureTraffic() {
+	if _, ok := http.DefaultTransport.(*countingTransport); ok {
+		return
+	}
+	http.DefaultTransport = Traffic.Transport(http.DefaultTransport)
+}
+
+// Transport returns a transport that counts the bytes sent and received through the provided transport.
+func (c *TrafficCounter) Transport(base http.RoundTripper) http.RoundTripper {
+	return &countingTransport{base: base, counter: c}
+}
+
+// Take returns the bytes read and written since the last call to Take.
+func (c *TrafficCounter) Take() (read, written int64) {
+	return c.read.Swap(0), c.written.Swap(0)
+}
+
+type countingTransport struct {
+	base    http.RoundTripper
+	counter *TrafficCounter
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		// the request must not be modified, so its body is counted in a copy
+		req = req.Clone(req.Context())
+		req.Body = &countingReadCloser{ReadCloser: req.Body, n: &t.counter.written}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, n: &t.counter.read}
+	return resp, nil
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,53 @@
+package image
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestTraffic(t *testing.T) {
+	spec.Run(t, "Traffic", testTraffic, spec.Report(report.Terminal{}))
+}
+
+func testTraffic(t *testing.T, when spec.G, it spec.S) {
+	var server *httptest.Server
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			_, _ = w.Write([]byte("some-response"))
+		}))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	when("#Transport", func() {
+		it("counts the bytes of the bodies of requests and responses until taken", func() {
+			counter := &TrafficCounter{}
+			client := &http.Client{Transport: counter.Transport(http.DefaultTransport)}
+
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("some-request"))
+			h.AssertNil(t, err)
+			_, err = io.ReadAll(resp.Body)
+			h.AssertNil(t, err)
+			h.AssertNil(t, resp.Body.Close())
+
+			read, written := counter.Take()
+			h.AssertEq(t, read, int64(len("some-response")))
+			h.AssertEq(t, written, int64(len("some-request")))
+			read, written = counter.Take()
+			h.AssertEq(t, read, int64(0))
+			h.AssertEq(t, written, int64(0))
+		})
+	})
+}
@@ -74,6 +74,7 @@ func createOptions(baseImageRef string, dockerfile extend.Dockerfile, options ex
 		BuildArgs:         append(toArgList(dockerfile.Args), fmt.Sprintf(`base_image=%s`, baseImageRef)),
 		Cache:             true,
 		CacheOptions:      config.CacheOptions{CacheTTL: options.CacheTTL},
+		RegistryOptions:   registryOptions(options),
 		CacheRunLayers:    true,
 		CacheCopyLayers:   true,
 		CacheRepo:         kanikoCacheImageRef,
@@ -90,6 +91,49 @@ func createOptions(baseImageRef string, dockerfile extend.Dockerfile, options ex
 	}
 }
 
+func registryOptions(options extend.Options) config.RegistryOptions {
+	registryOptions := config.RegistryOptions{
+		RegistryMaps:                 registryMaps(options.RegistryMirrors),
+		RegistriesCertificates:       map[string]string{},
+		RegistriesClientCertificates: map[string]string{},
+	}
+	for registry, tlsConfig := range options.RegistryTLS {
+		registry = canonicalRegistry(registry)
+		if tlsConfig.CAFile != "" {
+			registryOptions.RegistriesCertificates[registry] = tlsConfig.CAFile
+		}
+		if tlsConfig.CertFile != "" {
+			// kaniko expects the paths to the certificate and key separated by a comma
+			registryOptions.RegistriesClientCertificates[registry] = tlsConfig.CertFile + "," + tlsConfig.KeyFile
+		}
+	}
+	return registryOptions
+}
+
+// registryMaps returns the mirrors of each registry in the provided mirrors, keyed by the canonical name of the registry.
+// Kaniko can only mirror whole registries, so mirrors of repository prefixes (e.g., `docker.io/library`) are omitted.
+func registryMaps(mirrors map[string][]string) map[string][]string {
+	result := map[string][]string{}
+	for prefix, prefixMirrors := range mirrors {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if strings.Contains(prefix, "/") {
+			continue
+		}
+		registry := canonicalRegistry(prefix)
+		result[registry] = append(result[registry], prefixMirrors...)
+	}
+	return result
+}
+
+// canonicalRegistry returns the name that kaniko uses for the provided registry (e.g., `index.docker.io` for `docker.io`).
+func canonicalRegistry(registry string) string {
+	reg, err := name.NewRegistry(registry, name.WeakValidation)
+	if err != nil {
+		return registry
+	}
+	return reg.RegistryStr()
+}
+
 func toArgList(args []extend.Arg) []string {
 	var result []string
 	for _, arg := range args {
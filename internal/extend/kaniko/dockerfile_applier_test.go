@@ -7,6 +7,7 @@ import (
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
+	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/internal/extend"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
@@ -59,6 +60,48 @@ func testDockerApplier(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("registry mirrors", func() {
+			it("adds the mirrors of whole registries to kaniko options", func() {
+				opts := createOptions(
+					"some-image-ref",
+					extend.Dockerfile{Path: "/something"},
+					extend.Options{
+						RegistryMirrors: map[string][]string{
+							"docker.io":              {"mirror.internal/dockerhub"},
+							"some.registry/some-org": {"mirror.internal/some-org"},
+						},
+					},
+				)
+
+				h.AssertEq(t, map[string][]string(opts.RegistryOptions.RegistryMaps), map[string][]string{
+					"index.docker.io": {"mirror.internal/dockerhub"},
+				})
+			})
+		})
+
+		when("registry TLS", func() {
+			it("adds the CA bundles and client certificates to kaniko options", func() {
+				opts := createOptions(
+					"some-image-ref",
+					extend.Dockerfile{Path: "/something"},
+					extend.Options{
+						RegistryTLS: image.RegistryTLS{
+							"registry.internal:5000": {CAFile: "/some/ca.pem", CertFile: "/some/client.pem", KeyFile: "/some/client-key.pem"},
+							"docker.io":              {CAFile: "/some/other-ca.pem"},
+						},
+					},
+				)
+
+				h.AssertEq(t, map[string]string(opts.RegistryOptions.RegistriesCertificates), map[string]string{
+					"registry.internal:5000": "/some/ca.pem",
+					"index.docker.io":        "/some/other-ca.pem",
+				})
+				h.AssertEq(t, map[string]string(opts.RegistryOptions.RegistriesClientCertificates), map[string]string{
+					"registry.internal:5000": "/some/client.pem,/some/client-key.pem",
+				})
+			})
+		})
+
 		when("cache dir", func() {
 			// If we provide cache directory as an option, kaniko looks there for the base image as a tarball;
 			// however the base image is in OCI layout format, so we fail to initialize the base image,
@@ -1,9 +1,17 @@
 package extend
 
-import "time"
+import (
+	"time"
+
+	"github.com/buildpacks/lifecycle/image"
+)
 
 type Options struct {
 	BuildContext string
 	IgnorePaths  []string
 	CacheTTL     time.Duration
+	// RegistryMirrors maps a registry or repository prefix to the registries or repositories that mirror it (see image.Mirrors).
+	RegistryMirrors map[string][]string
+	// RegistryTLS maps a registry to the TLS configuration for connections to it.
+	RegistryTLS image.RegistryTLS
 }
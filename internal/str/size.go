@@ -0,0 +1,38 @@
+package str
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// ParseSize parses a size in bytes, with an optional binary unit suffix, e.g., "512", "100M", "10Gi" or "1TiB".
+// An empty string is a size of zero.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(s))
+	if trimmed == "" {
+		return 0, nil
+	}
+	trimmed = strings.TrimSuffix(strings.TrimSuffix(trimmed, "B"), "I")
+	number := strings.TrimRight(trimmed, "KMGT")
+	multiplier, ok := sizeUnits[trimmed[len(number):]]
+	if !ok {
+		return 0, fmt.Errorf("failed to parse size '%s': unknown unit", s)
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse size '%s': %w", s, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("failed to parse size '%s': must not be negative", s)
+	}
+	return n * multiplier, nil
+}
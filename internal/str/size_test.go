@@ -0,0 +1,51 @@
+package str_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/internal/str"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSize(t *testing.T) {
+	spec.Run(t, "Size", testSize, spec.Report(report.Terminal{}))
+}
+
+func testSize(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseSize", func() {
+		it("parses sizes with and without units", func() {
+			for s, expected := range map[string]int64{
+				"":      0,
+				"512":   512,
+				"512B":  512,
+				"2k":    2 << 10,
+				"100M":  100 << 20,
+				"10Gi":  10 << 30,
+				"1TiB":  1 << 40,
+				" 3GB ": 3 << 30,
+			} {
+				size, err := str.ParseSize(s)
+				h.AssertNil(t, err)
+				h.AssertEq(t, size, expected)
+			}
+		})
+
+		it("errors for an unknown unit", func() {
+			_, err := str.ParseSize("10MG")
+			h.AssertError(t, err, "failed to parse size '10MG': unknown unit")
+		})
+
+		it("errors for an invalid number", func() {
+			_, err := str.ParseSize("1.5G")
+			h.AssertError(t, err, "failed to parse size '1.5G'")
+		})
+
+		it("errors for a negative size", func() {
+			_, err := str.ParseSize("-1M")
+			h.AssertError(t, err, "must not be negative")
+		})
+	})
+}
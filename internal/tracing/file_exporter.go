@@ -0,0 +1,191 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// FileExporter writes spans in the OTLP JSON file format: each batch of spans is written as a single line
+// containing the JSON encoding of an OTLP ExportTraceServiceRequest.
+// The output can be read by the OpenTelemetry Collector's `otlpjsonfile` receiver.
+type FileExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewFileExporter returns an exporter that writes spans to the provided writer.
+func NewFileExporter(w io.Writer) *FileExporter {
+	return &FileExporter{w: w}
+}
+
+func (e *FileExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(toRequest(spans))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+func (e *FileExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// The following types mirror the JSON encoding of the OTLP protobuf messages;
+// 64-bit integers are encoded as strings, and IDs as lowercase hex.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+func toRequest(spans []sdktrace.ReadOnlySpan) otlpRequest {
+	rs := otlpResourceSpans{}
+	if res := spans[0].Resource(); res != nil {
+		rs.Resource.Attributes = toKeyValues(res.Attributes())
+	}
+	scopes := map[string]int{}
+	for _, span := range spans {
+		scope := span.InstrumentationScope()
+		idx, ok := scopes[scope.Name]
+		if !ok {
+			idx = len(rs.ScopeSpans)
+			scopes[scope.Name] = idx
+			rs.ScopeSpans = append(rs.ScopeSpans, otlpScopeSpans{Scope: otlpScope{Name: scope.Name, Version: scope.Version}})
+		}
+		rs.ScopeSpans[idx].Spans = append(rs.ScopeSpans[idx].Spans, toSpan(span))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{rs}}
+}
+
+func toSpan(span sdktrace.ReadOnlySpan) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime().UnixNano(), 10),
+		Attributes:        toKeyValues(span.Attributes()),
+	}
+	if span.Parent().HasSpanID() {
+		s.ParentSpanID = span.Parent().SpanID().String()
+	}
+	for _, e := range span.Events() {
+		s.Events = append(s.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:         e.Name,
+			Attributes:   toKeyValues(e.Attributes),
+		})
+	}
+	// the OTLP status codes are Unset (0), Ok (1), and Error (2)
+	switch span.Status().Code {
+	case codes.Ok:
+		s.Status = otlpStatus{Code: 1}
+	case codes.Error:
+		s.Status = otlpStatus{Code: 2, Message: span.Status().Description}
+	}
+	return s
+}
+
+func toKeyValues(attrs []attribute.KeyValue) []otlpKeyValue {
+	var kvs []otlpKeyValue
+	for _, attr := range attrs {
+		kvs = append(kvs, otlpKeyValue{Key: string(attr.Key), Value: toValue(attr.Value)})
+	}
+	return kvs
+}
+
+func toValue(v attribute.Value) otlpValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpValue{DoubleValue: &f}
+	case attribute.STRINGSLICE:
+		arr := &otlpArrayValue{Values: []otlpValue{}}
+		for _, s := range v.AsStringSlice() {
+			arr.Values = append(arr.Values, toValue(attribute.StringValue(s)))
+		}
+		return otlpValue{ArrayValue: arr}
+	default:
+		s := v.Emit()
+		return otlpValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,71 @@
+// Package tracing records spans for the work done by the lifecycle, so that a build can be inspected alongside
+// the trace of the platform that started it.
+// Until Init is called, spans are not recorded.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/buildpacks/lifecycle"
+
+// Init configures spans to be written to the file at the provided path in the OTLP JSON file format.
+// If traceparent is a valid W3C trace context (e.g., provided by the platform in the TRACEPARENT environment variable),
+// the returned context carries it, so that spans started from the context are part of the platform's trace.
+// The returned function flushes any recorded spans and closes the file; it should be called before the process exits.
+// If path is empty, spans are not recorded.
+func Init(path, traceparent, version string) (context.Context, func() error, error) {
+	ctx := propagation.TraceContext{}.Extract(
+		context.Background(),
+		propagation.MapCarrier{"traceparent": traceparent},
+	)
+	if path == "" {
+		return ctx, func() error { return nil }, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644) // #nosec G302 G304
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open traces file: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(NewFileExporter(f)),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "lifecycle"),
+			attribute.String("service.version", version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return ctx, func() error {
+		err := provider.Shutdown(context.Background())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span with the provided name and attributes as a child of any span in the provided context.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the provided span, marking it as failed if the provided error is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
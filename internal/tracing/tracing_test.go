@@ -0,0 +1,139 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/buildpacks/lifecycle/internal/tracing"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestTracing(t *testing.T) {
+	spec.Run(t, "Tracing", testTracing, spec.Report(report.Terminal{}))
+}
+
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	} `json:"attributes"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func readSpans(t *testing.T, path string) []otlpSpan {
+	var spans []otlpSpan
+	for _, line := range strings.Split(strings.TrimSpace(string(h.MustReadFile(t, path))), "\n") {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		h.AssertNil(t, json.Unmarshal([]byte(line), &req))
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func testTracing(t *testing.T, when spec.G, it spec.S) {
+	const (
+		traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID    = "00f067aa0ba902b7"
+		traceparent = "00-" + traceID + "-" + parentID + "-01"
+	)
+
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.tracing")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	when("#Init", func() {
+		it("writes spans in the OTLP JSON file format as part of the provided trace", func() {
+			path := filepath.Join(tmpDir, "traces.jsonl")
+			ctx, shutdown, err := tracing.Init(path, traceparent, "1.2.3")
+			h.AssertNil(t, err)
+
+			ctx, phaseSpan := tracing.Start(ctx, "build")
+			_, bpSpan := tracing.Start(ctx, "bin/build", attribute.String("cnb.module.id", "some/buildpack"))
+			tracing.End(bpSpan, errors.New("some error"))
+			tracing.End(phaseSpan, nil)
+			h.AssertNil(t, shutdown())
+
+			spans := readSpans(t, path)
+			h.AssertEq(t, len(spans), 2)
+			bp, phase := spans[0], spans[1]
+
+			h.AssertEq(t, phase.Name, "build")
+			h.AssertEq(t, phase.TraceID, traceID)
+			h.AssertEq(t, phase.ParentSpanID, parentID)
+			h.AssertEq(t, phase.Status.Code, 0)
+
+			h.AssertEq(t, bp.Name, "bin/build")
+			h.AssertEq(t, bp.TraceID, traceID)
+			h.AssertEq(t, bp.ParentSpanID, phase.SpanID)
+			h.AssertEq(t, bp.Status.Code, 2)
+			h.AssertEq(t, bp.Status.Message, "some error")
+			h.AssertEq(t, len(bp.Attributes), 1)
+			h.AssertEq(t, bp.Attributes[0].Key, "cnb.module.id")
+			h.AssertEq(t, bp.Attributes[0].Value["stringValue"], "some/buildpack")
+		})
+
+		it("starts a new trace when traceparent is not provided", func() {
+			path := filepath.Join(tmpDir, "traces.jsonl")
+			ctx, shutdown, err := tracing.Init(path, "", "1.2.3")
+			h.AssertNil(t, err)
+
+			_, span := tracing.Start(ctx, "detect")
+			tracing.End(span, nil)
+			h.AssertNil(t, shutdown())
+
+			spans := readSpans(t, path)
+			h.AssertEq(t, len(spans), 1)
+			h.AssertEq(t, spans[0].ParentSpanID, "")
+			h.AssertEq(t, len(spans[0].TraceID), 32)
+		})
+
+		when("a path is not provided", func() {
+			it("propagates the provided trace context", func() {
+				ctx, shutdown, err := tracing.Init("", traceparent, "1.2.3")
+				h.AssertNil(t, err)
+				h.AssertNil(t, shutdown())
+
+				h.AssertEq(t, trace.SpanContextFromContext(ctx).TraceID().String(), traceID)
+			})
+		})
+
+		it("errors when the file cannot be opened", func() {
+			_, _, err := tracing.Init(filepath.Join(tmpDir, "missing", "traces.jsonl"), "", "1.2.3")
+			h.AssertError(t, err, "failed to open traces file")
+		})
+	})
+}
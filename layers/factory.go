@@ -12,8 +12,10 @@ import (
 	"time"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/buildpacks/lifecycle/archive"
+	"github.com/buildpacks/lifecycle/internal/tracing"
 	"github.com/buildpacks/lifecycle/log"
 )
 
@@ -33,8 +35,8 @@ type Factory struct {
 	ArtifactsDir string // ArtifactsDir is the directory where layer files are written
 	UID, GID     int    // UID and GID are used to normalize layer entries
 	Logger       log.Logger
-	Ctx          context.Context
-	tarHashes    sync.Map // tarHashes Stores hashes of layer tarballs for reuse between the export and cache steps.
+	Ctx          context.Context // Ctx, if provided, cancels writing layers and parents the span for each layer
+	tarHashes    sync.Map        // tarHashes Stores hashes of layer tarballs for reuse between the export and cache steps.
 }
 
 type Layer struct {
@@ -48,6 +50,13 @@ func (f *Factory) writeLayer(id, createdBy string, addEntries func(tw *archive.N
 	if f.Ctx == nil {
 		f.Ctx = context.TODO()
 	}
+	_, span := tracing.Start(f.Ctx, "layers.writeLayer", attribute.String("cnb.layer.id", id))
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.String("cnb.layer.digest", layer.Digest))
+		}
+		tracing.End(span, err)
+	}()
 	tarPath := filepath.Join(f.ArtifactsDir, escape(id)+".tar")
 	for {
 		sha, loaded := f.tarHashes.LoadOrStore(tarPath, processing)
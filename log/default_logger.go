@@ -39,6 +39,15 @@ func (l *DefaultLogger) Phase(name string) {
 	l.Info(phaseStyle("===> %s", name))
 }
 
+// SetRedactor configures the logger to mask the secrets known to the provided redactor in every message.
+func (l *DefaultLogger) SetRedactor(redactor *Redactor) {
+	if h, ok := l.Handler.(*handler); ok {
+		h.mu.Lock()
+		h.redactor = redactor
+		h.mu.Unlock()
+	}
+}
+
 func (l *DefaultLogger) SetLevel(requested string) error {
 	var err error
 	l.Level, err = log.ParseLevel(requested)
@@ -51,8 +60,9 @@ func (l *DefaultLogger) SetLevel(requested string) error {
 var _ log.Handler = &handler{}
 
 type handler struct {
-	mu     sync.Mutex
-	writer io.Writer
+	mu       sync.Mutex
+	writer   io.Writer
+	redactor *Redactor
 }
 
 const (
@@ -71,13 +81,14 @@ func (h *handler) HandleLog(entry *log.Entry) error {
 	defer h.mu.Unlock()
 
 	var err error
+	message := h.redactor.Redact(entry.Message)
 	switch entry.Level {
 	case log.WarnLevel:
-		_, err = h.writer.Write([]byte(warnStyle(warnLevelText) + appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(warnStyle(warnLevelText) + appendMissingLineFeed(message)))
 	case log.ErrorLevel:
-		_, err = h.writer.Write([]byte(errorStyle(errorLevelText) + appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(errorStyle(errorLevelText) + appendMissingLineFeed(message)))
 	default:
-		_, err = h.writer.Write([]byte(appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(appendMissingLineFeed(message)))
 	}
 	return err
 }
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces each secret value in redacted output.
+const Redacted = "********"
+
+// minSecretLength is the length of the shortest value that is redacted;
+// shorter values (e.g., a port number in a service binding) are too likely to match unrelated output.
+const minSecretLength = 4
+
+// Redactor masks known secret values (e.g., registry credentials) in output.
+// It is safe for concurrent use.
+type Redactor struct {
+	mu       sync.RWMutex
+	secrets  []string // longest first, so that a secret containing another is fully redacted
+	replacer *strings.Replacer
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Add adds the provided values to the secrets that are redacted.
+// Leading and trailing whitespace is ignored, as are values shorter than 4 characters.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if len(secret) < minSecretLength || slices.Contains(r.secrets, secret) {
+			continue
+		}
+		r.secrets = append(r.secrets, secret)
+	}
+	slices.SortStableFunc(r.secrets, func(a, b string) int { return len(b) - len(a) })
+	var oldnew []string
+	for _, secret := range r.secrets {
+		oldnew = append(oldnew, secret, Redacted)
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact returns the provided string with each secret replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// heldBack returns the length of the longest suffix of the provided bytes that is the beginning of a secret,
+// which must not be written until it is known whether the secret follows.
+func (r *Redactor) heldBack(p []byte) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var held int
+	for _, secret := range r.secrets {
+		for n := min(len(secret)-1, len(p)); n > held; n-- {
+			if string(p[len(p)-n:]) == secret[:n] {
+				held = n
+				break
+			}
+		}
+	}
+	return held
+}
+
+// Writer returns a writer that redacts secrets from everything written to it before writing to the provided writer,
+// e.g., for the output of buildpacks.
+// Output that could be the beginning of a secret is held back until the next write; Flush writes it.
+func (r *Redactor) Writer(w io.Writer) *RedactingWriter {
+	return &RedactingWriter{redactor: r, writer: w}
+}
+
+// RedactingWriter is an io.Writer that redacts secrets; see Redactor.Writer.
+type RedactingWriter struct {
+	redactor *Redactor
+	writer   io.Writer
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buf := append(w.pending, p...)
+	held := w.redactor.heldBack(buf)
+	w.pending = slices.Clone(buf[len(buf)-held:])
+	if held == len(buf) {
+		return len(p), nil
+	}
+	if _, err := io.WriteString(w.writer, w.redactor.Redact(string(buf[:len(buf)-held]))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes any output that was held back.
+func (w *RedactingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.writer, w.redactor.Redact(string(w.pending)))
+	w.pending = nil
+	return err
+}
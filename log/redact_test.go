@@ -0,0 +1,82 @@
+package log_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	llog "github.com/buildpacks/lifecycle/log"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRedactor(t *testing.T) {
+	spec.Run(t, "Redactor", testRedactor, spec.Report(report.Terminal{}))
+}
+
+func testRedactor(t *testing.T, when spec.G, it spec.S) {
+	var redactor *llog.Redactor
+
+	it.Before(func() {
+		redactor = llog.NewRedactor()
+		redactor.Add("some-secret", "some-secret-that-is-longer", "  padded-secret\n", "abc", "")
+	})
+
+	when("#Redact", func() {
+		it("replaces each secret", func() {
+			h.AssertEq(t,
+				redactor.Redact("token=some-secret, other=some-secret-that-is-longer, padded=padded-secret"),
+				"token=********, other=********, padded=********",
+			)
+		})
+
+		it("does not replace values shorter than 4 characters", func() {
+			h.AssertEq(t, redactor.Redact("abc"), "abc")
+		})
+
+		it("does nothing without secrets", func() {
+			h.AssertEq(t, llog.NewRedactor().Redact("some-secret"), "some-secret")
+		})
+	})
+
+	when("#Writer", func() {
+		it("replaces secrets split across writes", func() {
+			var out bytes.Buffer
+			w := redactor.Writer(&out)
+
+			_, err := w.Write([]byte("token=some-se"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "token=")
+
+			_, err = w.Write([]byte("cret\nother output\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "token=********\nother output\n")
+		})
+
+		it("writes held back output when flushed", func() {
+			var out bytes.Buffer
+			w := redactor.Writer(&out)
+
+			_, err := w.Write([]byte("almost some-sec"))
+			h.AssertNil(t, err)
+			h.AssertNil(t, w.Flush())
+
+			h.AssertEq(t, out.String(), "almost some-sec")
+		})
+	})
+
+	when("DefaultLogger", func() {
+		it("redacts messages", func() {
+			var out bytes.Buffer
+			logger := llog.NewDefaultLogger(&out)
+			logger.Level = log.DebugLevel
+			logger.SetRedactor(redactor)
+
+			logger.Debugf("Using auth %s", "some-secret")
+
+			h.AssertEq(t, out.String(), "Using auth ********\n")
+		})
+	})
+}
@@ -1,6 +1,8 @@
 package phase
 
 import (
+	"context"
+
 	"github.com/buildpacks/imgutil"
 	"github.com/pkg/errors"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/buildpacks/lifecycle/internal/layer"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -20,6 +23,7 @@ type Analyzer struct {
 	Logger        log.Logger
 	SBOMRestorer  layer.SBOMRestorer
 	PlatformAPI   *api.Version
+	Events        event.Sink
 }
 
 // NewAnalyzer configures a new Analyzer according to the provided Platform API version.
@@ -53,9 +57,18 @@ func (f *ConnectedFactory) NewAnalyzer(inputs platform.LifecycleInputs, logger l
 
 // Analyze fetches the layers metadata from the previous image and writes analyzed.toml.
 func (a *Analyzer) Analyze() (files.Analyzed, error) {
+	return a.AnalyzeContext(context.Background())
+}
+
+// AnalyzeContext is like Analyze, but returns early when the provided context is done.
+func (a *Analyzer) AnalyzeContext(ctx context.Context) (_ files.Analyzed, err error) {
 	defer log.NewMeasurement("Analyzer", a.Logger)()
+	ctx, endPhase := startPhase(ctx, a.Events, "analyze")
+	defer func() { endPhase(err) }()
+	if err = ctx.Err(); err != nil {
+		return files.Analyzed{}, err
+	}
 	var (
-		err              error
 		appMeta          files.LayersMetadata
 		previousImageRef string
 		runImageRef      string
@@ -65,6 +78,9 @@ func (a *Analyzer) Analyze() (files.Analyzed, error) {
 		return files.Analyzed{}, err
 	}
 
+	if err = ctx.Err(); err != nil {
+		return files.Analyzed{}, err
+	}
 	if sha := bomSHA(appMeta); sha != "" {
 		if err = a.SBOMRestorer.RestoreFromPrevious(a.PreviousImage, sha); err != nil {
 			return files.Analyzed{}, errors.Wrap(err, "retrieving launch SBOM layer")
@@ -75,6 +91,9 @@ func (a *Analyzer) Analyze() (files.Analyzed, error) {
 		atm          *files.TargetMetadata
 		runImageName string
 	)
+	if err = ctx.Err(); err != nil {
+		return files.Analyzed{}, err
+	}
 	if a.RunImage != nil {
 		runImageRef, err = a.getImageIdentifier(a.RunImage)
 		if err != nil {
@@ -95,11 +114,13 @@ func (a *Analyzer) Analyze() (files.Analyzed, error) {
 	return files.Analyzed{
 		PreviousImage: &files.ImageIdentifier{
 			Reference: previousImageRef,
+			Mirror:    image.MirrorOf(a.PreviousImage),
 		},
 		RunImage: &files.RunImage{
 			Reference:      runImageRef, // the image identifier, e.g. "s0m3d1g3st" (the image identifier) when exporting to a daemon, or "some.registry/some-repo@sha256:s0m3d1g3st" when exporting to a registry
 			TargetMetadata: atm,
-			Image:          runImageName, // the provided tag, e.g., "some.registry/some-repo:some-tag" if supported by the platform
+			Image:          runImageName,               // the provided tag, e.g., "some.registry/some-repo:some-tag" if supported by the platform
+			Mirror:         image.MirrorOf(a.RunImage), // the mirror the run image was pulled from, e.g., "mirror.internal/some-repo:some-tag", if any
 		},
 		LayersMetadata: appMeta,
 	}, nil
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -49,10 +51,26 @@ type Builder struct {
 	Plan           files.Plan
 	PlatformAPI    *api.Version
 	AnalyzeMD      files.Analyzed
+	Timeouts       buildpack.Timeouts
+	Events         event.Sink
+
+	// Network, if provided, is the network namespace in which `./bin/build` is run, so that buildpacks cannot reach the network.
+	Network *buildpack.IsolatedNetwork
+
+	// VerifyDigests causes the build to fail before running any `./bin/build`
+	// if a buildpack directory does not match the digest recorded in the group by the detector.
+	VerifyDigests bool
 }
 
 func (b *Builder) Build() (*files.BuildMetadata, error) {
+	return b.BuildContext(context.Background())
+}
+
+// BuildContext is like Build, but stops running `./bin/build` (killing any running processes) when the provided context is done.
+func (b *Builder) BuildContext(ctx context.Context) (_ *files.BuildMetadata, err error) {
 	defer log.NewMeasurement("Builder", b.Logger)()
+	ctx, endPhase := startPhase(ctx, b.Events, "build")
+	defer func() { endPhase(err) }()
 
 	// ensure layers SBOM directory is removed
 	if err := os.RemoveAll(filepath.Join(b.LayersDir, "sbom")); err != nil {
@@ -71,7 +89,16 @@ func (b *Builder) Build() (*files.BuildMetadata, error) {
 
 	filteredPlan := b.Plan
 
+	if b.VerifyDigests {
+		if err := VerifyDigests(b.DirStore, buildpack.KindBuildpack, b.Group.Group); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, bp := range b.Group.Group {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		b.Logger.Debugf("Running build for buildpack %s", bp)
 
 		b.Logger.Debug("Looking up buildpack")
@@ -82,10 +109,14 @@ func (b *Builder) Build() (*files.BuildMetadata, error) {
 
 		b.Logger.Debug("Finding plan")
 		inputs.Plan = filteredPlan.Find(buildpack.KindBuildpack, bp.ID)
+		inputs.Timeout = b.Timeouts.For(bp.ID)
 
+		var endModule func(*int, *buildpack.Usage, error)
+		inputs.Context, endModule = startModule(ctx, b.Events, "bin/build", event.BuildStart, event.BuildEnd, bp, inputs.Network != nil)
 		br, err := b.BuildExecutor.Build(*bpTOML, inputs, b.Logger)
+		endModule(exitCodeOf(err), br.Usage, err)
 		if err != nil {
-			return nil, err
+			return nil, withModule(err, bp)
 		}
 
 		b.Logger.Debug("Updating buildpack processes")
@@ -154,6 +185,7 @@ func (b *Builder) getBuildInputs() buildpack.BuildInputs {
 		ExecEnv:        b.ExecEnv,
 		Out:            b.Out,
 		Err:            b.Err,
+		Network:        b.Network,
 	}
 }
 
@@ -2,12 +2,14 @@ package phase_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -25,6 +27,7 @@ import (
 	llog "github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/phase"
 	"github.com/buildpacks/lifecycle/phase/testmock"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
@@ -266,6 +269,118 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 			h.AssertNil(t, err)
 		})
 
+		it("stops before running the next buildpack when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			bpA := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}}}
+			dirStore.EXPECT().LookupBp("A", "v1").Return(bpA, nil)
+			executor.EXPECT().Build(*bpA, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ buildpack.BpDescriptor, inputs buildpack.BuildInputs, _ llog.Logger) (buildpack.BuildOutputs, error) {
+					cancel()
+					if inputs.Context.Err() == nil {
+						t.Fatalf("Expected the build context to be derived from the provided context")
+					}
+					return buildpack.BuildOutputs{}, nil
+				},
+			)
+
+			_, err := builder.BuildContext(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("Unexpected error:\n%s\n", err)
+			}
+		})
+
+		it("emits events for the phase and each buildpack", func() {
+			events := &bytes.Buffer{}
+			builder.Events = event.NewJSONSink(events)
+			bpA := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}}}
+			bpB := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v1"}}}
+			dirStore.EXPECT().LookupBp("A", "v1").Return(bpA, nil)
+			dirStore.EXPECT().LookupBp("B", "v2").Return(bpB, nil)
+			executor.EXPECT().Build(*bpA, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, nil)
+			executor.EXPECT().Build(*bpB, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, errors.New("some error"))
+
+			_, err := builder.Build()
+			h.AssertNotNil(t, err)
+
+			var got []event.Event
+			for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+				var e event.Event
+				h.AssertNil(t, json.Unmarshal([]byte(line), &e))
+				h.AssertEq(t, e.Time.IsZero(), false)
+				e.Time = time.Time{}
+				got = append(got, e)
+			}
+			zero := 0
+			h.AssertEq(t, got, []event.Event{
+				{Type: event.PhaseStart, Phase: "build"},
+				{Type: event.BuildStart, Module: "A@v1"},
+				{Type: event.BuildEnd, Module: "A@v1", ExitCode: &zero},
+				{Type: event.BuildStart, Module: "B@v2"},
+				{Type: event.BuildEnd, Module: "B@v2", Error: "some error"},
+				{Type: event.PhaseEnd, Phase: "build", Error: "some error"},
+			})
+		})
+
+		it("records the buildpack that failed", func() {
+			bpA := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}}}
+			bpB := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v2"}}}
+			dirStore.EXPECT().LookupBp("A", "v1").Return(bpA, nil)
+			dirStore.EXPECT().LookupBp("B", "v2").Return(bpB, nil)
+			executor.EXPECT().Build(*bpA, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, nil)
+			executor.EXPECT().Build(*bpB, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, buildpack.NewError(errors.New("some error"), buildpack.ErrTypeBuildpack))
+
+			_, err := builder.Build()
+
+			var bpErr *buildpack.Error
+			h.AssertEq(t, errors.As(err, &bpErr), true)
+			h.AssertEq(t, bpErr.Module.ID, "B")
+			h.AssertEq(t, bpErr.Module.Version, "v2")
+		})
+
+		when("verifying digests", func() {
+			var bpA, bpB *buildpack.BpDescriptor
+
+			it.Before(func() {
+				builder.VerifyDigests = true
+				bpA = &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}}, WithRootDir: filepath.Join(tmpDir, "A")}
+				bpB = &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v2"}}, WithRootDir: filepath.Join(tmpDir, "B")}
+				for _, bp := range []*buildpack.BpDescriptor{bpA, bpB} {
+					h.Mkdir(t, bp.WithRootDir)
+					h.Mkfile(t, `api = "0.10"`, filepath.Join(bp.WithRootDir, "buildpack.toml"))
+				}
+				dirStore.EXPECT().LookupBp("A", "v1").Return(bpA, nil).AnyTimes()
+				dirStore.EXPECT().LookupBp("B", "v2").Return(bpB, nil).AnyTimes()
+				for i, bp := range []*buildpack.BpDescriptor{bpA, bpB} {
+					digest, err := buildpack.DirDigest(bp.WithRootDir)
+					h.AssertNil(t, err)
+					builder.Group.Group[i].Digest = digest
+				}
+			})
+
+			it("runs each buildpack when the digests match", func() {
+				executor.EXPECT().Build(*bpA, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, nil)
+				executor.EXPECT().Build(*bpB, gomock.Any(), gomock.Any()).Return(buildpack.BuildOutputs{}, nil)
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+			})
+
+			it("errors before running any buildpack when a buildpack was modified", func() {
+				h.Mkfile(t, `api = "0.11"`, filepath.Join(bpB.WithRootDir, "buildpack.toml"))
+
+				_, err := builder.Build()
+				h.AssertError(t, err, "buildpack B@v2 has been modified since detection")
+			})
+
+			it("errors when a digest was not recorded", func() {
+				builder.Group.Group[0].Digest = ""
+
+				_, err := builder.Build()
+				h.AssertError(t, err, "no digest recorded for buildpack A@v1")
+			})
+		})
+
 		it("passes empty ExecEnv when not set", func() {
 			builder.ExecEnv = ""
 			bpA := &buildpack.BpDescriptor{Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}}}
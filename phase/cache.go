@@ -1,31 +1,73 @@
 package phase
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/buildpacks/lifecycle/buildpack"
 	c "github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/internal/tracing"
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 )
 
+// EvictingCache is implemented by caches that may evict layers when committed.
+type EvictingCache interface {
+	Evicted() []c.EvictedLayer
+}
+
+// ContextCache is implemented by caches whose operations can be cancelled (e.g., requests to an object store).
+// The exporter and restorer use these methods instead of those of Cache, so that their context cancels them.
+type ContextCache interface {
+	RetrieveMetadataContext(ctx context.Context) (platform.CacheMetadata, error)
+	AddLayerFileContext(ctx context.Context, tarPath string, sha string) error
+	ReuseLayerContext(ctx context.Context, sha string) error
+	RetrieveLayerContext(ctx context.Context, sha string) (io.ReadCloser, error)
+	VerifyLayerContext(ctx context.Context, sha string) error
+	CommitContext(ctx context.Context) error
+}
+
+// AbortingCache is implemented by caches that stage changes before they are committed.
+// The exporter and restorer abort the cache when they fail or are stopped, so that the staged changes are discarded.
+type AbortingCache interface {
+	Abort() error
+}
+
 type LayerDir interface {
 	Identifier() string
 	Path() string
 }
 
 func (e *Exporter) Cache(layersDir string, cacheStore Cache) error {
+	return e.CacheContext(context.Background(), layersDir, cacheStore)
+}
+
+// CacheContext is like Cache, but stops adding layers when the provided context is done.
+// When stopped, the cache is not committed, leaving the previously committed cache unchanged,
+// and the changes staged by a cache that implements AbortingCache are discarded.
+// A cache operation that is in progress (e.g., uploading a layer) is only interrupted if the cache implements ContextCache.
+func (e *Exporter) CacheContext(ctx context.Context, layersDir string, cacheStore Cache) (err error) {
 	defer log.NewMeasurement("Cache", e.Logger)()
-	var err error
+	ctx, endPhase := startPhase(ctx, e.Events, "cache")
+	defer func() { endPhase(err) }()
+	defer func() {
+		if err != nil {
+			abortCache(cacheStore, e.Logger)
+		}
+	}()
 	if !cacheStore.Exists() {
 		e.Logger.Info("Layer cache not found")
 	}
-	origMeta, err := cacheStore.RetrieveMetadata()
+	store := withContext(ctx, cacheStore)
+	origMeta, err := store.RetrieveMetadata()
 	if err != nil {
 		return errors.Wrap(err, "metadata for previous cache")
 	}
@@ -43,6 +85,9 @@ func (e *Exporter) Cache(layersDir string, cacheStore Cache) error {
 			Layers:  map[string]buildpack.LayerMetadata{},
 		}
 		for _, layer := range bpDir.FindLayers(buildpack.MadeCached) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if !layer.HasLocalContents() {
 				e.Logger.Warnf("Failed to cache layer '%s' because it has no contents", layer.Identifier())
 				continue
@@ -54,7 +99,7 @@ func (e *Exporter) Cache(layersDir string, cacheStore Cache) error {
 			}
 			origLayerMetadata := origMeta.MetadataForBuildpack(bp.ID).Layers[layer.Name()]
 			createdBy := fmt.Sprintf(layers.BuildpackLayerName, layer.Name(), fmt.Sprintf("%s@%s", bp.ID, bp.Version))
-			if lmd.SHA, err = e.addOrReuseCacheLayer(cacheStore, &layer, origLayerMetadata.SHA, createdBy); err != nil {
+			if lmd.SHA, err = e.addOrReuseCacheLayer(ctx, store, &layer, origLayerMetadata.SHA, createdBy); err != nil {
 				e.Logger.Warnf("Failed to cache layer '%s': %s", layer.Identifier(), err)
 				continue
 			}
@@ -64,17 +109,25 @@ func (e *Exporter) Cache(layersDir string, cacheStore Cache) error {
 	}
 
 	if e.PlatformAPI.AtLeast("0.8") {
-		if err := e.addSBOMCacheLayer(layersDir, cacheStore, origMeta, &meta); err != nil {
+		if err := e.addSBOMCacheLayer(ctx, layersDir, store, origMeta, &meta); err != nil {
 			return err
 		}
 	}
 
-	if err := cacheStore.SetMetadata(meta); err != nil {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := store.SetMetadata(meta); err != nil {
 		return errors.Wrap(err, "setting cache metadata")
 	}
-	if err := cacheStore.Commit(); err != nil {
+	if err := store.Commit(); err != nil {
 		return errors.Wrap(err, "committing cache")
 	}
+	if evictingCache, ok := cacheStore.(EvictingCache); ok {
+		for _, layer := range evictingCache.Evicted() {
+			event.Emit(e.Events, event.Event{Type: event.LayerEvicted, Digest: layer.DiffID, Size: layer.Size})
+		}
+	}
 
 	return nil
 }
@@ -92,11 +145,12 @@ func (l *layerDir) Path() string {
 	return l.path
 }
 
-func (e *Exporter) addOrReuseCacheLayer(cache Cache, layerDir LayerDir, previousSHA, createdBy string) (string, error) {
+func (e *Exporter) addOrReuseCacheLayer(ctx context.Context, cache Cache, layerDir LayerDir, previousSHA, createdBy string) (string, error) {
 	layer, err := e.LayerFactory.DirLayer(layerDir.Identifier(), layerDir.Path(), createdBy)
 	if err != nil {
 		return "", errors.Wrapf(err, "creating layer '%s'", layerDir.Identifier())
 	}
+	var reused bool
 	if layer.Digest == previousSHA {
 		if err = cache.VerifyLayer(previousSHA); err == nil {
 			e.Logger.Infof("Reusing cache layer '%s'\n", layer.ID)
@@ -108,6 +162,8 @@ func (e *Exporter) addOrReuseCacheLayer(cache Cache, layerDir LayerDir, previous
 				} else {
 					return "", errors.Wrapf(err, "reusing layer %s", layer.ID)
 				}
+			} else {
+				reused = true
 			}
 		} else {
 			if isReadErr, readErr := c.IsReadErr(err); isReadErr {
@@ -119,10 +175,22 @@ func (e *Exporter) addOrReuseCacheLayer(cache Cache, layerDir LayerDir, previous
 	}
 	e.Logger.Infof("Adding cache layer '%s'\n", layer.ID)
 	e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
-	return layer.Digest, cache.AddLayerFile(layer.TarPath, layer.Digest)
+	_, span := tracing.Start(ctx, "cache.AddLayerFile",
+		attribute.String("cnb.layer.id", layer.ID),
+		attribute.String("cnb.layer.digest", layer.Digest),
+	)
+	err = cache.AddLayerFile(layer.TarPath, layer.Digest)
+	tracing.End(span, err)
+	if err != nil {
+		return layer.Digest, err
+	}
+	cached := layerEvent(event.LayerCached, layer.ID, layer.TarPath, layer.Digest)
+	cached.Reused = reused
+	event.Emit(e.Events, cached)
+	return layer.Digest, nil
 }
 
-func (e *Exporter) addSBOMCacheLayer(layersDir string, cacheStore Cache, origMetadata platform.CacheMetadata, meta *platform.CacheMetadata) error {
+func (e *Exporter) addSBOMCacheLayer(ctx context.Context, layersDir string, cacheStore Cache, origMetadata platform.CacheMetadata, meta *platform.CacheMetadata) error {
 	sbomCacheDir, err := readLayersSBOM(layersDir, "cache", e.Logger)
 	if err != nil {
 		return errors.Wrap(err, "failed to read layers SBOM")
@@ -136,7 +204,7 @@ func (e *Exporter) addSBOMCacheLayer(layersDir string, cacheStore Cache, origMet
 
 		lyr := &layerDir{path: l.TarPath, identifier: l.ID}
 
-		meta.BOM.SHA, err = e.addOrReuseCacheLayer(cacheStore, lyr, origMetadata.BOM.SHA, layers.SBOMLayerName)
+		meta.BOM.SHA, err = e.addOrReuseCacheLayer(ctx, cacheStore, lyr, origMetadata.BOM.SHA, layers.SBOMLayerName)
 		if err != nil {
 			return err
 		}
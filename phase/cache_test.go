@@ -1,6 +1,7 @@
 package phase_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -257,6 +258,33 @@ func testCache(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("the context is done", func() {
+			it.Before(func() {
+				layerFactory.EXPECT().
+					DirLayer(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(id string, dir string, createdBy string) (layers.Layer, error) {
+						return createTestLayer(id, tmpDir)
+					}).AnyTimes()
+
+				layersDir = filepath.Join("testdata", "cacher", "layers")
+			})
+
+			it("does not commit the cache and removes the changes staged by the build", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				volumeCache := testCache.(*cache.VolumeCache)
+
+				err := exporter.CacheContext(ctx, layersDir, &cancelingCache{VolumeCache: volumeCache, cancel: cancel})
+				h.AssertEq(t, errors.Is(err, context.Canceled), true)
+
+				metadata, err := testCache.RetrieveMetadata()
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(metadata.Buildpacks), 0)
+				entries, err := os.ReadDir(filepath.Join(cacheDir, "staging"))
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(entries), 0)
+			})
+		})
+
 		when("there are invalid layers", func() {
 			it.Before(func() {
 				layerFactory.EXPECT().
@@ -318,3 +346,14 @@ func initializeCache(t *testing.T, exporter *phase.Exporter, testCache *phase.Ca
 		0600,
 	))
 }
+
+// cancelingCache cancels a context once a layer is added to the cache.
+type cancelingCache struct {
+	*cache.VolumeCache
+	cancel func()
+}
+
+func (c *cancelingCache) AddLayerFile(tarPath string, sha string) error {
+	defer c.cancel()
+	return c.VolumeCache.AddLayerFile(tarPath, sha)
+}
@@ -0,0 +1,81 @@
+package phase
+
+import (
+	"context"
+	"io"
+
+	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// contextReader stops reading once its context is done,
+// so that copying data (e.g., extracting a layer from the cache) can be cancelled.
+// It counts the bytes read.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// cacheContext is a Cache whose operations are cancelled when its context is done.
+type cacheContext struct {
+	Cache
+	cache ContextCache
+	ctx   context.Context
+}
+
+// withContext returns a Cache that uses the context-aware methods of the provided cache, if it implements ContextCache.
+func withContext(ctx context.Context, cache Cache) Cache {
+	contextCache, ok := cache.(ContextCache)
+	if !ok {
+		return cache
+	}
+	return &cacheContext{Cache: cache, cache: contextCache, ctx: ctx}
+}
+
+func (c *cacheContext) RetrieveMetadata() (platform.CacheMetadata, error) {
+	return c.cache.RetrieveMetadataContext(c.ctx)
+}
+
+func (c *cacheContext) AddLayerFile(tarPath string, sha string) error {
+	return c.cache.AddLayerFileContext(c.ctx, tarPath, sha)
+}
+
+func (c *cacheContext) ReuseLayer(sha string) error {
+	return c.cache.ReuseLayerContext(c.ctx, sha)
+}
+
+func (c *cacheContext) RetrieveLayer(sha string) (io.ReadCloser, error) {
+	return c.cache.RetrieveLayerContext(c.ctx, sha)
+}
+
+func (c *cacheContext) VerifyLayer(sha string) error {
+	return c.cache.VerifyLayerContext(c.ctx, sha)
+}
+
+func (c *cacheContext) Commit() error {
+	return c.cache.CommitContext(c.ctx)
+}
+
+// abortCache discards the changes staged in the provided cache, if it implements AbortingCache.
+func abortCache(cache Cache, logger log.Logger) {
+	if contextCache, ok := cache.(*cacheContext); ok {
+		cache = contextCache.Cache
+	}
+	abortingCache, ok := cache.(AbortingCache)
+	if !ok {
+		return
+	}
+	if err := abortingCache.Abort(); err != nil {
+		logger.Warnf("Failed to discard changes to cache: %s", err)
+	}
+}
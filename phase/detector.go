@@ -1,14 +1,17 @@
 package phase
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"slices"
+	"strings"
 	"sync"
 
 	apexlog "github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
@@ -17,6 +20,7 @@ import (
 	"github.com/buildpacks/lifecycle/internal/fsutil"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -28,8 +32,12 @@ const (
 var (
 	ErrFailedDetection = errors.New("no buildpacks participating")
 	ErrBuildpack       = errors.New("buildpack(s) failed with err")
+	ErrTargetMismatch  = errors.New("unable to satisfy target os/arch constraints")
 )
 
+// detectReportOutputLimit is the maximum number of bytes of `./bin/detect` output recorded in the detect report.
+const detectReportOutputLimit = 4096
+
 // DetectResolver given a group of buildpacks (and optional image extensions)
 // processes the outputs of each `./bin/detect` to determine if the group is viable -
 // that is, whether all non-optional buildpacks passed detection and a valid Build Plan can be resolved
@@ -52,19 +60,43 @@ type Detector struct {
 	PlatformDir    string
 	Resolver       DetectResolver
 	Runs           *sync.Map
+	Report         *files.DetectReport
 	AnalyzeMD      files.Analyzed
 	PlatformAPI    *api.Version
 	OSDetector     *fsutil.DefaultDetector
 
+	// Parallelism, if greater than zero, causes `./bin/detect` to be run speculatively for every buildpack and image extension
+	// in the order (using at most Parallelism concurrent processes) before any group is resolved.
+	// Groups are still resolved in order, so the selected group is the same as when detecting sequentially.
+	Parallelism int
+
+	// Timeouts limits how long each `./bin/detect` may run.
+	Timeouts buildpack.Timeouts
+
+	// RecordDigests causes the digest of the directory of each buildpack and image extension in the selected group to be recorded,
+	// so that later phases can verify that the buildpacks they run are the ones that were detected.
+	RecordDigests bool
+
+	// Events, if provided, receives an event before and after each `./bin/detect`.
+	Events event.Sink
+
+	// Network, if provided, is the network namespace in which `./bin/detect` is run, so that buildpacks cannot reach the network.
+	Network *buildpack.IsolatedNetwork
+
 	// If detect fails, we want to print debug statements as info level.
 	// memHandler holds all log entries; we'll iterate through them at the end of detect,
 	// providing them to the detector's logger according to the desired log level.
 	memHandler *memory.Handler
+
+	// resolved holds the keys of the elements of the groups resolved by the last call to DetectOrderContext,
+	// so that runs of other elements (e.g., speculative runs) do not affect the error it returns.
+	resolved map[string]bool
 }
 
 // NewDetector constructs a new Detector by initializing services and reading the provided analyzed and order files.
 func (f *HermeticFactory) NewDetector(inputs platform.LifecycleInputs, logger log.LoggerHandlerWithLevel) (*Detector, error) {
 	memHandler := memory.New()
+	report := &files.DetectReport{}
 	detector := &Detector{
 		AppDir:         inputs.AppDir,
 		BuildConfigDir: inputs.BuildConfigDir,
@@ -73,13 +105,19 @@ func (f *HermeticFactory) NewDetector(inputs platform.LifecycleInputs, logger lo
 		ExecEnv:        inputs.ExecEnv,
 		Logger:         logger,
 		PlatformDir:    inputs.PlatformDir,
-		Resolver:       NewDefaultDetectResolver(&apexlog.Logger{Handler: memHandler}),
+		Resolver:       &DefaultDetectResolver{Logger: &apexlog.Logger{Handler: memHandler}, Report: report},
 		Runs:           &sync.Map{},
+		Report:         report,
 		memHandler:     memHandler,
 		PlatformAPI:    f.platformAPI,
 		OSDetector:     &fsutil.DefaultDetector{},
+		Parallelism:    inputs.DetectParallelism,
+		RecordDigests:  inputs.RecordDigests,
 	}
 	var err error
+	if detector.Timeouts, err = buildpack.ParseTimeouts(inputs.BuildpackTimeout); err != nil {
+		return nil, err
+	}
 	if detector.AnalyzeMD, err = f.configHandler.ReadAnalyzed(inputs.AnalyzedPath, logger); err != nil {
 		return nil, err
 	}
@@ -90,8 +128,20 @@ func (f *HermeticFactory) NewDetector(inputs platform.LifecycleInputs, logger lo
 }
 
 func (d *Detector) Detect() (buildpack.Group, files.Plan, error) {
+	return d.DetectContext(context.Background())
+}
+
+// DetectContext is like Detect, but stops running `./bin/detect` (killing any running processes) when the provided context is done.
+func (d *Detector) DetectContext(ctx context.Context) (_ buildpack.Group, _ files.Plan, err error) {
 	defer log.NewMeasurement("Detector", d.Logger)()
-	group, plan, detectErr := d.DetectOrder(d.Order)
+	ctx, endPhase := startPhase(ctx, d.Events, "detect")
+	defer func() { endPhase(err) }()
+	group, plan, detectErr := d.DetectOrderContext(ctx, d.Order)
+	if detectErr == nil && d.RecordDigests {
+		if detectErr = recordDigests(d.DirStore, buildpack.KindBuildpack, group.Group); detectErr == nil {
+			detectErr = recordDigests(d.DirStore, buildpack.KindExtension, group.GroupExtensions)
+		}
+	}
 	for _, e := range d.memHandler.Entries {
 		if detectErr != nil || e.Level >= d.Logger.LogLevel() {
 			if err := d.Logger.HandleLog(e); err != nil {
@@ -103,8 +153,19 @@ func (d *Detector) Detect() (buildpack.Group, files.Plan, error) {
 }
 
 func (d *Detector) DetectOrder(order buildpack.Order) (buildpack.Group, files.Plan, error) {
-	detected, planEntries, err := d.detectOrder(order, nil, nil, false, &sync.WaitGroup{})
-	if err == ErrBuildpack {
+	return d.DetectOrderContext(context.Background(), order)
+}
+
+// DetectOrderContext is like DetectOrder, but stops running `./bin/detect` (killing any running processes) when the provided context is done.
+func (d *Detector) DetectOrderContext(ctx context.Context, order buildpack.Order) (buildpack.Group, files.Plan, error) {
+	d.resolved = map[string]bool{}
+	if d.Parallelism > 0 {
+		d.detectAll(ctx, order)
+	}
+	detected, planEntries, err := d.detectOrder(ctx, order, nil, nil, nil, nil, false, &sync.WaitGroup{})
+	if err == ErrBuildpack && d.anyTimedOut() {
+		err = buildpack.NewError(err, buildpack.ErrTypeTimeout)
+	} else if err == ErrBuildpack {
 		err = buildpack.NewError(err, buildpack.ErrTypeBuildpack)
 	} else if err == ErrFailedDetection {
 		err = buildpack.NewError(err, buildpack.ErrTypeFailedDetection)
@@ -114,6 +175,104 @@ func (d *Detector) DetectOrder(order buildpack.Order) (buildpack.Group, files.Pl
 		err
 }
 
+// anyTimedOut returns true if `./bin/detect` was killed for exceeding its timeout for any buildpack or image extension
+// in the groups that were resolved.
+func (d *Detector) anyTimedOut() bool {
+	for key := range d.resolved {
+		value, ok := d.Runs.Load(key)
+		if !ok {
+			continue
+		}
+		if run, ok := value.(buildpack.DetectOutputs); ok && buildpack.IsTimeout(run.Err) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectAll runs `./bin/detect` for every unique buildpack and image extension reachable from the provided order,
+// storing the outputs in d.Runs so that they are re-used when groups are resolved.
+func (d *Detector) detectAll(ctx context.Context, order buildpack.Order) {
+	runImageTargetInfo := d.AnalyzeMD.RunImageTarget()
+	var (
+		elements    []buildpack.GroupElement
+		descriptors = map[string]buildpack.Descriptor{}
+	)
+	d.collectDetectable(order, runImageTargetInfo, &elements, descriptors, map[string]bool{})
+
+	inputs := d.detectInputs(runImageTargetInfo)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.Parallelism)
+	for _, groupEl := range elements {
+		key := keyFor(groupEl)
+		if _, ok := d.Runs.Load(key); ok {
+			continue
+		}
+		descriptor := descriptors[key]
+		g.Go(func() error {
+			if gctx.Err() != nil {
+				return nil
+			}
+			d.Runs.Store(key, d.detect(gctx, groupEl, descriptor, inputs))
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// collectDetectable walks the provided order (expanding composite buildpacks and the order for extensions),
+// recording each buildpack or image extension that would be eligible to run `./bin/detect`.
+// Errors are ignored here; they are returned when (and if) the group containing the element is detected.
+func (d *Detector) collectDetectable(order buildpack.Order, runImageTargetInfo files.TargetMetadata, elements *[]buildpack.GroupElement, descriptors map[string]buildpack.Descriptor, visited map[string]bool) {
+	for _, group := range order {
+		for _, groupEl := range group.Group {
+			if groupEl.IsExtensionsOrder() {
+				d.collectDetectable(groupEl.OrderExtensions, runImageTargetInfo, elements, descriptors, visited)
+				continue
+			}
+			var descriptor buildpack.Descriptor
+			if groupEl.Kind() == buildpack.KindBuildpack {
+				bpDescriptor, err := d.DirStore.LookupBp(groupEl.ID, groupEl.Version)
+				if err != nil {
+					continue
+				}
+				groupEl.Version = resolvedVersion(groupEl.Version, bpDescriptor.Buildpack.Version)
+				if visited[keyFor(groupEl)] {
+					continue
+				}
+				visited[keyFor(groupEl)] = true
+				if len(bpDescriptor.Order) > 0 {
+					d.collectDetectable(bpDescriptor.Order, runImageTargetInfo, elements, descriptors, visited)
+					continue
+				}
+				descriptor = bpDescriptor
+			} else {
+				extDescriptor, err := d.DirStore.LookupExt(groupEl.ID, groupEl.Version)
+				if err != nil {
+					continue
+				}
+				groupEl.Version = resolvedVersion(groupEl.Version, extDescriptor.Extension.Version)
+				if visited[keyFor(groupEl)] {
+					continue
+				}
+				visited[keyFor(groupEl)] = true
+				descriptor = extDescriptor
+			}
+			key := keyFor(groupEl)
+			if d.PlatformAPI.AtLeast("0.12") && !d.targetMatches(descriptor, runImageTargetInfo) {
+				continue
+			}
+			if d.PlatformAPI.AtLeast("0.15") && d.ExecEnv != "" {
+				if _, ok := d.execEnvMatches(groupEl, descriptor); !ok {
+					continue
+				}
+			}
+			*elements = append(*elements, groupEl)
+			descriptors[key] = descriptor
+		}
+	}
+}
+
 func filter(group []buildpack.GroupElement, kind string) []buildpack.GroupElement {
 	var out []buildpack.GroupElement
 	for _, el := range group {
@@ -124,12 +283,19 @@ func filter(group []buildpack.GroupElement, kind string) []buildpack.GroupElemen
 	return out
 }
 
-func (d *Detector) detectOrder(order buildpack.Order, done, next []buildpack.GroupElement, optional bool, wg *sync.WaitGroup) ([]buildpack.GroupElement, []files.BuildPlanEntry, error) {
+// detectOrder tries each group in order (followed by the next elements) until one passes detection.
+// The path is the chain of composite buildpacks that contain the order, and nextPaths holds the
+// corresponding chain for each of the next elements; they are used to detect cyclical references.
+func (d *Detector) detectOrder(ctx context.Context, order buildpack.Order, path, done, next []buildpack.GroupElement, nextPaths [][]buildpack.GroupElement, optional bool, wg *sync.WaitGroup) ([]buildpack.GroupElement, []files.BuildPlanEntry, error) {
 	ngroup := buildpack.Group{Group: next}
 	buildpackErr := false
 	for _, group := range order {
+		paths := make([][]buildpack.GroupElement, len(group.Group), len(group.Group)+len(nextPaths))
+		for i := range group.Group {
+			paths[i] = path
+		}
 		// FIXME: double-check slice safety here
-		found, plan, err := d.detectGroup(group.Append(ngroup), done, wg)
+		found, plan, err := d.detectGroup(ctx, group.Append(ngroup), append(paths, nextPaths...), done, wg)
 		if err == ErrBuildpack {
 			buildpackErr = true
 		}
@@ -140,7 +306,7 @@ func (d *Detector) detectOrder(order buildpack.Order, done, next []buildpack.Gro
 		return found, plan, err
 	}
 	if optional {
-		return d.detectGroup(ngroup, done, wg)
+		return d.detectGroup(ctx, ngroup, nextPaths, done, wg)
 	}
 
 	if buildpackErr {
@@ -149,12 +315,15 @@ func (d *Detector) detectOrder(order buildpack.Order, done, next []buildpack.Gro
 	return nil, nil, ErrFailedDetection
 }
 
-func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupElement, wg *sync.WaitGroup) ([]buildpack.GroupElement, []files.BuildPlanEntry, error) {
+func (d *Detector) detectGroup(ctx context.Context, group buildpack.Group, paths [][]buildpack.GroupElement, done []buildpack.GroupElement, wg *sync.WaitGroup) ([]buildpack.GroupElement, []files.BuildPlanEntry, error) {
 	// used below to mark each item as "done" by appending it to the done list
 	markDone := func(groupEl buildpack.GroupElement, descriptor buildpack.Descriptor) {
 		done = append(done, groupEl.WithAPI(descriptor.API()).WithHomepage(descriptor.Homepage()))
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 	runImageTargetInfo := d.AnalyzeMD.RunImageTarget()
 
 	for i, groupEl := range group.Group {
@@ -165,7 +334,7 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupElem
 
 		// Resolve order if element is the order for extensions.
 		if groupEl.IsExtensionsOrder() {
-			return d.detectOrder(groupEl.OrderExtensions, done, group.Group[i+1:], true, wg)
+			return d.detectOrder(ctx, groupEl.OrderExtensions, paths[i], done, group.Group[i+1:], paths[i+1:], true, wg)
 		}
 
 		// Lookup element in store (the "store" is the directory where all the buildpacks are).
@@ -178,46 +347,41 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupElem
 			if err != nil {
 				return nil, nil, err
 			}
+			groupEl.Version = resolvedVersion(groupEl.Version, bpDescriptor.Buildpack.Version)
 
 			// Resolve order if element is a composite buildpack.
 			if order := bpDescriptor.Order; len(order) > 0 {
+				path := append(slices.Clone(paths[i]), groupEl)
+				for j, ancestor := range paths[i] {
+					if ancestor.ID == groupEl.ID && ancestor.Version == groupEl.Version {
+						return nil, nil, newOrderCycleError(path[j:])
+					}
+				}
 				// FIXME: double-check slice safety here
-				// FIXME: cyclical references lead to infinite recursion
-				return d.detectOrder(order, done, group.Group[i+1:], groupEl.Optional, wg)
+				return d.detectOrder(ctx, order, path, done, group.Group[i+1:], paths[i+1:], groupEl.Optional, wg)
 			}
 			descriptor = bpDescriptor // Standardize the type so below we don't have to care whether it is an extension.
 		} else {
-			descriptor, err = d.DirStore.LookupExt(groupEl.ID, groupEl.Version)
+			var extDescriptor *buildpack.ExtDescriptor
+			extDescriptor, err = d.DirStore.LookupExt(groupEl.ID, groupEl.Version)
 			if err != nil {
 				return nil, nil, err
 			}
+			groupEl.Version = resolvedVersion(groupEl.Version, extDescriptor.Extension.Version)
+			descriptor = extDescriptor
 		}
 
 		// Check target compatibility.
 		if d.PlatformAPI.AtLeast("0.12") {
-			var targetMatch bool
-			if len(descriptor.TargetsList()) == 0 {
-				// This is actually just for tests. In practice, the lifecycle will infer a target with at least an OS
-				// when targets are missing from buildpack.toml.
-				targetMatch = true
-			} else {
-				for _, target := range descriptor.TargetsList() {
-					d.Logger.Debugf("Checking for match against descriptor: %s", target)
-					if platform.TargetSatisfiedForBuild(d.OSDetector, &runImageTargetInfo, target, d.Logger) {
-						targetMatch = true
-						break
-					}
-				}
-			}
-
-			if !targetMatch && !groupEl.Optional {
+			if !d.targetMatches(descriptor, runImageTargetInfo) && !groupEl.Optional {
 				markDone(groupEl, descriptor)
 				d.Runs.Store(
 					keyFor(groupEl),
 					buildpack.DetectOutputs{
 						Code: -1,
 						Err: fmt.Errorf(
-							"unable to satisfy target os/arch constraints; run image: %s, buildpack: %s",
+							"%w; run image: %s, buildpack: %s",
+							ErrTargetMismatch,
 							encoding.ToJSONMaybe(runImageTargetInfo),
 							encoding.ToJSONMaybe(descriptor.TargetsList()),
 						),
@@ -230,46 +394,7 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupElem
 		// Note: This is gated by Platform API 0.15, not Buildpack API
 		// The platform controls the Platform API version and determines when this is available
 		if d.PlatformAPI.AtLeast("0.15") && d.ExecEnv != "" {
-			var execEnvMatch bool
-			// Get the exec-env list from the buildpack descriptor
-			var execEnvList []string
-
-			// Only honor buildpack.exec-env if the buildpack API version is >= 0.12
-			var descriptorAPI string
-			if groupEl.Kind() == buildpack.KindBuildpack {
-				if bpDescriptor, ok := descriptor.(*buildpack.BpDescriptor); ok {
-					descriptorAPI = bpDescriptor.API()
-					if api.MustParse(descriptorAPI).AtLeast("0.12") {
-						execEnvList = bpDescriptor.Buildpack.ExecEnv
-					}
-				}
-			} else {
-				if extDescriptor, ok := descriptor.(*buildpack.ExtDescriptor); ok {
-					descriptorAPI = extDescriptor.API()
-					if api.MustParse(descriptorAPI).AtLeast("0.12") {
-						execEnvList = extDescriptor.Extension.ExecEnv
-					}
-				}
-			}
-
-			if len(execEnvList) == 0 {
-				// If no exec-env specified, buildpack/extension applies to all execution environments
-				execEnvMatch = true
-			} else {
-				// Check if buildpack/extension supports all execution environments
-				if slices.Contains(execEnvList, "*") {
-					execEnvMatch = true
-				}
-
-				// Check if buildpack/extension supports the current execution environment
-				if !execEnvMatch {
-					if slices.Contains(execEnvList, d.ExecEnv) {
-						execEnvMatch = true
-					}
-				}
-			}
-
-			if !execEnvMatch {
+			if execEnvList, ok := d.execEnvMatches(groupEl, descriptor); !ok {
 				// Skip buildpack entirely if it doesn't support the current execution environment
 				// This allows the group to continue processing other buildpacks
 				d.Logger.Debugf("Skipping %s due to execution environment mismatch; current: %s, buildpack supports: %s",
@@ -283,27 +408,97 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupElem
 		// Run detect if element is a component buildpack or an extension.
 		wg.Add(1)
 		key := keyFor(groupEl)
-		go func(key string, descriptor buildpack.Descriptor) {
+		go func(key string, groupEl buildpack.GroupElement, descriptor buildpack.Descriptor) {
 			if _, ok := d.Runs.Load(key); !ok {
-				inputs := buildpack.DetectInputs{
-					AppDir:         d.AppDir,
-					BuildConfigDir: d.BuildConfigDir,
-					PlatformDir:    d.PlatformDir,
-					Env:            env.NewBuildEnv(os.Environ()),
-					TargetEnv:      platform.EnvVarsFor(d.OSDetector, runImageTargetInfo, d.Logger),
-					ExecEnv:        d.ExecEnv,
-				}
-				d.Runs.Store(key, d.Executor.Detect(descriptor, inputs, d.Logger)) // this is where we finally invoke bin/detect
+				d.Runs.Store(key, d.detect(ctx, groupEl, descriptor, d.detectInputs(runImageTargetInfo))) // this is where we finally invoke bin/detect
 			}
 			wg.Done()
-		}(key, descriptor)
+		}(key, groupEl, descriptor)
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
 
+	for _, groupEl := range done {
+		d.resolved[keyFor(groupEl)] = true
+	}
 	return d.Resolver.Resolve(done, d.Runs)
 }
 
+func (d *Detector) detectInputs(runImageTargetInfo files.TargetMetadata) buildpack.DetectInputs {
+	return buildpack.DetectInputs{
+		AppDir:         d.AppDir,
+		BuildConfigDir: d.BuildConfigDir,
+		PlatformDir:    d.PlatformDir,
+		Env:            env.NewBuildEnv(os.Environ()),
+		TargetEnv:      platform.EnvVarsFor(d.OSDetector, runImageTargetInfo, d.Logger),
+		ExecEnv:        d.ExecEnv,
+		Network:        d.Network,
+	}
+}
+
+// detect runs `./bin/detect` for the provided buildpack or image extension, applying its configured timeout.
+func (d *Detector) detect(ctx context.Context, groupEl buildpack.GroupElement, descriptor buildpack.Descriptor, inputs buildpack.DetectInputs) buildpack.DetectOutputs {
+	inputs.Timeout = d.Timeouts.For(groupEl.ID)
+	var endModule func(*int, *buildpack.Usage, error)
+	inputs.Context, endModule = startModule(ctx, d.Events, "bin/detect", event.DetectStart, event.DetectEnd, groupEl, inputs.Network != nil)
+	run := d.Executor.Detect(descriptor, inputs, d.Logger)
+	endModule(&run.Code, run.Usage, run.Err)
+	return run
+}
+
+// targetMatches returns true if any of the targets of the provided buildpack or image extension
+// are satisfied by the run image target.
+func (d *Detector) targetMatches(descriptor buildpack.Descriptor, runImageTargetInfo files.TargetMetadata) bool {
+	if len(descriptor.TargetsList()) == 0 {
+		// This is actually just for tests. In practice, the lifecycle will infer a target with at least an OS
+		// when targets are missing from buildpack.toml.
+		return true
+	}
+	for _, target := range descriptor.TargetsList() {
+		d.Logger.Debugf("Checking for match against descriptor: %s", target)
+		if platform.TargetSatisfiedForBuild(d.OSDetector, &runImageTargetInfo, target, d.Logger) {
+			return true
+		}
+	}
+	return false
+}
+
+// execEnvMatches returns true if the provided buildpack or image extension supports the current execution environment,
+// along with the list of execution environments that it supports.
+func (d *Detector) execEnvMatches(groupEl buildpack.GroupElement, descriptor buildpack.Descriptor) ([]string, bool) {
+	// Get the exec-env list from the buildpack descriptor
+	var execEnvList []string
+
+	// Only honor buildpack.exec-env if the buildpack API version is >= 0.12
+	var descriptorAPI string
+	if groupEl.Kind() == buildpack.KindBuildpack {
+		if bpDescriptor, ok := descriptor.(*buildpack.BpDescriptor); ok {
+			descriptorAPI = bpDescriptor.API()
+			if api.MustParse(descriptorAPI).AtLeast("0.12") {
+				execEnvList = bpDescriptor.Buildpack.ExecEnv
+			}
+		}
+	} else {
+		if extDescriptor, ok := descriptor.(*buildpack.ExtDescriptor); ok {
+			descriptorAPI = extDescriptor.API()
+			if api.MustParse(descriptorAPI).AtLeast("0.12") {
+				execEnvList = extDescriptor.Extension.ExecEnv
+			}
+		}
+	}
+
+	if len(execEnvList) == 0 {
+		// If no exec-env specified, buildpack/extension applies to all execution environments
+		return execEnvList, true
+	}
+	// Check if buildpack/extension supports all execution environments,
+	// or if it supports the current execution environment
+	return execEnvList, slices.Contains(execEnvList, "*") || slices.Contains(execEnvList, d.ExecEnv)
+}
+
 func hasIDForKind(els []buildpack.GroupElement, kind string, id string) bool {
 	for _, el := range els {
 		if el.Kind() == kind && el.ID == id {
@@ -313,12 +508,34 @@ func hasIDForKind(els []buildpack.GroupElement, kind string, id string) bool {
 	return false
 }
 
+// resolvedVersion returns the version of the buildpack or image extension that was found for the version in the order,
+// which may be a range (e.g., `^2.3`) or empty, so that the exact version is recorded in the group.
+func resolvedVersion(orderVersion, descriptorVersion string) string {
+	if descriptorVersion == "" {
+		return orderVersion
+	}
+	return descriptorVersion
+}
+
 func keyFor(groupEl buildpack.GroupElement) string {
 	return fmt.Sprintf("%s %s", groupEl.Kind(), groupEl.String())
 }
 
+func newOrderCycleError(cycle []buildpack.GroupElement) error {
+	var ids []string
+	for _, el := range cycle {
+		ids = append(ids, el.String())
+	}
+	return buildpack.NewError(
+		fmt.Errorf("found cyclical reference in composite buildpack order: %s", strings.Join(ids, " -> ")),
+		buildpack.ErrTypeOrderCycle,
+	)
+}
+
 type DefaultDetectResolver struct {
 	Logger log.Logger
+	// Report, if provided, is appended with the outcome of each group that is resolved.
+	Report *files.DetectReport
 }
 
 func NewDefaultDetectResolver(logger log.Logger) *DefaultDetectResolver {
@@ -327,7 +544,20 @@ func NewDefaultDetectResolver(logger log.Logger) *DefaultDetectResolver {
 
 // Resolve aggregates the detect output for a group of buildpacks and tries to resolve a build plan for the group.
 // If any required buildpack in the group failed detection or a build plan cannot be resolved, it returns an error.
-func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRuns *sync.Map) ([]buildpack.GroupElement, []files.BuildPlanEntry, error) {
+func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRuns *sync.Map) (found []buildpack.GroupElement, plan []files.BuildPlanEntry, err error) {
+	groupReport := files.DetectGroupReport{}
+	if r.Report != nil {
+		defer func() {
+			groupReport.Result = "pass"
+			if err == ErrBuildpack {
+				groupReport.Result = "error"
+			} else if err != nil {
+				groupReport.Result = "fail"
+			}
+			r.Report.Groups = append(r.Report.Groups, groupReport)
+		}()
+	}
+
 	var groupRuns []buildpack.DetectOutputs
 	for _, el := range done {
 		key := keyFor(el) // FIXME: ensure the Detector and Resolver always use the same key
@@ -365,6 +595,7 @@ func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRun
 	buildpackErr := false
 	for i, el := range done {
 		run := groupRuns[i]
+		elementReport := newDetectElementReport(el, run)
 		switch run.Code {
 		case CodeDetectPass:
 			r.Logger.Debugf("pass: %s", el)
@@ -372,22 +603,28 @@ func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRun
 			if !el.Extension {
 				anyBuildpacksDetected = true
 			}
+			elementReport.Result = "pass"
 		case CodeDetectFail:
 			if el.Optional {
 				r.Logger.Debugf("skip: %s", el)
+				elementReport.Result = "skip"
 			} else {
 				r.Logger.Debugf("fail: %s", el)
+				elementReport.Result = "fail"
 			}
 			detected = detected && el.Optional
 		case -1:
 			r.Logger.Infof("err:  %s", el)
 			buildpackErr = true
 			detected = detected && el.Optional
+			elementReport.Result = "error"
 		default:
 			r.Logger.Infof("err:  %s (%d)", el, run.Code)
 			buildpackErr = true
 			detected = detected && el.Optional
+			elementReport.Result = "error"
 		}
+		groupReport.Elements = append(groupReport.Elements, elementReport)
 	}
 	if !detected {
 		if buildpackErr {
@@ -402,7 +639,13 @@ func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRun
 	i := 0
 	deps, trial, err := results.runTrials(func(trial detectTrial) (depMap, detectTrial, error) {
 		i++
-		return r.runTrial(i, trial)
+		trialReport := files.DetectTrialReport{Result: "pass"}
+		deps, trial, err := r.runTrial(i, trial, &trialReport)
+		if err != nil {
+			trialReport.Result = "fail"
+		}
+		groupReport.Trials = append(groupReport.Trials, trialReport)
+		return deps, trial, err
 	})
 	if err != nil {
 		return nil, nil, err
@@ -426,19 +669,20 @@ func (r *DefaultDetectResolver) Resolve(done []buildpack.GroupElement, detectRun
 		r.Logger.Infof(f, t.ID, t.Version)
 	}
 
-	var found []buildpack.GroupElement
 	for _, r := range trial {
 		found = append(found, r.GroupElement.NoOpt())
 	}
-	var plan []files.BuildPlanEntry
 	for _, dep := range deps {
 		plan = append(plan, dep.BuildPlanEntry.NoOpt())
 	}
 	return found, plan, nil
 }
 
-func (r *DefaultDetectResolver) runTrial(i int, trial detectTrial) (depMap, detectTrial, error) {
+func (r *DefaultDetectResolver) runTrial(i int, trial detectTrial, report *files.DetectTrialReport) (depMap, detectTrial, error) {
 	r.Logger.Debugf("Resolving plan... (try #%d)", i)
+	for _, option := range trial {
+		report.Elements = append(report.Elements, option.GroupElement.String())
+	}
 
 	var deps depMap
 	retry := true
@@ -448,6 +692,7 @@ func (r *DefaultDetectResolver) runTrial(i int, trial detectTrial) (depMap, dete
 
 		if err := deps.eachUnmetRequire(func(name string, el buildpack.GroupElement) error {
 			retry = true
+			report.Unmet = append(report.Unmet, files.DetectUnmetEntry{ID: el.ID, Version: el.Version, Optional: el.Optional, Requires: name})
 			if !el.Optional {
 				r.Logger.Debugf("fail: %s requires %s", el, name)
 				return ErrFailedDetection
@@ -461,6 +706,7 @@ func (r *DefaultDetectResolver) runTrial(i int, trial detectTrial) (depMap, dete
 
 		if err := deps.eachUnmetProvide(func(name string, el buildpack.GroupElement) error {
 			retry = true
+			report.Unmet = append(report.Unmet, files.DetectUnmetEntry{ID: el.ID, Version: el.Version, Optional: el.Optional, Provides: name})
 			if !el.Optional {
 				r.Logger.Debugf("fail: %s provides unused %s", el, name)
 				return ErrFailedDetection
@@ -480,6 +726,26 @@ func (r *DefaultDetectResolver) runTrial(i int, trial detectTrial) (depMap, dete
 	return deps, trial, nil
 }
 
+func newDetectElementReport(el buildpack.GroupElement, run buildpack.DetectOutputs) files.DetectElementReport {
+	report := files.DetectElementReport{
+		ID:             el.ID,
+		Version:        el.Version,
+		Extension:      el.Extension,
+		Optional:       el.Optional,
+		ExitCode:       run.Code,
+		TargetMismatch: errors.Is(run.Err, ErrTargetMismatch),
+	}
+	output := run.Output
+	if len(output) > detectReportOutputLimit {
+		output = output[len(output)-detectReportOutputLimit:]
+	}
+	report.Output = string(output)
+	if run.Err != nil {
+		report.Error = run.Err.Error()
+	}
+	return report
+}
+
 type detectResult struct {
 	buildpack.GroupElement
 	buildpack.DetectOutputs
@@ -1,12 +1,16 @@
 package phase_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	apexlog "github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -269,6 +273,41 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 				h.AssertNotNil(t, detector.Runs)
 			})
 		})
+
+		when("a composite buildpack references itself", func() {
+			it("errors before running detect", func() {
+				order := buildpack.Order{
+					buildpack.Group{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}},
+				}
+				configHandler.EXPECT().ReadOrder("some-order-path").Return(order, nil, nil)
+
+				bpA1 := &buildpack.BpDescriptor{
+					WithAPI: "0.2",
+					Order:   buildpack.Order{{Group: []buildpack.GroupElement{{ID: "B", Version: "v2"}}}},
+				}
+				bpB2 := &buildpack.BpDescriptor{
+					WithAPI: "0.2",
+					Order: buildpack.Order{
+						{Group: []buildpack.GroupElement{{ID: "C", Version: "v1"}}},
+						{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}},
+					},
+				}
+				bpC1 := &buildpack.BpDescriptor{WithAPI: "0.2"}
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "A", "v1").Return(bpA1, nil)
+				apiVerifier.EXPECT().VerifyBuildpackAPI(buildpack.KindBuildpack, "A@v1", "0.2", logger)
+				dirStore.EXPECT().LookupBp("B", "v2").Return(bpB2, nil)
+				dirStore.EXPECT().LookupBp("C", "v1").Return(bpC1, nil)
+
+				_, err := detectorFactory.NewDetector(platform.LifecycleInputs{
+					AnalyzedPath: "some-analyzed-path",
+					OrderPath:    "some-order-path",
+				}, logger)
+				bpErr, ok := err.(*buildpack.Error)
+				h.AssertEq(t, ok, true)
+				h.AssertEq(t, bpErr.Type, buildpack.ErrTypeOrderCycle)
+				h.AssertError(t, err, "A@v1 -> B@v2 -> A@v1")
+			})
+		})
 	})
 
 	when(".Detect", func() {
@@ -784,6 +823,156 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 			_, _, _ = detector.Detect()
 		})
 
+		it("records the digest of each buildpack in the selected group when configured", func() {
+			tmpDir := t.TempDir()
+			bpA1 := &buildpack.BpDescriptor{
+				Buildpack:   buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+				WithRootDir: tmpDir,
+			}
+			h.Mkfile(t, `api = "0.10"`, filepath.Join(tmpDir, "buildpack.toml"))
+			dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+			executor.EXPECT().Detect(bpA1, gomock.Any(), gomock.Any())
+			resolver.EXPECT().Resolve(gomock.Any(), detector.Runs).Return([]buildpack.GroupElement{{ID: "A", Version: "v1"}}, nil, nil)
+
+			detector.RecordDigests = true
+			detector.Order = buildpack.Order{{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}}}
+			group, _, err := detector.Detect()
+			h.AssertNil(t, err)
+
+			digest, err := buildpack.DirDigest(tmpDir)
+			h.AssertNil(t, err)
+			h.AssertEq(t, group.Group, []buildpack.GroupElement{{ID: "A", Version: "v1", Digest: digest}})
+		})
+
+		it("records the version resolved for a version range", func() {
+			bpA1 := &buildpack.BpDescriptor{
+				Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "1.2.3"}},
+			}
+			bpB1 := &buildpack.BpDescriptor{
+				Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "2.0.0"}},
+			}
+			dirStore.EXPECT().LookupBp("A", "^1.2").Return(bpA1, nil).AnyTimes()
+			dirStore.EXPECT().LookupBp("B", "").Return(bpB1, nil).AnyTimes()
+			executor.EXPECT().Detect(bpA1, gomock.Any(), gomock.Any())
+			executor.EXPECT().Detect(bpB1, gomock.Any(), gomock.Any())
+
+			resolver.EXPECT().Resolve([]buildpack.GroupElement{
+				{ID: "A", Version: "1.2.3"},
+				{ID: "B", Version: "2.0.0"},
+			}, detector.Runs)
+
+			detector.Order = buildpack.Order{{Group: []buildpack.GroupElement{
+				{ID: "A", Version: "^1.2"},
+				{ID: "B"},
+			}}}
+			_, _, _ = detector.Detect()
+		})
+
+		when("parallelism is configured", func() {
+			it.Before(func() {
+				detector.Parallelism = 2
+			})
+
+			it("detects every element up front and selects the first passing group", func() {
+				bpA1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+				}
+				dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+				bpB1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v1"}},
+				}
+				dirStore.EXPECT().LookupBp("B", "v1").Return(bpB1, nil).AnyTimes()
+				bpC1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "C", Version: "v1"}},
+					Order:     buildpack.Order{{Group: []buildpack.GroupElement{{ID: "D", Version: "v1"}}}},
+				}
+				dirStore.EXPECT().LookupBp("C", "v1").Return(bpC1, nil).AnyTimes()
+				bpD1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "D", Version: "v1"}},
+				}
+				dirStore.EXPECT().LookupBp("D", "v1").Return(bpD1, nil).AnyTimes()
+
+				// each element is detected exactly once, including those in groups that are never resolved
+				executor.EXPECT().Detect(bpA1, gomock.Any(), gomock.Any())
+				executor.EXPECT().Detect(bpB1, gomock.Any(), gomock.Any())
+				executor.EXPECT().Detect(bpD1, gomock.Any(), gomock.Any())
+
+				firstResolve := resolver.EXPECT().Resolve(
+					[]buildpack.GroupElement{{ID: "A", Version: "v1"}, {ID: "B", Version: "v1"}},
+					detector.Runs,
+				).Return(nil, nil, phase.ErrFailedDetection)
+				resolver.EXPECT().Resolve(
+					[]buildpack.GroupElement{{ID: "B", Version: "v1"}},
+					detector.Runs,
+				).Return(
+					[]buildpack.GroupElement{{ID: "B", Version: "v1"}},
+					[]files.BuildPlanEntry{},
+					nil,
+				).After(firstResolve)
+
+				detector.Order = buildpack.Order{
+					{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}, {ID: "B", Version: "v1"}}},
+					{Group: []buildpack.GroupElement{{ID: "B", Version: "v1"}}},
+					{Group: []buildpack.GroupElement{{ID: "C", Version: "v1"}}},
+				}
+				group, _, err := detector.Detect()
+				h.AssertNil(t, err)
+				h.AssertEq(t, group.Group, []buildpack.GroupElement{{ID: "B", Version: "v1"}})
+			})
+		})
+
+		when("a composite buildpack references itself", func() {
+			it("returns an order cycle error listing the cycle", func() {
+				bpA1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+					Order:     buildpack.Order{{Group: []buildpack.GroupElement{{ID: "B", Version: "v2"}}}},
+				}
+				dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+				bpB2 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v2"}},
+					Order:     buildpack.Order{{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}}},
+				}
+				dirStore.EXPECT().LookupBp("B", "v2").Return(bpB2, nil).AnyTimes()
+
+				detector.Order = buildpack.Order{{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}}}
+				_, _, err := detector.Detect()
+				bpErr, ok := err.(*buildpack.Error)
+				h.AssertEq(t, ok, true)
+				h.AssertEq(t, bpErr.Type, buildpack.ErrTypeOrderCycle)
+				h.AssertError(t, err, "A@v1 -> B@v2 -> A@v1")
+			})
+
+			it("does not mistake a repeated sibling for a cycle", func() {
+				bpA1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+					Order:     buildpack.Order{{Group: []buildpack.GroupElement{{ID: "C", Version: "v1"}}}},
+				}
+				dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+				bpB1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "B", Version: "v1"}},
+					Order:     buildpack.Order{{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}}},
+				}
+				dirStore.EXPECT().LookupBp("B", "v1").Return(bpB1, nil).AnyTimes()
+				bpC1 := &buildpack.BpDescriptor{
+					Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "C", Version: "v1"}},
+				}
+				dirStore.EXPECT().LookupBp("C", "v1").Return(bpC1, nil).AnyTimes()
+				executor.EXPECT().Detect(bpC1, gomock.Any(), gomock.Any())
+
+				resolver.EXPECT().Resolve(
+					[]buildpack.GroupElement{{ID: "C", Version: "v1"}},
+					detector.Runs,
+				).Return([]buildpack.GroupElement{{ID: "C", Version: "v1"}}, nil, nil)
+
+				detector.Order = buildpack.Order{{Group: []buildpack.GroupElement{
+					{ID: "A", Version: "v1"},
+					{ID: "B", Version: "v1"},
+				}}}
+				_, _, err := detector.Detect()
+				h.AssertNil(t, err)
+			})
+		})
+
 		when("resolve errors", func() {
 			when("with buildpack error", func() {
 				it("returns a buildpack error", func() {
@@ -810,6 +999,79 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 
+			when("with buildpack timeout", func() {
+				it("returns a timeout error", func() {
+					bpA1 := &buildpack.BpDescriptor{
+						Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+					}
+					dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+					detector.Timeouts = buildpack.Timeouts{Default: time.Minute, ByID: map[string]time.Duration{"A": time.Second}}
+					executor.EXPECT().Detect(bpA1, gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ buildpack.Descriptor, inputs buildpack.DetectInputs, _ log.Logger) buildpack.DetectOutputs {
+							h.AssertEq(t, inputs.Timeout, time.Second)
+							return buildpack.DetectOutputs{Code: -1, Err: buildpack.NewError(errors.New("timed out"), buildpack.ErrTypeTimeout)}
+						})
+
+					group := []buildpack.GroupElement{
+						{ID: "A", Version: "v1"},
+					}
+					resolver.EXPECT().Resolve(group, detector.Runs).Return(
+						[]buildpack.GroupElement{},
+						[]files.BuildPlanEntry{},
+						phase.ErrBuildpack,
+					)
+
+					detector.Order = buildpack.Order{{Group: group}}
+					_, _, err := detector.Detect()
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeTimeout {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+				})
+			})
+
+			when("with a buildpack timeout in a group that is not resolved", func() {
+				it("returns a buildpack error", func() {
+					detector.Parallelism = 2
+					bpA1 := &buildpack.BpDescriptor{
+						Buildpack: buildpack.BpInfo{BaseInfo: buildpack.BaseInfo{ID: "A", Version: "v1"}},
+					}
+					dirStore.EXPECT().LookupBp("A", "v1").Return(bpA1, nil).AnyTimes()
+					executor.EXPECT().Detect(bpA1, gomock.Any(), gomock.Any()).Return(
+						buildpack.DetectOutputs{Code: 127, Err: errors.New("some-error")},
+					)
+					// the outputs of a speculative run of a group that is not resolved (e.g., by an earlier call with another order)
+					detector.Runs.Store("buildpack C@v1", buildpack.DetectOutputs{Code: -1, Err: buildpack.NewError(errors.New("timed out"), buildpack.ErrTypeTimeout)})
+
+					group := []buildpack.GroupElement{
+						{ID: "A", Version: "v1"},
+					}
+					resolver.EXPECT().Resolve(group, detector.Runs).Return(
+						[]buildpack.GroupElement{},
+						[]files.BuildPlanEntry{},
+						phase.ErrBuildpack,
+					)
+
+					detector.Order = buildpack.Order{{Group: group}}
+					_, _, err := detector.Detect()
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeBuildpack {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+				})
+			})
+
+			when("the context is done", func() {
+				it("returns the context error without running detect", func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+
+					detector.Order = buildpack.Order{{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}}}}
+					_, _, err := detector.DetectContext(ctx)
+					if !errors.Is(err, context.Canceled) {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+				})
+			})
+
 			when("with detect error", func() {
 				it("returns a detect error", func() {
 					bpA1 := &buildpack.BpDescriptor{
@@ -1704,6 +1966,90 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf("Unexpected log:\n%s\n", s)
 			}
 		})
+
+		when("a report is provided", func() {
+			it.Before(func() {
+				resolver.Report = &files.DetectReport{}
+			})
+
+			it("records the outcome of each element and trial", func() {
+				group := []buildpack.GroupElement{
+					{ID: "A", Version: "v1", Optional: true},
+					{ID: "B", Version: "v1"},
+					{ID: "C", Version: "v1"},
+				}
+
+				detectRuns := &sync.Map{}
+				detectRuns.Store("Buildpack A@v1", buildpack.DetectOutputs{
+					Code:   100,
+					Output: []byte("no package.json"),
+				})
+				detectRuns.Store("Buildpack B@v1", buildpack.DetectOutputs{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Requires: []buildpack.Require{{Name: "node"}},
+						},
+					},
+				})
+				detectRuns.Store("Buildpack C@v1", buildpack.DetectOutputs{
+					Code: -1,
+					Err:  fmt.Errorf("%w; some details", phase.ErrTargetMismatch),
+				})
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				if err != phase.ErrBuildpack {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+
+				h.AssertEq(t, len(resolver.Report.Groups), 1)
+				groupReport := resolver.Report.Groups[0]
+				h.AssertEq(t, groupReport.Result, "error")
+				h.AssertEq(t, groupReport.Elements, []files.DetectElementReport{
+					{ID: "A", Version: "v1", Optional: true, Result: "skip", ExitCode: 100, Output: "no package.json"},
+					{ID: "B", Version: "v1", Result: "pass"},
+					{ID: "C", Version: "v1", Result: "error", ExitCode: -1, TargetMismatch: true, Error: "unable to satisfy target os/arch constraints; some details"},
+				})
+				h.AssertEq(t, len(groupReport.Trials), 0)
+			})
+
+			it("records unmet requires for each trial", func() {
+				group := []buildpack.GroupElement{
+					{ID: "A", Version: "v1", Optional: true},
+					{ID: "B", Version: "v1"},
+				}
+
+				detectRuns := &sync.Map{}
+				detectRuns.Store("Buildpack A@v1", buildpack.DetectOutputs{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Requires: []buildpack.Require{{Name: "python"}},
+						},
+					},
+				})
+				detectRuns.Store("Buildpack B@v1", buildpack.DetectOutputs{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Provides: []buildpack.Provide{{Name: "node"}},
+							Requires: []buildpack.Require{{Name: "node"}},
+						},
+					},
+				})
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, len(resolver.Report.Groups), 1)
+				groupReport := resolver.Report.Groups[0]
+				h.AssertEq(t, groupReport.Result, "pass")
+				h.AssertEq(t, groupReport.Trials, []files.DetectTrialReport{
+					{
+						Result:   "pass",
+						Elements: []string{"A@v1", "B@v1"},
+						Unmet:    []files.DetectUnmetEntry{{ID: "A", Version: "v1", Optional: true, Requires: "python"}},
+					},
+				})
+			})
+		})
 	})
 
 	when("execution environment filtering", func() {
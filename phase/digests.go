@@ -0,0 +1,56 @@
+package phase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+// recordDigests sets the digest of the directory of each buildpack or image extension (according to kind) in the provided group.
+func recordDigests(store DirStore, kind string, group []buildpack.GroupElement) error {
+	for i, groupEl := range group {
+		digest, err := digestOf(store, kind, groupEl)
+		if err != nil {
+			return err
+		}
+		group[i].Digest = digest
+	}
+	return nil
+}
+
+// VerifyDigests returns an error if the directory of any buildpack or image extension (according to kind) in the provided group
+// does not match the digest recorded by the detector, i.e., if it was modified (or replaced) after detection.
+func VerifyDigests(store DirStore, kind string, group []buildpack.GroupElement) error {
+	for _, groupEl := range group {
+		if groupEl.Digest == "" {
+			return fmt.Errorf("no digest recorded for %s %s; digests must be recorded during detection to be verified", strings.ToLower(kind), groupEl)
+		}
+		digest, err := digestOf(store, kind, groupEl)
+		if err != nil {
+			return err
+		}
+		if digest != groupEl.Digest {
+			return fmt.Errorf("%s %s has been modified since detection: expected digest '%s', found '%s'", strings.ToLower(kind), groupEl, groupEl.Digest, digest)
+		}
+	}
+	return nil
+}
+
+func digestOf(store DirStore, kind string, groupEl buildpack.GroupElement) (string, error) {
+	var rootDir string
+	if kind == buildpack.KindExtension {
+		descriptor, err := store.LookupExt(groupEl.ID, groupEl.Version)
+		if err != nil {
+			return "", err
+		}
+		rootDir = descriptor.WithRootDir
+	} else {
+		descriptor, err := store.LookupBp(groupEl.ID, groupEl.Version)
+		if err != nil {
+			return "", err
+		}
+		rootDir = descriptor.WithRootDir
+	}
+	return buildpack.DirDigest(rootDir)
+}
@@ -0,0 +1,88 @@
+package phase
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/internal/tracing"
+	"github.com/buildpacks/lifecycle/platform/event"
+)
+
+// startPhase emits a phase-start event and starts a span for the named phase,
+// returning a context carrying the span and a function that ends the span and emits the matching phase-end event.
+func startPhase(ctx context.Context, sink event.Sink, phase string) (context.Context, func(err error)) {
+	event.Emit(sink, event.Event{Type: event.PhaseStart, Phase: phase})
+	ctx, span := tracing.Start(ctx, phase)
+	return ctx, func(err error) {
+		tracing.End(span, err)
+		e := event.Event{Type: event.PhaseEnd, Phase: phase}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		event.Emit(sink, e)
+	}
+}
+
+// exitCodeOf returns the exit code of the buildpack or image extension process that resulted in the provided error.
+func exitCodeOf(err error) *int {
+	code := 0
+	if err != nil {
+		var bpErr *buildpack.Error
+		if errors.As(err, &bpErr) {
+			err = bpErr.Cause()
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil
+		}
+		code = exitErr.ExitCode()
+	}
+	return &code
+}
+
+// withModule records the provided buildpack or image extension as the module that failed, if the provided error is a *buildpack.Error.
+func withModule(err error, module buildpack.GroupElement) error {
+	var bpErr *buildpack.Error
+	if errors.As(err, &bpErr) && bpErr.Module == nil {
+		bpErr.Module = &module
+	}
+	return err
+}
+
+// layerEvent returns an event describing the provided layer tarball.
+func layerEvent(eventType event.Type, id, tarPath, digest string) event.Event {
+	e := event.Event{Type: eventType, Layer: id, Digest: digest}
+	if fi, err := os.Stat(tarPath); err == nil {
+		e.Size = fi.Size()
+	}
+	return e
+}
+
+// startModule emits an event and starts a span for running the named executable (e.g., `./bin/build`)
+// of the provided buildpack or image extension, returning a context carrying the span
+// and a function that ends the span and emits the matching end event.
+// Offline is true if the executable is run without network access.
+func startModule(ctx context.Context, sink event.Sink, name string, start, end event.Type, module buildpack.GroupElement, offline bool) (context.Context, func(exitCode *int, usage *buildpack.Usage, err error)) {
+	event.Emit(sink, event.Event{Type: start, Module: module.String(), Offline: offline})
+	ctx, span := tracing.Start(ctx, name,
+		attribute.String("cnb.module.id", module.ID),
+		attribute.String("cnb.module.version", module.Version),
+		attribute.String("cnb.module.kind", module.Kind()),
+	)
+	return ctx, func(exitCode *int, usage *buildpack.Usage, err error) {
+		if exitCode != nil {
+			span.SetAttributes(attribute.Int("process.exit.code", *exitCode))
+		}
+		tracing.End(span, err)
+		e := event.Event{Type: end, Module: module.String(), ExitCode: exitCode, Usage: usage}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		event.Emit(sink, e)
+	}
+}
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,6 +24,7 @@ import (
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -43,6 +45,7 @@ type Exporter struct {
 	LayerFactory LayerFactory
 	Logger       log.Logger
 	PlatformAPI  *api.Version
+	Events       event.Sink
 }
 
 // LayerFactory given a directory on the local filesystem will return a `layers.Layer`
@@ -92,8 +95,19 @@ type ExportOptions struct {
 }
 
 func (e *Exporter) Export(opts ExportOptions) (files.Report, error) {
-	var err error
+	return e.ExportContext(context.Background(), opts)
+}
+
+// ExportContext is like Export, but returns early when the provided context is done.
+// The context is checked before each layer is added and before the image is saved, and stops retries of the save.
+// A save or cache operation that is in progress is not interrupted, as imgutil and Cache do not accept a context.
+func (e *Exporter) ExportContext(ctx context.Context, opts ExportOptions) (_ files.Report, err error) {
 	defer log.NewMeasurement("Exporter", e.Logger)()
+	ctx, endPhase := startPhase(ctx, e.Events, "export")
+	defer func() { endPhase(err) }()
+	if err = ctx.Err(); err != nil {
+		return files.Report{}, err
+	}
 
 	if e.PlatformAPI.AtLeast("0.11") {
 		if err = e.copyBuildpacksioSBOMs(opts); err != nil {
@@ -136,7 +150,7 @@ func (e *Exporter) Export(opts ExportOptions) (files.Report, error) {
 	}
 
 	// buildpack-provided layers
-	if err := e.addBuildpackLayers(opts, &meta); err != nil {
+	if err := e.addBuildpackLayers(ctx, opts, &meta); err != nil {
 		return files.Report{}, err
 	}
 
@@ -146,6 +160,9 @@ func (e *Exporter) Export(opts ExportOptions) (files.Report, error) {
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return files.Report{}, err
+	}
 	// app layers (split into 1 or more slices)
 	if err := e.addAppLayers(opts, buildMD.Slices, &meta); err != nil {
 		return files.Report{}, errors.Wrap(err, "exporting app layers")
@@ -187,7 +204,10 @@ func (e *Exporter) Export(opts ExportOptions) (files.Report, error) {
 	if err != nil {
 		return files.Report{}, err
 	}
-	report.Image, err = saveImage(opts.WorkingImage, opts.AdditionalNames, e.Logger)
+	if err = ctx.Err(); err != nil {
+		return files.Report{}, err
+	}
+	report.Image, err = saveImage(ctx, opts.WorkingImage, opts.AdditionalNames, e.Logger, e.Events)
 	if err != nil {
 		return files.Report{}, err
 	}
@@ -362,7 +382,7 @@ func isLocalImage(workingImage imgutil.Image) bool {
 	return false
 }
 
-func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *files.LayersMetadata) error {
+func (e *Exporter) addBuildpackLayers(ctx context.Context, opts ExportOptions, meta *files.LayersMetadata) error {
 	for _, bp := range e.Buildpacks {
 		bpDir, err := buildpack.ReadLayersDir(opts.LayersDir, bp, e.Logger)
 		e.Logger.Debugf("Processing buildpack directory: %s", bpDir.Path)
@@ -376,6 +396,9 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *files.LayersMeta
 			Store:   bpDir.Store,
 		}
 		for _, fsLayer := range bpDir.FindLayers(buildpack.MadeLaunch) {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			e.Logger.Debugf("Processing launch layer: %s", fsLayer.Path())
 			lmd, err := fsLayer.Read()
 			if err != nil {
@@ -407,6 +430,7 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *files.LayersMeta
 				if err := opts.WorkingImage.ReuseLayerWithHistory(origLayerMetadata.SHA, v1.History{CreatedBy: createdBy}); err != nil {
 					return errors.Wrapf(err, "reusing layer: '%s'", fsLayer.Identifier())
 				}
+				event.Emit(e.Events, event.Event{Type: event.LayerReused, Layer: fsLayer.Identifier(), Digest: origLayerMetadata.SHA})
 				lmd.SHA = origLayerMetadata.SHA
 			}
 			bpMD.Layers[fsLayer.Name()] = lmd
@@ -468,15 +492,18 @@ func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta
 				break
 			}
 		}
+		eventType := event.LayerAdded
 		if found {
 			err = opts.WorkingImage.ReuseLayerWithHistory(slice.Digest, slice.History)
 			numberOfReusedLayers++
+			eventType = event.LayerReused
 		} else {
 			err = opts.WorkingImage.AddLayerWithDiffIDAndHistory(slice.TarPath, slice.Digest, slice.History)
 		}
 		if err != nil {
 			return err
 		}
+		event.Emit(e.Events, layerEvent(eventType, slice.ID, slice.TarPath, slice.Digest))
 		meta.App = append(meta.App, files.LayerMetadata{SHA: slice.Digest})
 	}
 
@@ -619,15 +646,27 @@ func (e *Exporter) addOrReuseBuildpackLayer(image imgutil.Image, layer layers.La
 	if layer.Digest == previousSHA {
 		e.Logger.Infof("Reusing layer '%s'\n", layer.ID)
 		e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
-		return layer.Digest, image.ReuseLayerWithHistory(previousSHA, layer.History)
+		if err := image.ReuseLayerWithHistory(previousSHA, layer.History); err != nil {
+			return layer.Digest, err
+		}
+		event.Emit(e.Events, layerEvent(event.LayerReused, layer.ID, layer.TarPath, layer.Digest))
+		return layer.Digest, nil
 	}
 	e.Logger.Infof("Adding layer '%s'\n", layer.ID)
 	e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
-	return layer.Digest, image.AddLayerWithDiffIDAndHistory(layer.TarPath, layer.Digest, layer.History)
+	return layer.Digest, e.addLayer(image, layer)
 }
 
 func (e *Exporter) addExtensionLayer(image imgutil.Image, layer layers.Layer) (string, error) {
-	return layer.Digest, image.AddLayerWithDiffIDAndHistory(layer.TarPath, layer.Digest, layer.History)
+	return layer.Digest, e.addLayer(image, layer)
+}
+
+func (e *Exporter) addLayer(image imgutil.Image, layer layers.Layer) error {
+	if err := image.AddLayerWithDiffIDAndHistory(layer.TarPath, layer.Digest, layer.History); err != nil {
+		return err
+	}
+	event.Emit(e.Events, layerEvent(event.LayerAdded, layer.ID, layer.TarPath, layer.Digest))
+	return nil
 }
 
 func (e *Exporter) makeBuildReport(layersDir string) (files.BuildReport, error) {
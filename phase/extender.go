@@ -20,6 +20,7 @@ import (
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/internal/extend"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/layers"
@@ -36,6 +37,8 @@ type Extender struct {
 	PlatformDir  string // explicitly ignored by the Dockerfile applier
 
 	CacheTTL          time.Duration            // a platform input
+	RegistryMirrors   image.Mirrors            // a platform input
+	RegistryTLS       image.RegistryTLS        // a platform input
 	DockerfileApplier DockerfileApplier        // uses kaniko, BuildKit, or other to apply the provided Dockerfile to the provided image
 	Extensions        []buildpack.GroupElement // extensions are ordered from group.toml
 
@@ -62,6 +65,8 @@ func (f *HermeticFactory) NewExtender(inputs platform.LifecycleInputs, dockerfil
 		LayersDir:         inputs.LayersDir,
 		PlatformDir:       inputs.PlatformDir,
 		CacheTTL:          inputs.KanikoCacheTTL,
+		RegistryMirrors:   inputs.RegistryMirrors,
+		RegistryTLS:       inputs.RegistryTLS,
 		DockerfileApplier: dockerfileApplier,
 		PlatformAPI:       f.platformAPI,
 	}
@@ -467,5 +472,8 @@ func (e *Extender) extendOptions(dockerfile extend.Dockerfile) extend.Options {
 		BuildContext: dockerfile.ContextDir,
 		CacheTTL:     e.CacheTTL,
 		IgnorePaths:  []string{e.AppDir, e.LayersDir, e.PlatformDir},
+
+		RegistryMirrors: e.RegistryMirrors,
+		RegistryTLS:     e.RegistryTLS,
 	}
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/internal/extend"
 	llog "github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/phase"
@@ -82,6 +83,9 @@ func testExtenderFactory(t *testing.T, when spec.G, it spec.S) {
 				PlatformDir:    "some-platform-dir",
 				KanikoCacheTTL: 7 * (24 * time.Hour),
 				ExtendKind:     kind,
+				RegistryMirrors: image.Mirrors{
+					"some.registry": {"mirror.internal/some-registry"},
+				},
 			}, fakeDockerfileApplier, logger)
 			h.AssertNil(t, err)
 		}
@@ -110,6 +114,7 @@ func testExtenderFactory(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, extender.LayersDir, "some-layers-dir")
 			h.AssertEq(t, extender.PlatformDir, "some-platform-dir")
 			h.AssertEq(t, extender.CacheTTL, 7*(24*time.Hour))
+			h.AssertEq(t, extender.RegistryMirrors, image.Mirrors{"some.registry": {"mirror.internal/some-registry"}})
 			h.AssertEq(t, extender.Extensions, []buildpack.GroupElement{
 				{ID: "A", Version: "v1", API: "0.9", Extension: true},
 			})
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,7 @@ import (
 	"github.com/buildpacks/lifecycle/internal/fsutil"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -31,6 +33,11 @@ type Generator struct {
 	Out, Err       io.Writer
 	Plan           files.Plan
 	RunMetadata    files.Run
+	Timeouts       buildpack.Timeouts
+	Events         event.Sink
+
+	// Network, if provided, is the network namespace in which `./bin/generate` is run, so that image extensions cannot reach the network.
+	Network *buildpack.IsolatedNetwork
 }
 
 // NewGenerator constructs a new Generator by initializing services and reading the provided analyzed, group, plan, and run files.
@@ -48,6 +55,9 @@ func (f *HermeticFactory) NewGenerator(inputs platform.LifecycleInputs, stdout,
 		Err:            stderr,
 	}
 	var err error
+	if generator.Timeouts, err = buildpack.ParseTimeouts(inputs.BuildpackTimeout); err != nil {
+		return nil, err
+	}
 	if generator.Extensions, err = f.getExtensions(inputs.GroupPath, logger); err != nil {
 		return nil, err
 	}
@@ -69,10 +79,16 @@ type GenerateResult struct {
 }
 
 func (g *Generator) Generate() (GenerateResult, error) {
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext is like Generate, but stops running `./bin/generate` (killing any running processes) when the provided context is done.
+func (g *Generator) GenerateContext(ctx context.Context) (_ GenerateResult, err error) {
 	defer log.NewMeasurement("Generator", g.Logger)()
+	ctx, endPhase := startPhase(ctx, g.Events, "generate")
+	defer func() { endPhase(err) }()
 	inputs := g.getGenerateInputs()
 
-	var err error
 	if g.PlatformAPI.LessThan("0.13") {
 		extensionOutputParentDir, err := os.MkdirTemp("", "cnb-extensions-generated.")
 		if err != nil {
@@ -89,6 +105,9 @@ func (g *Generator) Generate() (GenerateResult, error) {
 	var dockerfiles []buildpack.DockerfileInfo
 	filteredPlan := g.Plan
 	for _, ext := range g.Extensions {
+		if err := ctx.Err(); err != nil {
+			return GenerateResult{}, err
+		}
 		g.Logger.Debugf("Running generate for extension %s", ext)
 
 		g.Logger.Debug("Looking up extension")
@@ -99,11 +118,15 @@ func (g *Generator) Generate() (GenerateResult, error) {
 
 		g.Logger.Debug("Finding plan")
 		inputs.Plan = filteredPlan.Find(buildpack.KindExtension, ext.ID)
+		inputs.Timeout = g.Timeouts.For(ext.ID)
 
 		g.Logger.Debug("Invoking command")
+		var endModule func(*int, *buildpack.Usage, error)
+		inputs.Context, endModule = startModule(ctx, g.Events, "bin/generate", event.GenerateStart, event.GenerateEnd, ext, inputs.Network != nil)
 		result, err := g.Executor.Generate(*descriptor, inputs, g.Logger)
+		endModule(exitCodeOf(err), result.Usage, err)
 		if err != nil {
-			return GenerateResult{}, err
+			return GenerateResult{}, withModule(err, ext)
 		}
 
 		// aggregate build results
@@ -154,6 +177,7 @@ func (g *Generator) getGenerateInputs() buildpack.GenerateInputs {
 		TargetEnv:      platform.EnvVarsFor(&fsutil.DefaultDetector{}, g.AnalyzedMD.RunImageTarget(), g.Logger),
 		Out:            g.Out,
 		Err:            g.Err,
+		Network:        g.Network,
 	}
 }
 
@@ -2,6 +2,7 @@ package phase
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/pkg/errors"
 
@@ -148,6 +149,7 @@ func (f *HermeticFactory) verifyGroup(group []buildpack.GroupElement, logger log
 }
 
 func (f *HermeticFactory) verifyOrder(orderBp buildpack.Order, orderExt buildpack.Order, logger log.Logger) error {
+	acyclic := map[string]bool{}
 	for _, group := range append(orderBp, orderExt...) {
 		for _, groupEl := range group.Group {
 			module, err := f.dirStore.Lookup(groupEl.Kind(), groupEl.ID, groupEl.Version)
@@ -157,6 +159,64 @@ func (f *HermeticFactory) verifyOrder(orderBp buildpack.Order, orderExt buildpac
 			if err = f.apiVerifier.VerifyBuildpackAPI(groupEl.Kind(), groupEl.String(), module.API(), logger); err != nil {
 				return err
 			}
+			if bpDescriptor, ok := module.(*buildpack.BpDescriptor); ok && groupEl.Kind() == buildpack.KindBuildpack && len(bpDescriptor.Order) > 0 {
+				groupEl.Version = resolvedVersion(groupEl.Version, bpDescriptor.Buildpack.Version)
+				if err = verifyAcyclicOrder(f.dirStore, bpDescriptor.Order, []buildpack.GroupElement{groupEl}, acyclic); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// verifyAcyclicOrder walks the given order of a composite buildpack, where path is the chain of composite buildpacks
+// being expanded (ending with the owner of the order). It returns an error if any composite buildpack is reachable from itself.
+// Composite buildpacks that have already been walked are recorded in acyclic so that they are not walked again.
+func verifyAcyclicOrder(store DirStore, order buildpack.Order, path []buildpack.GroupElement, acyclic map[string]bool) error {
+	for _, group := range order {
+		for _, groupEl := range group.Group {
+			if groupEl.Kind() != buildpack.KindBuildpack || groupEl.IsExtensionsOrder() {
+				continue
+			}
+			if err := checkCycle(path, groupEl); err != nil {
+				return err
+			}
+			if acyclic[keyFor(groupEl)] {
+				continue
+			}
+			descriptor, err := store.LookupBp(groupEl.ID, groupEl.Version)
+			if err != nil {
+				// missing buildpacks are reported if and when they are detected
+				continue
+			}
+			if version := resolvedVersion(groupEl.Version, descriptor.Buildpack.Version); version != groupEl.Version {
+				// the order specifies a range, so check again with the version it resolved to
+				groupEl.Version = version
+				if err = checkCycle(path, groupEl); err != nil {
+					return err
+				}
+				if acyclic[keyFor(groupEl)] {
+					continue
+				}
+			}
+			if len(descriptor.Order) == 0 {
+				continue
+			}
+			if err = verifyAcyclicOrder(store, descriptor.Order, append(slices.Clone(path), groupEl), acyclic); err != nil {
+				return err
+			}
+		}
+	}
+	acyclic[keyFor(path[len(path)-1])] = true
+	return nil
+}
+
+// checkCycle returns an error if the provided buildpack is already in the provided chain of composite buildpacks.
+func checkCycle(path []buildpack.GroupElement, groupEl buildpack.GroupElement) error {
+	for i, ancestor := range path {
+		if ancestor.ID == groupEl.ID && ancestor.Version == groupEl.Version {
+			return newOrderCycleError(append(slices.Clone(path[i:]), groupEl))
 		}
 	}
 	return nil
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -15,6 +16,7 @@ import (
 	"github.com/buildpacks/lifecycle/internal/str"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -30,11 +32,24 @@ type Rebaser struct {
 	Logger      log.Logger
 	PlatformAPI *api.Version
 	Force       bool
+	Events      event.Sink
 }
 
 // Rebase changes the underlying base image for an application image.
 func (r *Rebaser) Rebase(workingImage imgutil.Image, newBaseImage imgutil.Image, outputImageRef string, additionalNames []string) (files.RebaseReport, error) {
+	return r.RebaseContext(context.Background(), workingImage, newBaseImage, outputImageRef, additionalNames)
+}
+
+// RebaseContext is like Rebase, but returns early when the provided context is done.
+// The context is checked before the image is rebased and before it is saved, and stops retries of the save.
+// A save that is in progress is not interrupted, as imgutil does not accept a context.
+func (r *Rebaser) RebaseContext(ctx context.Context, workingImage imgutil.Image, newBaseImage imgutil.Image, outputImageRef string, additionalNames []string) (_ files.RebaseReport, err error) {
 	defer log.NewMeasurement("Rebaser", r.Logger)()
+	ctx, endPhase := startPhase(ctx, r.Events, "rebase")
+	defer func() { endPhase(err) }()
+	if err = ctx.Err(); err != nil {
+		return files.RebaseReport{}, err
+	}
 	appPlatformAPI, err := workingImage.Env(platform.EnvPlatformAPI)
 	if err != nil {
 		return files.RebaseReport{}, fmt.Errorf("failed to get app image platform API: %w", err)
@@ -60,6 +75,9 @@ func (r *Rebaser) Rebase(workingImage imgutil.Image, newBaseImage imgutil.Image,
 	}
 
 	// rebase
+	if err = ctx.Err(); err != nil {
+		return files.RebaseReport{}, err
+	}
 	if err = workingImage.Rebase(origMetadata.RunImage.TopLayer, newBaseImage); err != nil {
 		return files.RebaseReport{}, fmt.Errorf("rebase app image: %w", err)
 	}
@@ -128,8 +146,11 @@ func (r *Rebaser) Rebase(workingImage imgutil.Image, newBaseImage imgutil.Image,
 	}
 
 	// save
+	if err := ctx.Err(); err != nil {
+		return files.RebaseReport{}, err
+	}
 	report := files.RebaseReport{}
-	report.Image, err = saveImageAs(workingImage, outputImageRef, additionalNames, r.Logger)
+	report.Image, err = saveImageAs(ctx, workingImage, outputImageRef, additionalNames, r.Logger, r.Events)
 	if err != nil {
 		return files.RebaseReport{}, err
 	}
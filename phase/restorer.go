@@ -1,9 +1,11 @@
 package phase
 
 import (
+	"context"
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 
 	c "github.com/buildpacks/lifecycle/cache"
@@ -11,9 +13,11 @@ import (
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/internal/layer"
+	"github.com/buildpacks/lifecycle/internal/tracing"
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
@@ -26,12 +30,32 @@ type Restorer struct {
 	LayersMetadata        files.LayersMetadata
 	PlatformAPI           *api.Version
 	SBOMRestorer          layer.SBOMRestorer
+	Events                event.Sink
 }
 
 // Restore restores metadata for launch and cache layers into the layers directory and attempts to restore layer data for cache=true layers, removing the layer when unsuccessful.
 // If a usable cache is not provided, Restore will not restore any cache=true layer metadata.
 func (r *Restorer) Restore(cache Cache) error {
+	return r.RestoreContext(context.Background(), cache)
+}
+
+// RestoreContext is like Restore, but stops restoring layer data when the provided context is done.
+// Layers whose data was not fully restored are removed.
+// Reading from the cache is interrupted between reads; opening a layer is only interrupted if the cache implements ContextCache.
+// When stopped, the changes staged by a cache that implements AbortingCache (e.g., decompressed layers) are discarded.
+func (r *Restorer) RestoreContext(ctx context.Context, cache Cache) (err error) {
 	defer log.NewMeasurement("Restorer", r.Logger)()
+	ctx, endPhase := startPhase(ctx, r.Events, "restore")
+	defer func() { endPhase(err) }()
+	defer func() {
+		if err != nil {
+			abortCache(cache, r.Logger)
+		}
+	}()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	cache = withContext(ctx, cache)
 	cacheMeta, err := retrieveCacheMetadata(cache, r.Logger)
 	if err != nil {
 		return err
@@ -55,7 +79,7 @@ func (r *Restorer) Restore(cache Cache) error {
 		return err
 	}
 
-	var g errgroup.Group
+	g, gctx := errgroup.WithContext(ctx)
 	for _, bp := range r.Buildpacks {
 		cachedLayers := cacheMeta.MetadataForBuildpack(bp.ID).Layers
 
@@ -80,6 +104,7 @@ func (r *Restorer) Restore(cache Cache) error {
 			if !exists {
 				// This should be unreachable, as "find layers" uses the same cache metadata as the map
 				r.Logger.Infof("Removing %q, not in cache", bpLayer.Identifier())
+				event.Emit(r.Events, event.Event{Type: event.CacheMiss, Layer: bpLayer.Identifier()})
 				if err := bpLayer.Remove(); err != nil {
 					return errors.Wrapf(err, "removing layer")
 				}
@@ -93,6 +118,7 @@ func (r *Restorer) Restore(cache Cache) error {
 
 			if layerSha != cachedLayer.SHA {
 				r.Logger.Infof("Removing %q, wrong sha", bpLayer.Identifier())
+				event.Emit(r.Events, event.Event{Type: event.CacheMiss, Layer: bpLayer.Identifier(), Digest: cachedLayer.SHA})
 				r.Logger.Debugf("Layer sha: %q, cache sha: %q", layerSha, cachedLayer.SHA)
 				if err := bpLayer.Remove(); err != nil {
 					return errors.Wrapf(err, "removing layer")
@@ -100,7 +126,12 @@ func (r *Restorer) Restore(cache Cache) error {
 			} else {
 				r.Logger.Infof("Restoring data for %q from cache", bpLayer.Identifier())
 				g.Go(func() error {
-					err = r.restoreCacheLayer(cache, cachedLayer.SHA)
+					size, err := r.restoreCacheLayer(gctx, cache, cachedLayer.SHA)
+					if gctx.Err() != nil {
+						// don't leave partially restored data behind
+						_ = bpLayer.Remove()
+						return gctx.Err()
+					}
 					if err != nil {
 						isReadErr, readErr := c.IsReadErr(err)
 						if isReadErr {
@@ -109,6 +140,7 @@ func (r *Restorer) Restore(cache Cache) error {
 						}
 						return errors.Wrapf(err, "restoring layer %s", bpLayer.Identifier())
 					}
+					event.Emit(r.Events, event.Event{Type: event.CacheHit, Layer: bpLayer.Identifier(), Digest: cachedLayer.SHA, Size: size})
 					return nil
 				})
 			}
@@ -119,6 +151,9 @@ func (r *Restorer) Restore(cache Cache) error {
 		g.Go(func() error {
 			if cacheMeta.BOM.SHA != "" {
 				r.Logger.Infof("Restoring data for SBOM from cache")
+				if err := gctx.Err(); err != nil {
+					return err
+				}
 				if err := r.SBOMRestorer.RestoreFromCache(cache, cacheMeta.BOM.SHA); err != nil {
 					return err
 				}
@@ -134,22 +169,27 @@ func (r *Restorer) Restore(cache Cache) error {
 	return nil
 }
 
-func (r *Restorer) restoreCacheLayer(cache Cache, sha string) error {
+// restoreCacheLayer extracts the cached layer with the provided SHA, returning the number of bytes read from the cache.
+func (r *Restorer) restoreCacheLayer(ctx context.Context, cache Cache, sha string) (_ int64, err error) {
 	// Sanity check to prevent panic.
 	if cache == nil {
-		return errors.New("restoring layer: cache not provided")
+		return 0, errors.New("restoring layer: cache not provided")
 	}
 	r.Logger.Debugf("Retrieving data for %q", sha)
+	_, span := tracing.Start(ctx, "cache.RetrieveLayer", attribute.String("cnb.layer.digest", sha))
+	defer func() { tracing.End(span, err) }()
 	if err := cache.VerifyLayer(sha); err != nil {
-		return err
+		return 0, err
 	}
 	rc, err := cache.RetrieveLayer(sha)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer rc.Close()
 
-	return layers.Extract(rc, "")
+	cr := &contextReader{ctx: ctx, r: rc}
+	err = layers.Extract(cr, "")
+	return cr.n, err
 }
 
 func retrieveCacheMetadata(fromCache Cache, logger log.Logger) (platform.CacheMetadata, error) {
@@ -1,6 +1,7 @@
 package phase_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -104,6 +105,20 @@ func testRestorer(buildpackAPI, platformAPI string) func(t *testing.T, when spec
 					})
 				})
 
+				when("the context is done", func() {
+					it("does not restore data", func() {
+						h.AssertNil(t, writeLayer(layersDir, "buildpack.id", "cache-only", "[metadata]\n", ""))
+						ctx, cancel := context.WithCancel(context.Background())
+						cancel()
+
+						err := restorer.RestoreContext(ctx, testCache)
+						if !errors.Is(err, context.Canceled) {
+							t.Fatalf("Unexpected error:\n%s\n", err)
+						}
+						h.AssertPathDoesNotExist(t, filepath.Join(layersDir, "buildpack.id", "cache-only", "file-from-cache-only-layer"))
+					})
+				})
+
 				when("there is a cache=false layer", func() {
 					it.Before(func() {
 						var meta, sha string
@@ -1,6 +1,7 @@
 package phase
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
+	"github.com/buildpacks/lifecycle/image"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
 
@@ -32,27 +34,24 @@ func (s *stubImage) TopLayer() (string, error) {
 	return s.result.sha, s.result.err
 }
 
-// swapRetryTiming replaces the package-level retry timing vars with deterministic
-// values for testing. It returns a pointer to the recorded sleep durations.
+// swapRetryTiming replaces the registry retry policy with one that retries n times without delay
+// and records the delays it would have slept for. It returns a pointer to the recorded sleep durations.
 // Cleanup is handled via t.Cleanup.
 func swapRetryTiming(t *testing.T, n int) *[]time.Duration {
 	t.Helper()
 	var recorded []time.Duration
 
-	origDelays := topLayerDelays
-	origSleep := topLayerSleep
-
-	topLayerDelays = make([]time.Duration, n)
-	for i := range topLayerDelays {
-		topLayerDelays[i] = time.Duration(i+1) * time.Millisecond
-	}
-	topLayerSleep = func(d time.Duration) {
-		recorded = append(recorded, d)
+	origRetries := image.Retries
+	image.Retries = image.RetryPolicy{
+		MaxAttempts: n + 1,
+		Delay:       time.Millisecond,
+		Sleep: func(d time.Duration) {
+			recorded = append(recorded, d)
+		},
 	}
 
 	t.Cleanup(func() {
-		topLayerDelays = origDelays
-		topLayerSleep = origSleep
+		image.Retries = origRetries
 	})
 	return &recorded
 }
@@ -175,6 +174,24 @@ func testOpenRemoteImage(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("the context is done", func() {
+		it("stops retrying", func() {
+			sleeps := swapRetryTiming(t, 3)
+			ctx, cancel := context.WithCancel(context.Background())
+			var calls int
+
+			_, err := OpenRemoteImageContext(ctx, logger, func() (imgutil.Image, error) {
+				calls++
+				cancel()
+				return &stubImage{result: topLayerResult{err: &transport.Error{StatusCode: http.StatusInternalServerError}}}, nil
+			})
+
+			h.AssertEq(t, errors.Is(err, context.Canceled), true)
+			h.AssertEq(t, calls, 1)
+			h.AssertEq(t, len(*sleeps), 1)
+		})
+	})
+
 	when("factory returns error", func() {
 		it("retries when image creation fails", func() {
 			sleeps := swapRetryTiming(t, 3)
@@ -232,22 +249,27 @@ func testOpenRemoteImage(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, calls, 1)
 			h.AssertEq(t, len(*sleeps), 0)
 		})
+	})
 
-		it("does not retry on 429 TooManyRequests", func() {
+	when("throttled", func() {
+		it("retries on 429 TooManyRequests", func() {
 			sleeps := swapRetryTiming(t, 4)
 			var calls int
+			results := []topLayerResult{
+				{err: &transport.Error{StatusCode: http.StatusTooManyRequests}},
+				{err: &transport.Error{StatusCode: http.StatusServiceUnavailable}},
+				{sha: "sha-1"},
+			}
 
 			_, err := OpenRemoteImage(logger, func() (imgutil.Image, error) {
+				idx := calls
 				calls++
-				return &stubImage{result: topLayerResult{err: &transport.Error{StatusCode: http.StatusTooManyRequests}}}, nil
+				return &stubImage{result: results[idx]}, nil
 			})
 
-			h.AssertNotNil(t, err)
-			var tErr *transport.Error
-			h.AssertEq(t, errors.As(err, &tErr), true)
-			h.AssertEq(t, tErr.StatusCode, http.StatusTooManyRequests)
-			h.AssertEq(t, calls, 1)
-			h.AssertEq(t, len(*sleeps), 0)
+			h.AssertNil(t, err)
+			h.AssertEq(t, calls, 3)
+			h.AssertEq(t, *sleeps, []time.Duration{time.Millisecond, 2 * time.Millisecond})
 		})
 	})
 
@@ -284,7 +306,7 @@ func testOpenRemoteImage(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
-	when("zero retry delays configured", func() {
+	when("retries disabled", func() {
 		it("makes a single attempt and returns the error", func() {
 			sleeps := swapRetryTiming(t, 0)
 
@@ -297,38 +319,3 @@ func testOpenRemoteImage(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 }
-
-func TestIsRetryable(t *testing.T) {
-	spec.Run(t, "isRetryable", testIsRetryable, spec.Report(report.Terminal{}))
-}
-
-func testIsRetryable(t *testing.T, _ spec.G, it spec.S) {
-	it("returns true for non-transport errors", func() {
-		h.AssertEq(t, isRetryable(errors.New("generic error")), true)
-	})
-
-	it("returns true for retryable transport errors", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusInternalServerError}), true)
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusBadGateway}), true)
-	})
-
-	it("returns false for 400 BadRequest", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusBadRequest}), false)
-	})
-
-	it("returns false for 401 Unauthorized", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusUnauthorized}), false)
-	})
-
-	it("returns false for 403 Forbidden", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusForbidden}), false)
-	})
-
-	it("returns false for 405 MethodNotAllowed", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusMethodNotAllowed}), false)
-	})
-
-	it("returns false for 429 TooManyRequests", func() {
-		h.AssertEq(t, isRetryable(&transport.Error{StatusCode: http.StatusTooManyRequests}), false)
-	})
-}
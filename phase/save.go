@@ -1,27 +1,42 @@
 package phase
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/local"
 	"github.com/buildpacks/imgutil/remote"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/buildpacks/lifecycle/internal/tracing"
 	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform/event"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
 
-func saveImage(image imgutil.Image, additionalNames []string, logger log.Logger) (files.ImageReport, error) {
-	return saveImageAs(image, image.Name(), additionalNames, logger)
+func saveImage(ctx context.Context, image imgutil.Image, additionalNames []string, logger log.Logger, events event.Sink) (files.ImageReport, error) {
+	return saveImageAs(ctx, image, image.Name(), additionalNames, logger, events)
 }
 
-func saveImageAs(image imgutil.Image, name string, additionalNames []string, logger log.Logger) (files.ImageReport, error) {
+func saveImageAs(ctx context.Context, image imgutil.Image, name string, additionalNames []string, logger log.Logger, events event.Sink) (_ files.ImageReport, err error) {
 	defer log.NewMeasurement("Saving "+name+"...", logger)()
+	_, span := tracing.Start(ctx, "saveImage",
+		attribute.String("cnb.image.name", name),
+		attribute.StringSlice("cnb.image.tags", additionalNames),
+	)
+	defer func() { tracing.End(span, err) }()
 	var saveErr error
 	imageReport := files.ImageReport{}
 	logger.Infof("Saving %s...\n", name)
-	if err := image.SaveAs(name, additionalNames...); err != nil {
+	saveAs := func() error { return image.SaveAs(name, additionalNames...) }
+	save := saveAs
+	if _, ok := image.(*remote.Image); ok {
+		// images saved to a daemon or OCI layout are not retried
+		save = func() error { return retryRegistry(ctx, logger, "save image "+name, saveAs) }
+	}
+	if err := save(); err != nil {
 		var ok bool
 		if saveErr, ok = err.(imgutil.SaveError); !ok {
 			return files.ImageReport{}, errors.Wrap(err, "saving image")
@@ -38,12 +53,15 @@ func saveImageAs(image imgutil.Image, name string, additionalNames []string, log
 
 	logger.Infof("*** Images (%s):\n", shortID(id))
 	for _, n := range append([]string{name}, additionalNames...) {
+		saved := event.Event{Type: event.ImageSaved, Tag: n, Digest: imageDigest(id)}
 		if ok, message := getSaveStatus(saveErr, n); !ok {
 			logger.Infof("      %s - %s\n", n, message)
+			saved.Error = message
 		} else {
 			logger.Infof("      %s\n", n)
 			imageReport.Tags = append(imageReport.Tags, n)
 		}
+		event.Emit(events, saved)
 	}
 	switch v := id.(type) {
 	case local.IDIdentifier:
@@ -75,6 +93,14 @@ func (me *MultiError) Error() string {
 	return fmt.Sprintf("failed with multiple errors %+v", me.Errors)
 }
 
+// imageDigest returns the manifest digest of an image saved to a registry, or else the image identifier.
+func imageDigest(identifier imgutil.Identifier) string {
+	if v, ok := identifier.(remote.DigestIdentifier); ok {
+		return v.Digest.DigestStr()
+	}
+	return identifier.String()
+}
+
 func shortID(identifier imgutil.Identifier) string {
 	switch v := identifier.(type) {
 	case local.IDIdentifier:
@@ -0,0 +1,143 @@
+package phase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+const (
+	CheckInputs         = "inputs"
+	CheckRegistryAccess = "registry-access"
+	CheckOrder          = "order"
+	CheckGroup          = "group"
+	CheckSystem         = "system"
+	CheckBuildpackAPI   = "buildpack-api"
+	CheckOwnership      = "ownership"
+)
+
+// ValidationProblem is a problem with the inputs for a phase that would cause the phase to fail.
+type ValidationProblem struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// ValidationReport lists the problems found by `lifecycle validate`.
+type ValidationReport struct {
+	Phase    string              `json:"phase"`
+	Problems []ValidationProblem `json:"problems"`
+}
+
+// Add adds a problem for each non-nil error.
+func (r *ValidationReport) Add(check string, errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			r.Problems = append(r.Problems, ValidationProblem{Check: check, Message: err.Error()})
+		}
+	}
+}
+
+// Validator checks, before a phase is run, that the resources the phase needs are available:
+// registry access for the images it reads and writes, and the buildpacks and image extensions in the order or group.
+// Unlike the phases, it does not stop at the first problem.
+type Validator struct {
+	PlatformAPI     *api.Version
+	APIVerifier     BuildpackAPIVerifier
+	ConfigHandler   ConfigHandler
+	DirStore        DirStore
+	RegistryHandler image.RegistryHandler
+	Logger          log.Logger
+}
+
+// Validate adds the problems found for the provided phase to the provided report.
+// The inputs must already be resolved for the phase.
+func (v *Validator) Validate(targetPhase platform.LifecyclePhase, inputs platform.LifecycleInputs, report *ValidationReport) {
+	switch targetPhase {
+	case platform.Analyze, platform.Create:
+		v.validateRegistryAccess(inputs, report, inputs.PreviousImageRef, inputs.RunImageRef)
+	case platform.Export, platform.Rebase:
+		v.validateRegistryAccess(inputs, report, inputs.RunImageRef)
+	case platform.Restore:
+		report.Add(CheckRegistryAccess, v.RegistryHandler.EnsureReadAccess(inputs.CacheImageRef))
+	}
+	switch targetPhase {
+	case platform.Detect, platform.Create:
+		v.validateOrder(inputs, report)
+	case platform.Build, platform.Export, platform.Extend, platform.Restore:
+		v.validateGroup(inputs, report)
+	}
+}
+
+// validateRegistryAccess checks that the provided images can be read and that the output images and cache image can be written.
+func (v *Validator) validateRegistryAccess(inputs platform.LifecycleInputs, report *ValidationReport, readImages ...string) {
+	var writeImages []string
+	if inputs.CacheImageRef != "" {
+		writeImages = append(writeImages, inputs.CacheImageRef)
+	}
+	if !inputs.UseDaemon && !inputs.UseLayout {
+		writeImages = append(writeImages, inputs.DestinationImages()...)
+		for _, imageRef := range readImages {
+			report.Add(CheckRegistryAccess, v.RegistryHandler.EnsureReadAccess(imageRef))
+		}
+	}
+	for _, imageRef := range writeImages {
+		report.Add(CheckRegistryAccess, v.RegistryHandler.EnsureWriteAccess(imageRef))
+	}
+}
+
+func (v *Validator) validateOrder(inputs platform.LifecycleInputs, report *ValidationReport) {
+	orderBp, orderExt, err := v.ConfigHandler.ReadOrder(inputs.OrderPath)
+	if err != nil {
+		report.Add(CheckOrder, fmt.Errorf("reading order: %w", err))
+		return
+	}
+	if v.PlatformAPI.AtLeast("0.15") {
+		system, err := v.ConfigHandler.ReadSystem(inputs.SystemPath, v.Logger)
+		if err != nil {
+			report.Add(CheckSystem, fmt.Errorf("reading system: %w", err))
+		}
+		for _, systemBp := range append(system.Pre.Buildpacks, system.Post.Buildpacks...) {
+			v.validateModule(CheckSystem, buildpack.GroupElement{ID: systemBp.ID, Version: systemBp.Version}, report)
+		}
+	}
+	acyclic := map[string]bool{}
+	for _, group := range append(orderBp, orderExt...) {
+		for _, groupEl := range group.Group {
+			module := v.validateModule(CheckOrder, groupEl, report)
+			bpDescriptor, ok := module.(*buildpack.BpDescriptor)
+			if !ok || groupEl.Kind() != buildpack.KindBuildpack || len(bpDescriptor.Order) == 0 {
+				continue
+			}
+			groupEl.Version = resolvedVersion(groupEl.Version, bpDescriptor.Buildpack.Version)
+			report.Add(CheckOrder, verifyAcyclicOrder(v.DirStore, bpDescriptor.Order, []buildpack.GroupElement{groupEl}, acyclic))
+		}
+	}
+}
+
+func (v *Validator) validateGroup(inputs platform.LifecycleInputs, report *ValidationReport) {
+	group, err := v.ConfigHandler.ReadGroup(inputs.GroupPath)
+	if err != nil {
+		report.Add(CheckGroup, fmt.Errorf("reading group: %w", err))
+		return
+	}
+	for _, groupEl := range append(group.Group, group.GroupExtensions...) {
+		v.validateModule(CheckGroup, groupEl, report)
+	}
+}
+
+// validateModule checks that the provided buildpack or image extension is installed with a supported Buildpack API,
+// and returns its descriptor if it is installed.
+func (v *Validator) validateModule(check string, groupEl buildpack.GroupElement, report *ValidationReport) buildpack.Descriptor {
+	module, err := v.DirStore.Lookup(groupEl.Kind(), groupEl.ID, groupEl.Version)
+	if err != nil {
+		report.Add(check, fmt.Errorf("%s %s is not installed: %w", strings.ToLower(groupEl.Kind()), groupEl.String(), err))
+		return nil
+	}
+	report.Add(CheckBuildpackAPI, v.APIVerifier.VerifyBuildpackAPI(groupEl.Kind(), groupEl.String(), module.API(), v.Logger))
+	return module
+}
@@ -0,0 +1,166 @@
+package phase_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/internal/str"
+	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/phase"
+	"github.com/buildpacks/lifecycle/phase/testmock"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestValidator(t *testing.T) {
+	spec.Run(t, "Validator", testValidator, spec.Report(report.Terminal{}))
+}
+
+func testValidator(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockController  *gomock.Controller
+		apiVerifier     *testmock.MockBuildpackAPIVerifier
+		configHandler   *testmock.MockConfigHandler
+		dirStore        *testmock.MockDirStore
+		registryHandler *testmock.MockRegistryHandler
+		logger          log.Logger
+
+		validator *phase.Validator
+		report    *phase.ValidationReport
+	)
+
+	it.Before(func() {
+		mockController = gomock.NewController(t)
+		apiVerifier = testmock.NewMockBuildpackAPIVerifier(mockController)
+		configHandler = testmock.NewMockConfigHandler(mockController)
+		dirStore = testmock.NewMockDirStore(mockController)
+		registryHandler = testmock.NewMockRegistryHandler(mockController)
+		logger = log.NewDefaultLogger(io.Discard)
+
+		validator = &phase.Validator{
+			PlatformAPI:     api.Platform.Latest(),
+			APIVerifier:     apiVerifier,
+			ConfigHandler:   configHandler,
+			DirStore:        dirStore,
+			RegistryHandler: registryHandler,
+			Logger:          logger,
+		}
+		report = &phase.ValidationReport{}
+	})
+
+	it.After(func() {
+		mockController.Finish()
+	})
+
+	when("#Validate", func() {
+		when("analyze", func() {
+			it("reports every image that cannot be accessed", func() {
+				inputs := platform.LifecycleInputs{
+					OutputImageRef:   "some-output-image",
+					AdditionalTags:   str.Slice{"some-tag"},
+					PreviousImageRef: "some-previous-image",
+					RunImageRef:      "some-run-image",
+					CacheImageRef:    "some-cache-image",
+				}
+				registryHandler.EXPECT().EnsureReadAccess("some-previous-image").Return(errors.New("some-read-error"))
+				registryHandler.EXPECT().EnsureReadAccess("some-run-image").Return(nil)
+				registryHandler.EXPECT().EnsureWriteAccess("some-cache-image").Return(nil)
+				registryHandler.EXPECT().EnsureWriteAccess("some-output-image").Return(errors.New("some-write-error"))
+				registryHandler.EXPECT().EnsureWriteAccess("some-tag").Return(errors.New("other-write-error"))
+
+				validator.Validate(platform.Analyze, inputs, report)
+
+				h.AssertEq(t, report.Problems, []phase.ValidationProblem{
+					{Check: phase.CheckRegistryAccess, Message: "some-read-error"},
+					{Check: phase.CheckRegistryAccess, Message: "some-write-error"},
+					{Check: phase.CheckRegistryAccess, Message: "other-write-error"},
+				})
+			})
+
+			when("using a daemon", func() {
+				it("only checks the cache image", func() {
+					inputs := platform.LifecycleInputs{
+						OutputImageRef: "some-output-image",
+						RunImageRef:    "some-run-image",
+						CacheImageRef:  "some-cache-image",
+						UseDaemon:      true,
+					}
+					registryHandler.EXPECT().EnsureWriteAccess("some-cache-image").Return(nil)
+
+					validator.Validate(platform.Analyze, inputs, report)
+
+					h.AssertEq(t, len(report.Problems), 0)
+				})
+			})
+		})
+
+		when("detect", func() {
+			var inputs platform.LifecycleInputs
+
+			it.Before(func() {
+				inputs = platform.LifecycleInputs{OrderPath: "some-order-path", SystemPath: "some-system-path"}
+			})
+
+			it("reports every buildpack that is missing or has an unsupported api", func() {
+				configHandler.EXPECT().ReadOrder("some-order-path").Return(buildpack.Order{
+					{Group: []buildpack.GroupElement{{ID: "A", Version: "v1"}, {ID: "B", Version: "v1"}}},
+					{Group: []buildpack.GroupElement{{ID: "C", Version: "v1"}}},
+				}, nil, nil)
+				configHandler.EXPECT().ReadSystem("some-system-path", logger).Return(files.System{
+					Pre: files.SystemBuildpacks{Buildpacks: []files.SystemBuildpack{{ID: "S", Version: "v1"}}},
+				}, nil)
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "S", "v1").Return(nil, errors.New("some-lookup-error"))
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "A", "v1").Return(&buildpack.BpDescriptor{WithAPI: "0.2"}, nil)
+				apiVerifier.EXPECT().VerifyBuildpackAPI(buildpack.KindBuildpack, "A@v1", "0.2", logger).Return(errors.New("some-api-error"))
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "B", "v1").Return(nil, errors.New("other-lookup-error"))
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "C", "v1").Return(&buildpack.BpDescriptor{WithAPI: "0.10"}, nil)
+				apiVerifier.EXPECT().VerifyBuildpackAPI(buildpack.KindBuildpack, "C@v1", "0.10", logger).Return(nil)
+
+				validator.Validate(platform.Detect, inputs, report)
+
+				h.AssertEq(t, report.Problems, []phase.ValidationProblem{
+					{Check: phase.CheckSystem, Message: "buildpack S@v1 is not installed: some-lookup-error"},
+					{Check: phase.CheckBuildpackAPI, Message: "some-api-error"},
+					{Check: phase.CheckOrder, Message: "buildpack B@v1 is not installed: other-lookup-error"},
+				})
+			})
+
+			it("reports an order that cannot be read", func() {
+				configHandler.EXPECT().ReadOrder("some-order-path").Return(nil, nil, errors.New("some-read-error"))
+
+				validator.Validate(platform.Detect, inputs, report)
+
+				h.AssertEq(t, report.Problems, []phase.ValidationProblem{
+					{Check: phase.CheckOrder, Message: "reading order: some-read-error"},
+				})
+			})
+		})
+
+		when("build", func() {
+			it("reports every module in the group that is missing", func() {
+				inputs := platform.LifecycleInputs{GroupPath: "some-group-path"}
+				configHandler.EXPECT().ReadGroup("some-group-path").Return(buildpack.Group{
+					Group:           []buildpack.GroupElement{{ID: "A", Version: "v1", API: "0.10"}},
+					GroupExtensions: []buildpack.GroupElement{{ID: "X", Version: "v1", API: "0.10", Extension: true}},
+				}, nil)
+				dirStore.EXPECT().Lookup(buildpack.KindBuildpack, "A", "v1").Return(&buildpack.BpDescriptor{WithAPI: "0.10"}, nil)
+				apiVerifier.EXPECT().VerifyBuildpackAPI(buildpack.KindBuildpack, "A@v1", "0.10", logger).Return(nil)
+				dirStore.EXPECT().Lookup(buildpack.KindExtension, "X", "v1").Return(nil, errors.New("some-lookup-error"))
+
+				validator.Validate(platform.Build, inputs, report)
+
+				h.AssertEq(t, report.Problems, []phase.ValidationProblem{
+					{Check: phase.CheckGroup, Message: "extension X@v1 is not installed: some-lookup-error"},
+				})
+			})
+		})
+	})
+}
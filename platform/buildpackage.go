@@ -0,0 +1,434 @@
+package platform
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/buildpacks/lifecycle/archive"
+)
+
+const (
+	// BuildpackLayersLabel is the label on a buildpackage image describing the layer containing each buildpack.
+	BuildpackLayersLabel = "io.buildpacks.buildpack.layers"
+	// ExtensionLayersLabel is the label on a buildpackage image describing the layer containing each image extension.
+	ExtensionLayersLabel = "io.buildpacks.extension.layers"
+)
+
+// moduleLayers is the value of BuildpackLayersLabel or ExtensionLayersLabel, by ID and then by version.
+type moduleLayers map[string]map[string]struct {
+	LayerDiffID string `json:"layerDiffID"`
+}
+
+// packagedModule is a buildpack or image extension contained in a layer of a buildpackage.
+type packagedModule struct {
+	source string
+	image  v1.Image
+	diffID v1.Hash
+}
+
+// packageIndex records the buildpacks and image extensions contained in the buildpackages in a directory.
+type packageIndex struct {
+	modules map[string]map[string]packagedModule // by kind and ID, then by version
+}
+
+func (i *packageIndex) versions(kind, id string) []string {
+	var versions []string
+	for version := range i.modules[kind+" "+id] {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func (i *packageIndex) find(kind, id, version string) (packagedModule, bool) {
+	module, ok := i.modules[kind+" "+id][version]
+	return module, ok
+}
+
+// indexFor returns the index of the buildpackages in the provided directory, reading them the first time it is called.
+// A buildpackage is either an OCI image layout directory or a `.cnb` file (a tarball of an OCI image layout).
+// Only the index, manifest, and config of each buildpackage are read; layers are unpacked when they are needed.
+func (s *DirStore) indexFor(dir string) (*packageIndex, error) {
+	if index, ok := s.packages[dir]; ok {
+		return index, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read directory '%s': %w", dir, err)
+	}
+	index := &packageIndex{modules: map[string]map[string]packagedModule{}}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		var image v1.Image
+		switch {
+		case entry.IsDir():
+			if _, err := os.Stat(filepath.Join(path, "oci-layout")); err != nil {
+				continue
+			}
+			image, err = layoutImage(path)
+		case strings.HasSuffix(entry.Name(), ".cnb"):
+			image, err = archiveImage(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read buildpackage '%s': %w", path, err)
+		}
+		if err = index.add(path, image); err != nil {
+			return nil, err
+		}
+	}
+	if s.packages == nil {
+		s.packages = map[string]*packageIndex{}
+	}
+	s.packages[dir] = index
+	return index, nil
+}
+
+// add records the buildpacks and image extensions in the provided buildpackage image.
+func (i *packageIndex) add(source string, image v1.Image) error {
+	config, err := image.ConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to read buildpackage '%s': %w", source, err)
+	}
+	for kind, label := range map[string]string{"buildpack": BuildpackLayersLabel, "extension": ExtensionLayersLabel} {
+		contents, ok := config.Config.Labels[label]
+		if !ok {
+			continue
+		}
+		var layers moduleLayers
+		if err = json.Unmarshal([]byte(contents), &layers); err != nil {
+			return fmt.Errorf("failed to parse label '%s' of buildpackage '%s': %w", label, source, err)
+		}
+		for id, versions := range layers {
+			for version, layer := range versions {
+				diffID, err := v1.NewHash(layer.LayerDiffID)
+				if err != nil {
+					return fmt.Errorf("invalid layer for %s '%s@%s' in buildpackage '%s': %w", kind, id, version, source, err)
+				}
+				key := kind + " " + id
+				if existing, ok := i.modules[key][version]; ok && existing.diffID != diffID {
+					return fmt.Errorf("%s '%s@%s' is provided by both '%s' and '%s'", kind, id, version, existing.source, source)
+				}
+				if i.modules[key] == nil {
+					i.modules[key] = map[string]packagedModule{}
+				}
+				i.modules[key][version] = packagedModule{source: source, image: image, diffID: diffID}
+			}
+		}
+	}
+	return nil
+}
+
+// layoutImage returns the image in the OCI image layout at the provided path, which must contain exactly one image.
+func layoutImage(path string) (v1.Image, error) {
+	index, err := layout.ImageIndexFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) != 1 {
+		return nil, fmt.Errorf("expected 1 image in OCI layout, found %d", len(manifest.Manifests))
+	}
+	return index.Image(manifest.Manifests[0].Digest)
+}
+
+// archiveImage returns the image in the OCI image layout in the `.cnb` file at the provided path,
+// which must contain exactly one image. Blobs are read from the file when they are needed, rather than extracted.
+func archiveImage(path string) (v1.Image, error) {
+	rawIndex, err := readArchiveFile(path, "index.json")
+	if err != nil {
+		return nil, err
+	}
+	index, err := v1.ParseIndexManifest(bytes.NewReader(rawIndex))
+	if err != nil {
+		return nil, err
+	}
+	if len(index.Manifests) != 1 {
+		return nil, fmt.Errorf("expected 1 image in OCI layout, found %d", len(index.Manifests))
+	}
+	image := &archiveImageCore{path: path, mediaType: index.Manifests[0].MediaType}
+	if image.rawManifest, err = readArchiveBlob(path, index.Manifests[0].Digest); err != nil {
+		return nil, err
+	}
+	if image.manifest, err = v1.ParseManifest(bytes.NewReader(image.rawManifest)); err != nil {
+		return nil, err
+	}
+	if image.rawConfig, err = readArchiveBlob(path, image.manifest.Config.Digest); err != nil {
+		return nil, err
+	}
+	return partial.CompressedToImage(image)
+}
+
+// archiveImageCore is an image in the OCI image layout in a `.cnb` file.
+type archiveImageCore struct {
+	path        string
+	mediaType   types.MediaType
+	manifest    *v1.Manifest
+	rawManifest []byte
+	rawConfig   []byte
+}
+
+func (i *archiveImageCore) RawConfigFile() ([]byte, error) {
+	return i.rawConfig, nil
+}
+
+func (i *archiveImageCore) MediaType() (types.MediaType, error) {
+	return i.mediaType, nil
+}
+
+func (i *archiveImageCore) RawManifest() ([]byte, error) {
+	return i.rawManifest, nil
+}
+
+func (i *archiveImageCore) LayerByDigest(digest v1.Hash) (partial.CompressedLayer, error) {
+	for _, desc := range i.manifest.Layers {
+		if desc.Digest == digest {
+			return &archiveBlob{path: i.path, desc: desc}, nil
+		}
+	}
+	return nil, fmt.Errorf("layer '%s' not found in manifest", digest)
+}
+
+// archiveBlob is a layer in the OCI image layout in a `.cnb` file.
+type archiveBlob struct {
+	path string
+	desc v1.Descriptor
+}
+
+func (b *archiveBlob) Digest() (v1.Hash, error) {
+	return b.desc.Digest, nil
+}
+
+func (b *archiveBlob) Compressed() (io.ReadCloser, error) {
+	return openArchiveFile(b.path, blobPath(b.desc.Digest))
+}
+
+func (b *archiveBlob) Size() (int64, error) {
+	return b.desc.Size, nil
+}
+
+func (b *archiveBlob) MediaType() (types.MediaType, error) {
+	return b.desc.MediaType, nil
+}
+
+func blobPath(digest v1.Hash) string {
+	return "blobs/" + digest.Algorithm + "/" + digest.Hex
+}
+
+// readArchiveBlob reads the blob with the provided digest from the OCI image layout in the `.cnb` file at the provided path,
+// verifying its digest.
+func readArchiveBlob(path string, digest v1.Hash) ([]byte, error) {
+	contents, err := readArchiveFile(path, blobPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	actual, _, err := v1.SHA256(bytes.NewReader(contents))
+	if err != nil {
+		return nil, err
+	}
+	if actual != digest {
+		return nil, fmt.Errorf("digest mismatch: expected '%s', found '%s'", digest, actual)
+	}
+	return contents, nil
+}
+
+func readArchiveFile(path, name string) ([]byte, error) {
+	rc, err := openArchiveFile(path, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// openArchiveFile returns the contents of the file with the provided name in the tarball at the provided path.
+func openArchiveFile(path, name string) (io.ReadCloser, error) {
+	f, err := os.Open(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			_ = f.Close()
+			return nil, fmt.Errorf("'%s' not found", name)
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && strings.TrimPrefix(hdr.Name, "./") == name {
+			return struct {
+				io.Reader
+				io.Closer
+			}{tr, f}, nil
+		}
+	}
+}
+
+// scratchDir returns the directory that buildpackages are unpacked into, creating it if needed.
+func (s *DirStore) scratchDir() (string, error) {
+	if s.scratch != "" {
+		return s.scratch, nil
+	}
+	dir, err := os.MkdirTemp("", "lifecycle.buildpackages")
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory for buildpackages: %w", err)
+	}
+	s.scratch = dir
+	return dir, nil
+}
+
+// unpack extracts the layer containing the provided module, returning the directory it was extracted to.
+// The layer is extracted into a temporary directory, which is only moved into place once the digests of the layer
+// are verified against the buildpackage image manifest and config.
+func (s *DirStore) unpack(module packagedModule) (string, error) {
+	scratch, err := s.scratchDir()
+	if err != nil {
+		return "", err
+	}
+	layersDir := filepath.Join(scratch, "layers")
+	layerDir := filepath.Join(layersDir, module.diffID.Hex)
+	if _, err = os.Stat(layerDir); err == nil {
+		return layerDir, nil
+	}
+	if err = os.MkdirAll(layersDir, 0755); err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(layersDir, module.diffID.Hex+".")
+	if err != nil {
+		return "", err
+	}
+	if err = extractLayer(module, tmpDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", fmt.Errorf("failed to unpack layer '%s' of buildpackage '%s': %w", module.diffID, module.source, err)
+	}
+	if err = os.Rename(tmpDir, layerDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	return layerDir, nil
+}
+
+// Close removes the directory that buildpackages were unpacked into.
+// Descriptors of modules contained in buildpackages must not be used after the store is closed.
+func (s *DirStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scratch == "" {
+		return nil
+	}
+	if err := os.RemoveAll(s.scratch); err != nil {
+		return fmt.Errorf("failed to remove directory for buildpackages: %w", err)
+	}
+	s.scratch = ""
+	return nil
+}
+
+func extractLayer(module packagedModule, layerDir string) error {
+	layer, err := module.image.LayerByDiffID(module.diffID)
+	if err != nil {
+		return err
+	}
+	digest, err := layer.Digest()
+	if err != nil {
+		return err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	compressedHash := sha256.New()
+	compressed := bufio.NewReader(io.TeeReader(rc, compressedHash))
+	var uncompressed io.Reader = compressed
+	if magic, _ := compressed.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		if uncompressed, err = gzip.NewReader(compressed); err != nil {
+			return err
+		}
+	}
+	uncompressedHash := sha256.New()
+	tr := tar.NewReader(io.TeeReader(uncompressed, uncompressedHash))
+	if err = archive.Extract(&scopedTarReader{Reader: tr, dir: layerDir}); err != nil {
+		return err
+	}
+	// read any padding after the end of the archive, so that it is included in the digests
+	if _, err = io.Copy(io.Discard, uncompressed); err != nil {
+		return err
+	}
+	if _, err = io.Copy(io.Discard, compressed); err != nil {
+		return err
+	}
+	if actual := "sha256:" + hex.EncodeToString(compressedHash.Sum(nil)); actual != digest.String() {
+		return fmt.Errorf("digest mismatch: expected '%s', found '%s'", digest, actual)
+	}
+	if actual := "sha256:" + hex.EncodeToString(uncompressedHash.Sum(nil)); actual != module.diffID.String() {
+		return fmt.Errorf("diff ID mismatch: expected '%s', found '%s'", module.diffID, actual)
+	}
+	return nil
+}
+
+// scopedTarReader extracts each entry of an archive relative to dir, rejecting entries that would be extracted outside of dir:
+// entries with paths outside of dir, links with targets outside of dir, and entries whose path is (or is inside) an existing symlink.
+type scopedTarReader struct {
+	*tar.Reader
+	dir string
+}
+
+func (r *scopedTarReader) Next() (*tar.Header, error) {
+	hdr, err := r.Reader.Next()
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Join(r.dir, filepath.FromSlash(hdr.Name)) // #nosec G305 -- checked below
+	if !r.contains(name) {
+		return nil, fmt.Errorf("invalid path in archive: '%s'", hdr.Name)
+	}
+	switch hdr.Typeflag {
+	case tar.TypeSymlink, tar.TypeLink:
+		target := filepath.FromSlash(hdr.Linkname)
+		if strings.HasPrefix(hdr.Linkname, "/") || filepath.IsAbs(target) {
+			return nil, fmt.Errorf("invalid link in archive: '%s' -> '%s'", hdr.Name, hdr.Linkname)
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			// symlink targets are relative to the symlink, while hard link targets are relative to the root of the archive
+			target = filepath.Join(filepath.Dir(name), target)
+		} else {
+			target = filepath.Join(r.dir, target)
+		}
+		if !r.contains(target) {
+			return nil, fmt.Errorf("invalid link in archive: '%s' -> '%s'", hdr.Name, hdr.Linkname)
+		}
+	}
+	for path := name; path != r.dir; path = filepath.Dir(path) {
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("invalid path in archive: '%s' is inside symlink '%s'", hdr.Name, path)
+		}
+	}
+	hdr.Name = name
+	return hdr, nil
+}
+
+// contains returns true if the provided path is dir or is inside of dir.
+func (r *scopedTarReader) contains(path string) bool {
+	return path == r.dir || strings.HasPrefix(path, r.dir+string(filepath.Separator))
+}
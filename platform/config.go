@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ApplyConfigFile sets the inputs provided in the lifecycle config file at the provided path, a TOML file such as:
+//
+//	layers-dir = "/layers"
+//	run-image = "some.registry/some-run-image"
+//	registry-retries = 3
+//	insecure-registries = ["some.insecure.registry"]
+//
+// Each key is the name of the environment variable for the input without the `CNB_` prefix, in lowercase with dashes
+// (e.g., `analyzed-path` for `CNB_ANALYZED_PATH`); inputs that are only provided through arguments and flags are provided as
+// `output-image` and `tags` (the output image and its additional tags), and `launcher-path` and `launcher-sbom-dir`.
+// Some inputs cannot be provided in the file (see excludedInputs).
+// An input in the file is ignored if its environment variable is set,
+// and command-line flags (which are parsed afterwards) take precedence over both.
+func (i *LifecycleInputs) ApplyConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var fromFile LifecycleInputs
+	md, err := toml.Decode(string(data), &fromFile)
+	if err != nil {
+		return fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		if reason, ok := excludedInputs[undecoded[0].String()]; ok {
+			return fmt.Errorf("invalid config file '%s': line %d: input %q cannot be provided in the config file: %s", path, lineOf(string(data), undecoded[0]), undecoded[0].String(), reason)
+		}
+		return fmt.Errorf("invalid config file '%s': line %d: unknown input %q", path, lineOf(string(data), undecoded[0]), undecoded[0].String())
+	}
+
+	inputsValue, fileValue := reflect.ValueOf(i).Elem(), reflect.ValueOf(fromFile)
+	for idx := 0; idx < fileValue.NumField(); idx++ {
+		key := fileValue.Type().Field(idx).Tag.Get("toml")
+		if key == "-" || !md.IsDefined(key) || envVarSetFor(key) {
+			continue
+		}
+		inputsValue.Field(idx).Set(fileValue.Field(idx))
+	}
+	i.ConfigPath = path
+	return nil
+}
+
+// excludedInputs are the keys of the inputs that cannot be provided in the lifecycle config file, with the reason for each.
+var excludedInputs = map[string]string{
+	"config-path":  "the config file is provided with " + EnvConfigPath,
+	"image":        "the deprecated `-image` flag has no equivalent in the config file; provide `run-image` instead",
+	"kaniko-dir":   "the kaniko directory is not configurable",
+	"platform-api": "the Platform API is provided with " + EnvPlatformAPI,
+}
+
+// envVarSetFor returns true if an environment variable for the input with the provided key in the lifecycle config file is set.
+func envVarSetFor(key string) bool {
+	var envVars []string
+	switch key {
+	case "output-image", "tags", "launcher-path", "launcher-sbom-dir":
+		// only provided through arguments and flags
+	case "skip-layers":
+		envVars = []string{EnvSkipLayers, EnvSkipRestore}
+	case "traceparent":
+		envVars = []string{EnvTraceparent}
+	default:
+		envVars = []string{"CNB_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))}
+	}
+	for _, envVar := range envVars {
+		if os.Getenv(envVar) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOf returns the line of the provided TOML document on which the provided key is defined, or 0 if it cannot be found.
+func lineOf(data string, key toml.Key) int {
+	name := key[len(key)-1]
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "["+key.String()+"]" || line == "[["+key.String()+"]]" {
+			return n + 1
+		}
+		for _, quoted := range []string{name, `"` + name + `"`} {
+			if rest, ok := strings.CutPrefix(line, quoted); ok && strings.HasPrefix(strings.TrimSpace(rest), "=") {
+				return n + 1
+			}
+		}
+	}
+	return 0
+}
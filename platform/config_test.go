@@ -0,0 +1,147 @@
+package platform_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/internal/str"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestConfigFile(t *testing.T) {
+	spec.Run(t, "ConfigFile", testConfigFile, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testConfigFile(t *testing.T, when spec.G, it spec.S) {
+	var (
+		configPath string
+		inputs     *platform.LifecycleInputs
+	)
+
+	writeConfig := func(contents string) {
+		h.AssertNil(t, os.WriteFile(configPath, []byte(contents), 0600))
+	}
+
+	it.Before(func() {
+		configPath = filepath.Join(t.TempDir(), "lifecycle.toml")
+		inputs = platform.NewLifecycleInputs(api.Platform.Latest())
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.Unsetenv(platform.EnvRunImage))
+		h.AssertNil(t, os.Unsetenv(platform.EnvSkipRestore))
+	})
+
+	when("#ApplyConfigFile", func() {
+		it("sets the inputs in the file", func() {
+			writeConfig(`
+layers-dir = "/some/layers"
+run-image = "some-run-image"
+output-image = "some-output-image"
+tags = ["some-tag", "other-tag"]
+insecure-registries = ["some-insecure-registry"]
+registry-retries = 3
+kaniko-cache-ttl = "1h"
+use-daemon = true
+launcher-path = "/some/launcher"
+launcher-sbom-dir = "/some/launcher-sbom"
+`)
+
+			h.AssertNil(t, inputs.ApplyConfigFile(configPath))
+
+			h.AssertEq(t, inputs.LayersDir, "/some/layers")
+			h.AssertEq(t, inputs.RunImageRef, "some-run-image")
+			h.AssertEq(t, inputs.OutputImageRef, "some-output-image")
+			h.AssertEq(t, inputs.AdditionalTags, str.Slice{"some-tag", "other-tag"})
+			h.AssertEq(t, inputs.InsecureRegistries, str.Slice{"some-insecure-registry"})
+			h.AssertEq(t, inputs.RegistryRetries, 3)
+			h.AssertEq(t, inputs.KanikoCacheTTL, time.Hour)
+			h.AssertEq(t, inputs.UseDaemon, true)
+			h.AssertEq(t, inputs.LauncherPath, "/some/launcher")
+			h.AssertEq(t, inputs.LauncherSBOMDir, "/some/launcher-sbom")
+			h.AssertEq(t, inputs.ConfigPath, configPath)
+		})
+
+		it("keeps the default values of inputs not in the file", func() {
+			writeConfig(`run-image = "some-run-image"`)
+
+			h.AssertNil(t, inputs.ApplyConfigFile(configPath))
+
+			h.AssertEq(t, inputs.LayersDir, platform.DefaultLayersDir)
+			h.AssertEq(t, inputs.KanikoCacheTTL, platform.DefaultKanikoCacheTTL)
+		})
+
+		when("the environment variable for an input is set", func() {
+			it("ignores the input in the file", func() {
+				h.AssertNil(t, os.Setenv(platform.EnvRunImage, "some-env-run-image"))
+				h.AssertNil(t, os.Setenv(platform.EnvSkipRestore, "true"))
+				inputs = platform.NewLifecycleInputs(api.Platform.Latest())
+				writeConfig(`
+run-image = "some-run-image"
+skip-layers = false
+`)
+
+				h.AssertNil(t, inputs.ApplyConfigFile(configPath))
+
+				h.AssertEq(t, inputs.RunImageRef, "some-env-run-image")
+				h.AssertEq(t, inputs.SkipLayers, true)
+			})
+		})
+
+		when("the file is invalid", func() {
+			it("errors with the line of an unknown input", func() {
+				writeConfig(`
+run-image = "some-run-image"
+some-unknown-input = "some-value"
+`)
+
+				err := inputs.ApplyConfigFile(configPath)
+				h.AssertError(t, err, `line 3: unknown input "some-unknown-input"`)
+			})
+
+			it("errors with the reason an input cannot be provided in the file", func() {
+				writeConfig(`
+run-image = "some-run-image"
+kaniko-dir = "/some/kaniko"
+`)
+
+				err := inputs.ApplyConfigFile(configPath)
+				h.AssertError(t, err, `line 3: input "kaniko-dir" cannot be provided in the config file: the kaniko directory is not configurable`)
+			})
+
+			it("errors with the line of an invalid value", func() {
+				writeConfig(`
+run-image = "some-run-image"
+registry-retries = "three"
+`)
+
+				err := inputs.ApplyConfigFile(configPath)
+				h.AssertError(t, err, "line 3")
+			})
+
+			it("errors with the line of a syntax error", func() {
+				writeConfig(`
+run-image = "some-run-image"
+layers-dir =
+`)
+
+				err := inputs.ApplyConfigFile(configPath)
+				h.AssertError(t, err, "line 3")
+			})
+		})
+
+		when("the file does not exist", func() {
+			it("errors", func() {
+				err := inputs.ApplyConfigFile(filepath.Join(t.TempDir(), "missing.toml"))
+				h.AssertError(t, err, "failed to read config file")
+			})
+		})
+	})
+}
@@ -33,6 +33,20 @@ const (
 
 	EnvNoColor = "CNB_NO_COLOR"
 
+	// EnvEventsPath is the path to a file where the lifecycle appends a newline-delimited JSON stream of events describing the progress of the build.
+	// If not provided, and EnvEventsFD is not provided, no events are written.
+	EnvEventsPath = "CNB_EVENTS_PATH"
+	// EnvEventsFD is an inherited file descriptor where the lifecycle writes a newline-delimited JSON stream of events.
+	// It is ignored if EnvEventsPath is provided.
+	EnvEventsFD = "CNB_EVENTS_FD"
+
+	// EnvTracesPath is the path to a file where the lifecycle appends trace spans in the OTLP JSON file format.
+	// If not provided, no spans are recorded.
+	EnvTracesPath = "CNB_TRACES_PATH"
+	// EnvTraceparent is a W3C trace context (e.g., `00-<trace-id>-<span-id>-01`) identifying the platform span that
+	// lifecycle spans should belong to.
+	EnvTraceparent = "TRACEPARENT"
+
 	// EnvDeprecationMode is the desired behavior when deprecated APIs (either Platform or Buildpack) are requested.
 	EnvDeprecationMode = "CNB_DEPRECATION_MODE" // defaults to ModeQuiet
 
@@ -49,6 +63,10 @@ const (
 	DefaultExtendKind = "build"
 )
 
+// EnvConfigPath is the location of the lifecycle config file, a TOML file that can provide most inputs to the lifecycle
+// (see LifecycleInputs.ApplyConfigFile). Inputs provided through command-line flags or environment variables take precedence over the file.
+const EnvConfigPath = "CNB_CONFIG_PATH"
+
 // EnvUseDaemon configures the lifecycle to export the application image to a daemon satisfying the Docker socket interface (e.g., docker, podman).
 // If not provided, the default behavior is to export to an OCI registry.
 // When exporting to a daemon, the socket must be available in the build environment and the lifecycle must be run as root.
@@ -59,6 +77,42 @@ const EnvUseDaemon = "CNB_USE_DAEMON"
 // EnvInsecureRegistries configures the lifecycle to export the application to a remote "insecure" registry.
 const EnvInsecureRegistries = "CNB_INSECURE_REGISTRIES"
 
+// EnvRegistriesPath is the location of the registries file, which configures mirrors (e.g., pull-through caches) that images are pulled from
+// before falling back to the registry in the image reference. The mirror used for the run image and previous image is recorded in analyzed.toml.
+const EnvRegistriesPath = "CNB_REGISTRIES_PATH"
+
+// DefaultRegistriesPath is the default registries path; if the file does not exist, images are pulled from the registry in the image reference.
+var DefaultRegistriesPath = filepath.Join(path.RootDir, "cnb", "registries.toml")
+
+// The following configure how registry reads and writes (e.g., opening images, checking access, saving the application and cache images)
+// are retried when they fail with a transient error, including 429 Too Many Requests and 503 Service Unavailable.
+// The delay before each retry is doubled (with jitter), up to the maximum delay;
+// a delay requested by a registry through the `Retry-After` header is honored, also up to the maximum delay.
+const (
+	// EnvRegistryRetries is the maximum number of times a registry operation is retried; 0 disables retries.
+	EnvRegistryRetries     = "CNB_REGISTRY_RETRIES"
+	DefaultRegistryRetries = 5
+
+	// EnvRegistryRetryDelay is the delay before the first retry of a registry operation (e.g., `100ms`).
+	EnvRegistryRetryDelay = "CNB_REGISTRY_RETRY_DELAY"
+	// EnvRegistryRetryMaxDelay is the maximum delay before a retry of a registry operation (e.g., `1m`).
+	EnvRegistryRetryMaxDelay = "CNB_REGISTRY_RETRY_MAX_DELAY"
+)
+
+// DefaultRegistryRetryDelay is the default delay before the first retry of a registry operation.
+var DefaultRegistryRetryDelay = 100 * time.Millisecond
+
+// DefaultRegistryRetryMaxDelay is the default maximum delay before a retry of a registry operation.
+var DefaultRegistryRetryMaxDelay = time.Minute
+
+// EnvSecretEnvVars is a comma-separated list of the names of platform environment variables (i.e., files in `<platform>/env`) whose values are secret.
+// Secret values are redacted from the lifecycle logs, buildpack output, and the detect and error reports, along with the registry credentials in `CNB_REGISTRY_AUTH`
+// and the values of service bindings.
+const EnvSecretEnvVars = "CNB_SECRET_ENV_VARS"
+
+// EnvServiceBindingRoot is the directory containing service bindings, in addition to `<platform>/bindings`.
+const EnvServiceBindingRoot = "SERVICE_BINDING_ROOT"
+
 // ## Provided to handle inputs and outputs in OCI layout format
 
 // The lifecycle can be configured to read the input images like `run-image` or `previous-image` in OCI layout format instead of from a
@@ -86,8 +140,11 @@ const (
 // The following are directories and files that are present in a builder image, and are inputs to the lifecycle.
 const (
 	EnvBuildConfigDir = "CNB_BUILD_CONFIG_DIR"
-	EnvBuildpacksDir  = "CNB_BUILDPACKS_DIR"
-	EnvExtensionsDir  = "CNB_EXTENSIONS_DIR"
+
+	// EnvBuildpacksDir and EnvExtensionsDir contain buildpacks and image extensions, either unpacked at `<escaped id>/<version>`
+	// or in buildpackages (OCI image layout directories or `.cnb` files), which are unpacked as needed.
+	EnvBuildpacksDir = "CNB_BUILDPACKS_DIR"
+	EnvExtensionsDir = "CNB_EXTENSIONS_DIR"
 
 	// EnvOrderPath is the location of the order file, which is used for detection. It contains a list of one or more buildpack groups
 	// to be tested against application source code, so that the appropriate group for a given build can be determined.
@@ -130,6 +187,29 @@ const (
 	EnvLayersDir   = "CNB_LAYERS_DIR"
 	EnvPlatformDir = "CNB_PLATFORM_DIR"
 
+	// EnvBuildpackTimeout limits how long each buildpack or image extension's `./bin/detect`, `./bin/generate`, or `./bin/build` may run.
+	// It is a comma-separated list where each entry is a duration applying to all modules (e.g., "15m"),
+	// or a duration applying to the module with the given ID (e.g., "some/buildpack=1h"). If not provided, there is no timeout.
+	// When a timeout expires, the process and any processes it started are killed.
+	EnvBuildpackTimeout = "CNB_BUILDPACK_TIMEOUT"
+
+	// EnvDetectParallelism, if greater than zero, configures the detector to run `./bin/detect` for all buildpacks and image extensions
+	// in the order concurrently (with at most the given number of processes at a time) before resolving groups in order.
+	// The selected group is the same as when detecting sequentially, but detection may finish sooner when many groups are tried.
+	EnvDetectParallelism = "CNB_DETECT_PARALLELISM"
+
+	// EnvRecordDigests, if true, configures the detector to record the digest of each buildpack and image extension directory in the group file.
+	EnvRecordDigests = "CNB_RECORD_DIGESTS"
+
+	// EnvOffline, if true, configures the detector, builder and creator to run `./bin/detect`, `./bin/generate`, and `./bin/build`
+	// in a network namespace with only a loopback interface (Linux only), so that buildpacks cannot reach the network.
+	// Creating the namespace requires the CAP_SYS_ADMIN capability.
+	EnvOffline = "CNB_OFFLINE"
+
+	// EnvVerifyDigests, if true, configures the builder and exporter to fail if any buildpack directory
+	// does not match the digest recorded in the group file, i.e., if the buildpack was modified after the `detect` phase.
+	EnvVerifyDigests = "CNB_VERIFY_DIGESTS"
+
 	// EnvExecEnv is the target execution environment. Standard values include "production", "test", and "development".
 	EnvExecEnv     = "CNB_EXEC_ENV"
 	DefaultExecEnv = "production"
@@ -160,6 +240,11 @@ const (
 	EnvPlanPath     = "CNB_PLAN_PATH"
 	DefaultPlanFile = "plan.toml"
 
+	// EnvDetectReportPath is the location of the detect report file, an optional output of the `detect` phase.
+	// It contains the outcome of each group that was evaluated during detection. If not provided, the file is not written;
+	// platforms will typically write it alongside the group and plan files in the layers directory.
+	EnvDetectReportPath = "CNB_DETECT_REPORT_PATH"
+
 	// EnvGeneratedDir is the location of the directory where the lifecycle should copy any Dockerfiles
 	// output by image extensions during the `generate` phase.
 	EnvGeneratedDir     = "CNB_GENERATED_DIR"
@@ -172,8 +257,15 @@ const (
 
 	// EnvReportPath is the location of the report file, an output of the `export` phase.
 	// It contains information about the output application image.
+	// Each phase of the build (starting with `analyze`) also records its timings and resource usage in the report.
 	EnvReportPath     = "CNB_REPORT_PATH"
 	DefaultReportFile = "report.toml"
+
+	// EnvErrorReportPath is the location of the error report file, written by any phase that fails.
+	// It describes the failure (e.g., the exit code and the buildpack that failed) so that platforms do not need to parse the logs.
+	// The report is written as JSON if the path ends in `.json`, and as TOML otherwise.
+	EnvErrorReportPath     = "CNB_ERROR_REPORT_PATH"
+	DefaultErrorReportFile = "error.toml"
 )
 
 // The following are configuration options with respect to caching.
@@ -187,6 +279,28 @@ const (
 	// Cache images in a daemon are disallowed (for performance reasons).
 	EnvCacheImage = "CNB_CACHE_IMAGE"
 
+	// EnvCacheCompression is how layers added to the cache directory are compressed on disk, either `gzip` or `zstd`.
+	// By default, layers are stored uncompressed. Layers are addressed by diffID regardless of compression,
+	// so a cache directory may hold layers stored with different compressions by different builds.
+	EnvCacheCompression = "CNB_CACHE_COMPRESSION"
+
+	// EnvCacheMaxSize is the maximum total size of the layers in the cache directory, in bytes or with a binary unit suffix (e.g., "10G").
+	// When a maximum size or age is configured, layers from previous builds are kept in the cache directory until they are evicted,
+	// least recently used first, when the exporter commits the cache; layers used by the current build are never evicted.
+	EnvCacheMaxSize = "CNB_CACHE_MAX_SIZE"
+
+	// EnvCacheMaxAge is how long a layer in the cache directory may go unused before it is evicted (e.g., "168h").
+	EnvCacheMaxAge = "CNB_CACHE_MAX_AGE"
+
+	// EnvCacheURL is the location of a cache in an S3-compatible object store, in the form `s3://<bucket>/<prefix>`.
+	// The `endpoint` and `region` query parameters select a store other than AWS S3 (e.g., `s3://cache/app?endpoint=http://minio:9000`).
+	// Credentials are read from the standard AWS environment variables and configuration files.
+	// Alternatively, `oci:<path>` is the location of a cache stored as an image in OCI layout format in a directory.
+	// Only one of cache directory, cache image, or cache URL may be used.
+	EnvCacheURL = "CNB_CACHE_URL"
+	// OCILayoutCacheScheme is the prefix of a cache URL that locates a cache stored in OCI layout format.
+	OCILayoutCacheScheme = "oci:"
+
 	// EnvLaunchCacheDir is the location of the launch cache directory.
 	// The launch cache is used when exporting to a daemon to store buildpack-generated layers, in order to speed up data retrieval for future builds.
 	EnvLaunchCacheDir = "CNB_LAUNCH_CACHE_DIR"
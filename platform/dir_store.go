@@ -3,15 +3,30 @@ package platform
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver/v3"
 
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/launch"
 )
 
+// DirStore finds buildpacks and image extensions in the buildpacks and extensions directories,
+// where each is either unpacked (at `<dir>/<escaped id>/<version>`) or contained in a buildpackage in the directory
+// (see indexFor). The layer of a buildpackage containing a module is unpacked into a scratch directory when the module is first looked up;
+// the scratch directory is removed by Close.
 type DirStore struct {
 	buildpacksDir string
 	extensionsDir string
+
+	mu       sync.Mutex
+	packages map[string]*packageIndex // by directory
+	scratch  string
 }
 
 func NewDirStore(buildpacksDir string, extensionsDir string) *DirStore {
@@ -33,7 +48,10 @@ func (s *DirStore) LookupBp(id, version string) (*buildpack.BpDescriptor, error)
 	if s.buildpacksDir == "" {
 		return nil, errors.New("missing buildpacks directory")
 	}
-	descriptorPath := filepath.Join(s.buildpacksDir, launch.EscapeID(id), version, "buildpack.toml")
+	descriptorPath, err := s.descriptorPath(s.buildpacksDir, "buildpack", id, version)
+	if err != nil {
+		return nil, err
+	}
 	return buildpack.ReadBpDescriptor(descriptorPath)
 }
 
@@ -41,6 +59,121 @@ func (s *DirStore) LookupExt(id, version string) (*buildpack.ExtDescriptor, erro
 	if s.extensionsDir == "" {
 		return nil, errors.New("missing extensions directory")
 	}
-	descriptorPath := filepath.Join(s.extensionsDir, launch.EscapeID(id), version, "extension.toml")
+	descriptorPath, err := s.descriptorPath(s.extensionsDir, "extension", id, version)
+	if err != nil {
+		return nil, err
+	}
 	return buildpack.ReadExtDescriptor(descriptorPath)
 }
+
+// descriptorPath returns the path to the descriptor (e.g., `buildpack.toml`) of the module of the provided kind
+// with the provided ID and version (see resolveVersion), unpacking the buildpackage containing it if needed.
+func (s *DirStore) descriptorPath(dir, kind, id, version string) (string, error) {
+	descriptorFile := kind + ".toml"
+	if version != "" {
+		unpackedPath := filepath.Join(dir, launch.EscapeID(id), version, descriptorFile)
+		if _, err := os.Stat(unpackedPath); err == nil {
+			return unpackedPath, nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	installed, err := installedVersions(dir, kind, id)
+	if err != nil {
+		return "", err
+	}
+	index, err := s.indexFor(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range index.versions(kind, id) {
+		if !slices.Contains(installed, v) {
+			installed = append(installed, v)
+		}
+	}
+	if version, err = resolveVersion(kind, id, version, installed); err != nil {
+		return "", err
+	}
+	unpackedPath := filepath.Join(dir, launch.EscapeID(id), version, descriptorFile)
+	if _, err := os.Stat(unpackedPath); err == nil {
+		return unpackedPath, nil
+	}
+	module, ok := index.find(kind, id, version)
+	if !ok {
+		// report the missing descriptor when it is read
+		return unpackedPath, nil
+	}
+	layerDir, err := s.unpack(module)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(layerDir, "cnb", kind+"s", launch.EscapeID(id), version, descriptorFile), nil
+}
+
+// installedVersions returns the versions of the module of the provided kind with the provided ID that are unpacked in dir.
+func installedVersions(dir, kind, id string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, launch.EscapeID(id)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list installed versions of %s '%s': %w", kind, id, err)
+	}
+	var installed []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			installed = append(installed, entry.Name())
+		}
+	}
+	return installed, nil
+}
+
+// resolveVersion returns the installed version of the buildpack or image extension with the provided ID
+// that should be used for the provided version, which may be an exact version, a semver constraint (e.g., `^2.3`),
+// or empty (i.e., any version).
+// An installed version that is exactly equal to the provided version is always used;
+// otherwise the highest installed version satisfying the constraint is used.
+func resolveVersion(kind, id, version string, installed []string) (string, error) {
+	if version != "" && slices.Contains(installed, version) {
+		return version, nil
+	}
+	constraint := version
+	if constraint == "" {
+		constraint = "*"
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		// not a range, so report the missing version when the descriptor is read
+		return version, nil
+	}
+	var (
+		matches = map[string][]string{}
+		highest *semver.Version
+	)
+	for _, name := range installed {
+		v, err := semver.NewVersion(name)
+		if err != nil || !c.Check(v) {
+			continue
+		}
+		matches[v.String()] = append(matches[v.String()], name)
+		if highest == nil || v.GreaterThan(highest) {
+			highest = v
+		}
+	}
+	describe := func() string {
+		if len(installed) == 0 {
+			return "none"
+		}
+		sort.Strings(installed)
+		return strings.Join(installed, ", ")
+	}
+	if highest == nil {
+		if version == "" {
+			return "", fmt.Errorf("no version of %s '%s' is installed with a semantic version (installed: %s)", kind, id, describe())
+		}
+		return "", fmt.Errorf("no installed version of %s '%s' satisfies '%s' (installed: %s)", kind, id, version, describe())
+	}
+	if candidates := matches[highest.String()]; len(candidates) > 1 {
+		sort.Strings(candidates)
+		return "", fmt.Errorf("ambiguous version '%s' for %s '%s': installed versions %s are equivalent (installed: %s)", version, kind, id, strings.Join(candidates, ", "), describe())
+	}
+	return matches[highest.String()][0], nil
+}
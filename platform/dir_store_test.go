@@ -1,13 +1,24 @@
 package platform_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
 	"path/filepath"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/platform"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
@@ -58,6 +69,41 @@ func testDirStore(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, bp.Buildpack.ID, "A")
 			h.AssertEq(t, bp.Buildpack.Version, "v1")
 		})
+
+		when("version is a range", func() {
+			it("returns the highest installed version satisfying the range", func() {
+				bp, err := dirStore.LookupBp("B", "^2.3")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.Buildpack.Version, "2.4.1")
+
+				bp, err = dirStore.LookupBp("B", "~2.3")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.Buildpack.Version, "2.3.0")
+			})
+
+			it("errors listing the installed versions when the range is unsatisfiable", func() {
+				_, err := dirStore.LookupBp("B", "^4")
+				h.AssertError(t, err, "no installed version of buildpack 'B' satisfies '^4' (installed: 2.3.0, 2.4.1, 3.0.0)")
+			})
+
+			it("errors when the highest matching version is installed more than once", func() {
+				_, err := dirStore.LookupBp("C", "^1")
+				h.AssertError(t, err, "ambiguous version '^1' for buildpack 'C': installed versions 1.0, 1.0.0 are equivalent")
+			})
+		})
+
+		when("version is omitted", func() {
+			it("returns the highest installed version", func() {
+				bp, err := dirStore.LookupBp("B", "")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.Buildpack.Version, "3.0.0")
+			})
+
+			it("errors when the buildpack is not installed", func() {
+				_, err := dirStore.LookupBp("D", "")
+				h.AssertError(t, err, "no version of buildpack 'D' is installed with a semantic version (installed: none)")
+			})
+		})
 	})
 
 	when(".LookupExt", func() {
@@ -69,4 +115,196 @@ func testDirStore(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, ext.Extension.Version, "v1")
 		})
 	})
+	when("buildpacks are in buildpackages", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			tmpDir = t.TempDir()
+			dirStore = platform.NewDirStore(tmpDir, tmpDir)
+		})
+
+		it("returns buildpacks from OCI image layout directories", func() {
+			writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3")
+
+			bp, err := dirStore.LookupBp("some/bp", "1.2.3")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Buildpack.ID, "some/bp")
+			h.AssertEq(t, bp.Buildpack.Version, "1.2.3")
+			h.AssertEq(t, filepath.Base(bp.WithRootDir), "1.2.3")
+			h.AssertEq(t, filepath.Base(filepath.Dir(bp.WithRootDir)), "some_bp")
+		})
+
+		it("returns buildpacks and extensions from .cnb files", func() {
+			layoutDir := filepath.Join(t.TempDir(), "some-package")
+			writeBuildpackage(t, layoutDir, "buildpack", "some/bp", "1.2.3")
+			writeArchive(t, layoutDir, filepath.Join(tmpDir, "some-package.cnb"))
+			layoutDir = filepath.Join(t.TempDir(), "some-ext-package")
+			writeBuildpackage(t, layoutDir, "extension", "some/ext", "4.5.6")
+			writeArchive(t, layoutDir, filepath.Join(tmpDir, "some-ext-package.cnb"))
+
+			bp, err := dirStore.LookupBp("some/bp", "1.2.3")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Buildpack.Version, "1.2.3")
+
+			ext, err := dirStore.LookupExt("some/ext", "4.5.6")
+			h.AssertNil(t, err)
+			h.AssertEq(t, ext.Extension.Version, "4.5.6")
+		})
+
+		it("resolves version ranges across buildpackages and unpacked buildpacks", func() {
+			writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3")
+			h.Mkdir(t, filepath.Join(tmpDir, "some_bp", "1.1.0"))
+			h.Mkfile(t, "api = \"0.10\"\n[buildpack]\nid = \"some/bp\"\nversion = \"1.1.0\"\n", filepath.Join(tmpDir, "some_bp", "1.1.0", "buildpack.toml"))
+
+			bp, err := dirStore.LookupBp("some/bp", "^1")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Buildpack.Version, "1.2.3")
+
+			bp, err = dirStore.LookupBp("some/bp", "~1.1")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.Buildpack.Version, "1.1.0")
+		})
+
+		it("errors when a layer does not match its digest", func() {
+			layoutDir := filepath.Join(tmpDir, "some-package")
+			digest := writeBuildpackage(t, layoutDir, "buildpack", "some/bp", "1.2.3")
+			h.Mkfile(t, "some-tampered-layer", filepath.Join(layoutDir, "blobs", digest.Algorithm, digest.Hex))
+
+			_, err := dirStore.LookupBp("some/bp", "1.2.3")
+			h.AssertError(t, err, "failed to unpack layer")
+		})
+
+		when("a layer contains links outside of the layer", func() {
+			var outsideDir string
+
+			it.Before(func() {
+				outsideDir = t.TempDir()
+			})
+
+			it("errors when a symlink has an absolute target", func() {
+				writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3",
+					&tar.Header{Typeflag: tar.TypeSymlink, Name: "cnb/some-link", Linkname: outsideDir},
+					&tar.Header{Typeflag: tar.TypeReg, Name: "cnb/some-link/some-file", Mode: 0644},
+				)
+
+				_, err := dirStore.LookupBp("some/bp", "1.2.3")
+				h.AssertError(t, err, "invalid link in archive")
+				h.AssertPathDoesNotExist(t, filepath.Join(outsideDir, "some-file"))
+			})
+
+			it("errors when a symlink has a relative target outside of the layer", func() {
+				writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3",
+					&tar.Header{Typeflag: tar.TypeSymlink, Name: "cnb/some-link", Linkname: "../../.."},
+				)
+
+				_, err := dirStore.LookupBp("some/bp", "1.2.3")
+				h.AssertError(t, err, "invalid link in archive")
+			})
+
+			it("errors when a hard link has a target outside of the layer", func() {
+				writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3",
+					&tar.Header{Typeflag: tar.TypeLink, Name: "cnb/some-link", Linkname: "../some-file"},
+				)
+
+				_, err := dirStore.LookupBp("some/bp", "1.2.3")
+				h.AssertError(t, err, "invalid link in archive")
+			})
+
+			it("errors when an entry is inside of a symlink", func() {
+				writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3",
+					&tar.Header{Typeflag: tar.TypeSymlink, Name: "cnb/some-link", Linkname: "buildpacks"},
+					&tar.Header{Typeflag: tar.TypeReg, Name: "cnb/some-link/some-file", Mode: 0644},
+				)
+
+				_, err := dirStore.LookupBp("some/bp", "1.2.3")
+				h.AssertError(t, err, "is inside symlink")
+			})
+		})
+
+		it("removes unpacked buildpackages when closed", func() {
+			writeBuildpackage(t, filepath.Join(tmpDir, "some-package"), "buildpack", "some/bp", "1.2.3")
+			bp, err := dirStore.LookupBp("some/bp", "1.2.3")
+			h.AssertNil(t, err)
+			h.AssertPathExists(t, bp.WithRootDir)
+
+			h.AssertNil(t, dirStore.Close())
+			h.AssertPathDoesNotExist(t, bp.WithRootDir)
+		})
+	})
+}
+
+// writeBuildpackage writes an OCI image layout containing a buildpackage with a single module to the provided path,
+// returning the digest of the layer containing the module. The provided entries (which must be empty) are appended to the layer.
+func writeBuildpackage(t *testing.T, path, kind, id, version string, entries ...*tar.Header) v1.Hash {
+	t.Helper()
+	moduleDir := "cnb/" + kind + "s/" + launch.EscapeID(id) + "/" + version + "/"
+	descriptor := "api = \"0.10\"\n[" + kind + "]\nid = \"" + id + "\"\nversion = \"" + version + "\"\n"
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, dir := range []string{"cnb/", "cnb/" + kind + "s/", "cnb/" + kind + "s/" + launch.EscapeID(id) + "/", moduleDir} {
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir, Mode: 0755}))
+	}
+	h.AssertNil(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: moduleDir + kind + ".toml", Mode: 0644, Size: int64(len(descriptor))}))
+	_, err := tw.Write([]byte(descriptor))
+	h.AssertNil(t, err)
+	for _, entry := range entries {
+		h.AssertNil(t, tw.WriteHeader(entry))
+	}
+	h.AssertNil(t, tw.Close())
+
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	h.AssertNil(t, err)
+	diffID, err := layer.DiffID()
+	h.AssertNil(t, err)
+	digest, err := layer.Digest()
+	h.AssertNil(t, err)
+	label, err := json.Marshal(map[string]map[string]map[string]string{id: {version: {"layerDiffID": diffID.String()}}})
+	h.AssertNil(t, err)
+
+	image, err := mutate.AppendLayers(empty.Image, layer)
+	h.AssertNil(t, err)
+	image, err = mutate.Config(image, v1.Config{Labels: map[string]string{"io.buildpacks." + kind + ".layers": string(label)}})
+	h.AssertNil(t, err)
+	p, err := layout.Write(path, empty.Index)
+	h.AssertNil(t, err)
+	h.AssertNil(t, p.AppendImage(image))
+	return digest
+}
+
+// writeArchive writes the contents of the provided directory to a tarball at the provided path, as in a `.cnb` file.
+func writeArchive(t *testing.T, dir, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	h.AssertNil(t, err)
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	h.AssertNil(t, filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(contents)
+		return err
+	}))
+	h.AssertNil(t, tw.Close())
 }
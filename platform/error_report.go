@@ -0,0 +1,100 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/platform/files"
+)
+
+// NewErrorReport describes the provided error, with which the provided phase failed, for the error report.
+func NewErrorReport(phase string, err error, exiter Exiter) files.ErrorReport {
+	report := files.ErrorReport{
+		Phase:    phase,
+		ExitCode: CodeForFailed,
+		Message:  err.Error(),
+		Causes:   causesOf(err),
+	}
+	var errorFail *cmd.ErrorFail
+	if errors.As(err, &errorFail) {
+		report.ExitCode = errorFail.Code
+	}
+	if errType, ok := exiter.ErrorTypeFor(report.ExitCode); ok {
+		report.Type = errType.String()
+	}
+
+	var (
+		bpErr     *buildpack.Error
+		accessErr *image.AccessError
+	)
+	switch {
+	case errors.As(err, &bpErr):
+		if bpErr.Module != nil {
+			report.Buildpack = &files.ErrorReportBuildpack{ID: bpErr.Module.ID, Version: bpErr.Module.Version, Extension: bpErr.Module.Extension}
+		}
+		report.Hint = hintForModule(bpErr)
+	case errors.As(err, &accessErr):
+		report.Image = accessErr.ImageRef
+		if accessErr.Denied() {
+			report.Hint = fmt.Sprintf("Registry authentication was denied for %s; check that the registry credentials provided to the lifecycle grant %s access.", accessErr.ImageRef, accessErr.Access)
+		} else {
+			report.Hint = fmt.Sprintf("Check that the registry for %s is reachable and that the image reference is correct.", accessErr.ImageRef)
+		}
+	default:
+		switch report.ExitCode {
+		case cmd.CodeForInvalidArgs:
+			report.Hint = "Check the arguments, flags, environment variables and config file provided to the lifecycle."
+		case cmd.CodeForIncompatiblePlatformAPI:
+			report.Hint = fmt.Sprintf("Set %s to a Platform API version supported by the lifecycle.", EnvPlatformAPI)
+		case cmd.CodeForIncompatibleBuildpackAPI:
+			report.Hint = "Use a version of the buildpack with a Buildpack API supported by the lifecycle."
+		}
+	}
+	return report
+}
+
+func hintForModule(bpErr *buildpack.Error) string {
+	name := "a buildpack"
+	if bpErr.Module != nil {
+		name = fmt.Sprintf("%s %s", strings.ToLower(bpErr.Module.Kind()), bpErr.Module.String())
+	}
+	switch bpErr.Type {
+	case buildpack.ErrTypeBuildpack:
+		return fmt.Sprintf("The output of %s in the logs may explain why it failed.", name)
+	case buildpack.ErrTypeTimeout:
+		return fmt.Sprintf("The timeout for %s can be increased with %s.", name, EnvBuildpackTimeout)
+	case buildpack.ErrTypeFailedDetection:
+		return "No group of buildpacks passed detection; check that the application is supported by the buildpacks in the order."
+	default:
+		return ""
+	}
+}
+
+// causesOf returns the message of each error in the chain of errors wrapped by the provided error, outermost first.
+// Messages that repeat the message of the error before are skipped.
+func causesOf(err error) []string {
+	var causes []string
+	for err != nil {
+		message := err.Error()
+		if len(causes) == 0 || causes[len(causes)-1] != message {
+			causes = append(causes, message)
+		}
+		switch wrapper := err.(type) {
+		case interface{ Unwrap() error }:
+			err = wrapper.Unwrap()
+		case interface{ Unwrap() []error }:
+			errs := wrapper.Unwrap()
+			if len(errs) == 0 {
+				return causes
+			}
+			err = errs[0]
+		default:
+			return causes
+		}
+	}
+	return causes
+}
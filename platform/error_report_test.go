@@ -0,0 +1,135 @@
+package platform_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestErrorReport(t *testing.T) {
+	spec.Run(t, "ErrorReport", testErrorReport, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testErrorReport(t *testing.T, when spec.G, it spec.S) {
+	var exiter platform.Exiter
+
+	it.Before(func() {
+		exiter = platform.NewExiter("0.99")
+	})
+
+	when("#NewErrorReport", func() {
+		it("describes a failed buildpack", func() {
+			bpErr := buildpack.NewError(errors.New("exit status 1"), buildpack.ErrTypeBuildpack)
+			bpErr.Module = &buildpack.GroupElement{ID: "some/buildpack", Version: "1.2.3"}
+			err := cmd.FailErrCode(bpErr, exiter.CodeFor(platform.FailedBuildWithErrors), "build")
+
+			errReport := platform.NewErrorReport("build", err, exiter)
+
+			h.AssertEq(t, errReport, files.ErrorReport{
+				Phase:     "build",
+				ExitCode:  51,
+				Type:      "FailedBuildWithErrors",
+				Message:   "failed to build: exit status 1",
+				Causes:    []string{"failed to build: exit status 1", "exit status 1"},
+				Buildpack: &files.ErrorReportBuildpack{ID: "some/buildpack", Version: "1.2.3"},
+				Hint:      "The output of buildpack some/buildpack@1.2.3 in the logs may explain why it failed.",
+			})
+		})
+
+		it("describes a timed out extension", func() {
+			bpErr := buildpack.NewError(errors.New("bin/generate timed out after 1m0s"), buildpack.ErrTypeTimeout)
+			bpErr.Module = &buildpack.GroupElement{ID: "some/extension", Version: "1.2.3", Extension: true}
+			err := cmd.FailErrCode(bpErr, exiter.CodeFor(platform.GenerateTimeout), "build")
+
+			errReport := platform.NewErrorReport("detect", err, exiter)
+
+			h.AssertEq(t, errReport.Type, "GenerateTimeout")
+			h.AssertEq(t, errReport.Buildpack, &files.ErrorReportBuildpack{ID: "some/extension", Version: "1.2.3", Extension: true})
+			h.AssertEq(t, errReport.Hint, "The timeout for extension some/extension@1.2.3 can be increased with CNB_BUILDPACK_TIMEOUT.")
+		})
+
+		it("describes denied registry access", func() {
+			accessErr := &image.AccessError{
+				ImageRef: "some-registry.io/some-image",
+				Access:   "read/write",
+				Err:      &transport.Error{StatusCode: http.StatusUnauthorized},
+			}
+			err := cmd.FailErrCode(fmt.Errorf("validating registry write access: %w", accessErr), exiter.CodeFor(platform.AnalyzeError), "initialize analyzer")
+
+			errReport := platform.NewErrorReport("analyze", err, exiter)
+
+			h.AssertEq(t, errReport.ExitCode, 32)
+			h.AssertEq(t, errReport.Type, "AnalyzeError")
+			h.AssertEq(t, errReport.Image, "some-registry.io/some-image")
+			h.AssertEq(t, errReport.Hint, "Registry authentication was denied for some-registry.io/some-image; check that the registry credentials provided to the lifecycle grant read/write access.")
+			h.AssertEq(t, len(errReport.Causes), 4)
+		})
+
+		it("describes invalid arguments", func() {
+			err := cmd.FailErrCode(errors.New("image argument is required"), cmd.CodeForInvalidArgs, "resolve inputs")
+
+			errReport := platform.NewErrorReport("export", err, exiter)
+
+			h.AssertEq(t, errReport.ExitCode, cmd.CodeForInvalidArgs)
+			h.AssertEq(t, errReport.Type, "")
+			h.AssertEq(t, errReport.Hint, "Check the arguments, flags, environment variables and config file provided to the lifecycle.")
+		})
+
+		it("describes other errors", func() {
+			errReport := platform.NewErrorReport("export", errors.New("some-error"), exiter)
+
+			h.AssertEq(t, errReport, files.ErrorReport{
+				Phase:    "export",
+				ExitCode: 1,
+				Message:  "some-error",
+				Causes:   []string{"some-error"},
+			})
+		})
+	})
+
+	when("files.Handler#WriteErrorReport", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			tmpDir = t.TempDir()
+		})
+
+		it("writes TOML", func() {
+			path := filepath.Join(tmpDir, "error.toml")
+			errReport := platform.NewErrorReport("export", errors.New("some-error"), exiter)
+
+			h.AssertNil(t, files.Handler.WriteErrorReport(path, &errReport))
+
+			var written files.ErrorReport
+			_, err := toml.DecodeFile(path, &written)
+			h.AssertNil(t, err)
+			h.AssertEq(t, written, errReport)
+		})
+
+		it("writes JSON when the path ends in .json", func() {
+			path := filepath.Join(tmpDir, "error.json")
+			errReport := platform.NewErrorReport("export", errors.New("some-error"), exiter)
+
+			h.AssertNil(t, files.Handler.WriteErrorReport(path, &errReport))
+
+			contents, err := os.ReadFile(path)
+			h.AssertNil(t, err)
+			h.AssertEq(t, string(contents), `{"phase":"export","exitCode":1,"message":"some-error","causes":["some-error"]}`+"\n")
+		})
+	})
+}
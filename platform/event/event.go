@@ -0,0 +1,173 @@
+// Package event defines the structured events emitted by the lifecycle to describe the progress of a build,
+// and the sinks that receive them.
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+type Type string
+
+const (
+	// PhaseStart and PhaseEnd mark the boundaries of a lifecycle phase (e.g., "detect" or "export").
+	PhaseStart Type = "phase-start"
+	PhaseEnd   Type = "phase-end"
+
+	// DetectStart and DetectEnd mark the execution of `./bin/detect` for a buildpack or image extension.
+	DetectStart Type = "detect-start"
+	DetectEnd   Type = "detect-end"
+
+	// GenerateStart and GenerateEnd mark the execution of `./bin/generate` for an image extension.
+	GenerateStart Type = "generate-start"
+	GenerateEnd   Type = "generate-end"
+
+	// BuildStart and BuildEnd mark the execution of `./bin/build` for a buildpack.
+	BuildStart Type = "build-start"
+	BuildEnd   Type = "build-end"
+
+	// LayerAdded and LayerReused are emitted for each layer of the exported image;
+	// reused layers are not uploaded, as they already exist in the previous image.
+	LayerAdded  Type = "layer-added"
+	LayerReused Type = "layer-reused"
+
+	// LayerCached is emitted for each layer saved to the cache;
+	// Reused is true if the layer was already in the cache.
+	LayerCached Type = "layer-cached"
+
+	// LayerEvicted is emitted for each layer evicted from the cache when it is committed.
+	LayerEvicted Type = "layer-evicted"
+
+	// CacheHit and CacheMiss are emitted for each cached layer during restore.
+	CacheHit  Type = "cache-hit"
+	CacheMiss Type = "cache-miss"
+
+	// ImageSaved is emitted for each tag of a saved image; Error is set if the tag could not be saved.
+	ImageSaved Type = "image-saved"
+)
+
+// Event describes a single step in the progress of a build.
+// Fields that do not apply to the event type are omitted.
+type Event struct {
+	Time  time.Time `json:"time"`
+	Type  Type      `json:"type"`
+	Phase string    `json:"phase,omitempty"`
+	// Module is the ID and version of the buildpack or image extension, e.g., "some/buildpack@1.2.3".
+	Module   string `json:"module,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+	// Usage describes the resources used by the buildpack or image extension process; durations are in nanoseconds.
+	Usage *buildpack.Usage `json:"usage,omitempty"`
+	// Offline is true if the buildpack or image extension process is run without network access.
+	Offline bool   `json:"offline,omitempty"`
+	Layer   string `json:"layer,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Reused  bool   `json:"reused,omitempty"`
+	Tag     string `json:"tag,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Sink receives events. Implementations must be safe for concurrent use.
+type Sink interface {
+	Emit(e Event)
+}
+
+// SinkCloser is a Sink that must be closed once no more events are emitted to it.
+type SinkCloser interface {
+	Sink
+	io.Closer
+}
+
+// NopSink discards all events.
+type NopSink struct{}
+
+func (NopSink) Emit(Event) {}
+
+func (NopSink) Close() error { return nil }
+
+// JSONSink writes each event as a single line of JSON.
+type JSONSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONSink returns a sink that writes newline-delimited JSON to the provided writer.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+// Emit writes the provided event, setting its time if unset.
+// Events are best-effort: errors writing the event are ignored so that they never fail the build.
+func (s *JSONSink) Emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(append(data, '\n'))
+}
+
+// Close closes the underlying writer, if it is an io.Closer.
+func (s *JSONSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if closer, ok := s.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NewSink returns a sink that writes newline-delimited JSON to the file at the provided path,
+// or else to the provided (inherited) file descriptor if greater than zero.
+// If neither is provided, events are discarded.
+// Events are appended to an existing file, so that a single file can collect the events from each phase of a build.
+// The file descriptor is not inherited by the processes the lifecycle executes, and is closed with the sink.
+func NewSink(path string, fd int) (SinkCloser, error) {
+	switch {
+	case path != "":
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644) // #nosec G302 G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to open events file: %w", err)
+		}
+		return NewJSONSink(f), nil
+	case fd > 0:
+		closeOnExec(fd)
+		return NewJSONSink(os.NewFile(uintptr(fd), "events")), nil
+	default:
+		return NopSink{}, nil
+	}
+}
+
+type multiSink []Sink
+
+func (s multiSink) Emit(e Event) {
+	for _, sink := range s {
+		sink.Emit(e)
+	}
+}
+
+// Tee returns a sink that sends each event to all the provided sinks.
+func Tee(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+// Emit sends the provided event to the sink, if not nil, setting its time if unset.
+func Emit(sink Sink, e Event) {
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	sink.Emit(e)
+}
@@ -0,0 +1,97 @@
+package event_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/platform/event"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestEvent(t *testing.T) {
+	spec.Run(t, "unit-event", testEvent, spec.Report(report.Terminal{}))
+}
+
+func testEvent(t *testing.T, when spec.G, it spec.S) {
+	when("JSONSink", func() {
+		it("writes each event as a line of JSON", func() {
+			buf := &bytes.Buffer{}
+			sink := event.NewJSONSink(buf)
+
+			code := 1
+			sink.Emit(event.Event{Type: event.BuildEnd, Module: "some/buildpack@1.2.3", ExitCode: &code})
+			sink.Emit(event.Event{Type: event.LayerAdded, Layer: "some/buildpack:some-layer", Digest: "sha256:abc", Size: 10})
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			h.AssertEq(t, len(lines), 2)
+			var first map[string]interface{}
+			h.AssertNil(t, json.Unmarshal([]byte(lines[0]), &first))
+			h.AssertEq(t, first["type"], "build-end")
+			h.AssertEq(t, first["module"], "some/buildpack@1.2.3")
+			h.AssertEq(t, first["exitCode"], float64(1))
+			h.AssertNotNil(t, first["time"])
+			_, hasLayer := first["layer"]
+			h.AssertEq(t, hasLayer, false)
+
+			var second event.Event
+			h.AssertNil(t, json.Unmarshal([]byte(lines[1]), &second))
+			h.AssertEq(t, second.Digest, "sha256:abc")
+			h.AssertEq(t, second.Size, int64(10))
+		})
+	})
+
+	when("#NewSink", func() {
+		var tmpDir string
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = os.MkdirTemp("", "lifecycle.events")
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			_ = os.RemoveAll(tmpDir)
+		})
+
+		it("appends to the file at the provided path", func() {
+			path := filepath.Join(tmpDir, "events.ndjson")
+			h.Mkfile(t, "{}\n", path)
+
+			sink, err := event.NewSink(path, 0)
+			h.AssertNil(t, err)
+			sink.Emit(event.Event{Type: event.PhaseStart, Phase: "detect"})
+
+			contents := h.MustReadFile(t, path)
+			lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+			h.AssertEq(t, len(lines), 2)
+			h.AssertStringContains(t, lines[1], `"phase":"detect"`)
+		})
+
+		it("closes the file", func() {
+			path := filepath.Join(tmpDir, "events.ndjson")
+
+			sink, err := event.NewSink(path, 0)
+			h.AssertNil(t, err)
+			h.AssertNil(t, sink.Close())
+			h.AssertNotNil(t, sink.(*event.JSONSink).Close())
+		})
+
+		it("errors when the file cannot be opened", func() {
+			_, err := event.NewSink(filepath.Join(tmpDir, "missing", "events.ndjson"), 0)
+			h.AssertError(t, err, "failed to open events file")
+		})
+
+		it("discards events when neither a path nor a file descriptor is provided", func() {
+			sink, err := event.NewSink("", 0)
+			h.AssertNil(t, err)
+			h.AssertEq(t, sink, event.SinkCloser(event.NopSink{}))
+		})
+	})
+}
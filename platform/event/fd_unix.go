@@ -0,0 +1,11 @@
+//go:build unix
+
+package event
+
+import "syscall"
+
+// closeOnExec prevents the provided file descriptor from being inherited by the processes the lifecycle executes
+// (e.g., buildpacks), so that they cannot write to the event stream.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
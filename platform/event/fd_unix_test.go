@@ -0,0 +1,55 @@
+//go:build unix
+
+package event_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"golang.org/x/sys/unix"
+
+	"github.com/buildpacks/lifecycle/platform/event"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestEventFD(t *testing.T) {
+	spec.Run(t, "unit-event-fd", testEventFD, spec.Report(report.Terminal{}))
+}
+
+func testEventFD(t *testing.T, when spec.G, it spec.S) {
+	when("#NewSink", func() {
+		var (
+			r, w *os.File
+			fd   int
+		)
+
+		it.Before(func() {
+			var err error
+			r, w, err = os.Pipe()
+			h.AssertNil(t, err)
+			// unlike the file descriptors opened by Go, an inherited file descriptor is not close-on-exec
+			fd, err = unix.Dup(int(w.Fd()))
+			h.AssertNil(t, err)
+		})
+
+		it.After(func() {
+			_ = r.Close()
+			_ = w.Close()
+		})
+
+		it("does not pass the file descriptor to executed processes, and closes it with the sink", func() {
+			sink, err := event.NewSink("", fd)
+			h.AssertNil(t, err)
+
+			flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFD, 0)
+			h.AssertNil(t, err)
+			h.AssertEq(t, flags&unix.FD_CLOEXEC, unix.FD_CLOEXEC)
+
+			h.AssertNil(t, sink.Close())
+			_, err = unix.FcntlInt(uintptr(fd), unix.F_GETFD, 0)
+			h.AssertEq(t, err, unix.EBADF)
+		})
+	})
+}
@@ -0,0 +1,4 @@
+package event
+
+// closeOnExec does nothing, as Go only passes handles to child processes on Windows when they are requested explicitly.
+func closeOnExec(_ int) {}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buildpacks/lifecycle/platform/files"
+)
+
+// Recorder is a sink that summarizes the events it receives as the timings and resources sections of report.toml.
+// Registry traffic is not described by events, and so is not recorded (see image.Traffic).
+type Recorder struct {
+	mu        sync.Mutex
+	started   map[string]time.Time
+	timings   files.TimingsReport
+	resources files.ResourcesReport
+	network   files.NetworkReport
+}
+
+func (r *Recorder) Emit(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started == nil {
+		r.started = map[string]time.Time{}
+	}
+	switch e.Type {
+	case PhaseStart:
+		r.started[string(e.Type)+e.Phase] = e.Time
+	case PhaseEnd:
+		if start, ok := r.started[string(PhaseStart)+e.Phase]; ok {
+			r.timings.Phases = append(r.timings.Phases, files.PhaseTiming{Phase: e.Phase, WallTime: e.Time.Sub(start)})
+		}
+	case DetectStart, GenerateStart, BuildStart:
+		r.started[string(e.Type)+e.Module] = e.Time
+		if step := strings.TrimSuffix(string(e.Type), "-start"); e.Offline && !slices.Contains(r.network.OfflineSteps, step) {
+			r.network.OfflineSteps = append(r.network.OfflineSteps, step)
+		}
+	case DetectEnd, GenerateEnd, BuildEnd:
+		step := strings.TrimSuffix(string(e.Type), "-end")
+		id, version, _ := strings.Cut(e.Module, "@")
+		if start, ok := r.started[step+"-start"+e.Module]; ok {
+			r.timings.Buildpacks = append(r.timings.Buildpacks, files.ModuleTiming{
+				ID:       id,
+				Version:  version,
+				Step:     step,
+				WallTime: e.Time.Sub(start),
+			})
+		}
+		if e.Usage != nil {
+			r.resources.Buildpacks = append(r.resources.Buildpacks, files.ModuleResources{
+				ID:         id,
+				Version:    version,
+				Step:       step,
+				UserTime:   e.Usage.UserTime,
+				SystemTime: e.Usage.SystemTime,
+				MaxRSS:     e.Usage.MaxRSS,
+			})
+		}
+	case CacheHit:
+		r.resources.Cache.BytesRead += e.Size
+	case LayerCached:
+		if !e.Reused {
+			r.resources.Cache.BytesWritten += e.Size
+		}
+	case LayerEvicted:
+		r.resources.CacheEviction.Layers++
+		r.resources.CacheEviction.Bytes += e.Size
+	}
+}
+
+// Flush adds the timings, resources and network access recorded since the last call to Flush to those in the provided report.
+func (r *Recorder) Flush(report *files.Report) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report.Timings.Phases = append(report.Timings.Phases, r.timings.Phases...)
+	report.Timings.Buildpacks = append(report.Timings.Buildpacks, r.timings.Buildpacks...)
+	report.Resources.Buildpacks = append(report.Resources.Buildpacks, r.resources.Buildpacks...)
+	report.Resources.Cache.BytesRead += r.resources.Cache.BytesRead
+	report.Resources.Cache.BytesWritten += r.resources.Cache.BytesWritten
+	report.Resources.CacheEviction.Layers += r.resources.CacheEviction.Layers
+	report.Resources.CacheEviction.Bytes += r.resources.CacheEviction.Bytes
+	for _, step := range r.network.OfflineSteps {
+		if !slices.Contains(report.Network.OfflineSteps, step) {
+			report.Network.OfflineSteps = append(report.Network.OfflineSteps, step)
+		}
+	}
+	r.timings = files.TimingsReport{}
+	r.resources = files.ResourcesReport{}
+	r.network = files.NetworkReport{}
+}
@@ -0,0 +1,97 @@
+package event_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform/event"
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRecorder(t *testing.T) {
+	spec.Run(t, "unit-recorder", testRecorder, spec.Report(report.Terminal{}))
+}
+
+func testRecorder(t *testing.T, when spec.G, it spec.S) {
+	var (
+		recorder *event.Recorder
+		start    = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	it.Before(func() {
+		recorder = &event.Recorder{}
+	})
+
+	when("#Flush", func() {
+		it("adds the recorded timings and resources to the report", func() {
+			recorder.Emit(event.Event{Type: event.PhaseStart, Phase: "build", Time: start})
+			recorder.Emit(event.Event{Type: event.BuildStart, Module: "some/buildpack@1.2.3", Time: start})
+			recorder.Emit(event.Event{
+				Type:   event.BuildEnd,
+				Module: "some/buildpack@1.2.3",
+				Time:   start.Add(2 * time.Second),
+				Usage:  &buildpack.Usage{UserTime: time.Second, SystemTime: time.Millisecond, MaxRSS: 1024},
+			})
+			recorder.Emit(event.Event{Type: event.PhaseEnd, Phase: "build", Time: start.Add(3 * time.Second)})
+			recorder.Emit(event.Event{Type: event.CacheHit, Size: 10})
+			recorder.Emit(event.Event{Type: event.LayerCached, Size: 20})
+			recorder.Emit(event.Event{Type: event.LayerCached, Size: 40, Reused: true})
+			recorder.Emit(event.Event{Type: event.LayerAdded, Size: 30})
+			recorder.Emit(event.Event{Type: event.LayerEvicted, Digest: "sha256:some-layer", Size: 50})
+
+			rpt := files.Report{
+				Timings: files.TimingsReport{
+					Phases: []files.PhaseTiming{{Phase: "detect", WallTime: time.Second}},
+				},
+				Resources: files.ResourcesReport{Cache: files.TransferReport{BytesRead: 5}},
+			}
+			recorder.Flush(&rpt)
+
+			h.AssertEq(t, rpt.Timings, files.TimingsReport{
+				Phases: []files.PhaseTiming{
+					{Phase: "detect", WallTime: time.Second},
+					{Phase: "build", WallTime: 3 * time.Second},
+				},
+				Buildpacks: []files.ModuleTiming{
+					{ID: "some/buildpack", Version: "1.2.3", Step: "build", WallTime: 2 * time.Second},
+				},
+			})
+			h.AssertEq(t, rpt.Resources, files.ResourcesReport{
+				Buildpacks: []files.ModuleResources{
+					{ID: "some/buildpack", Version: "1.2.3", Step: "build", UserTime: time.Second, SystemTime: time.Millisecond, MaxRSS: 1024},
+				},
+				Cache:         files.TransferReport{BytesRead: 15, BytesWritten: 20},
+				CacheEviction: files.EvictionReport{Layers: 1, Bytes: 50},
+				// layers added to the image are not necessarily sent to a registry
+			})
+		})
+
+		it("adds the steps that were run offline to the report", func() {
+			recorder.Emit(event.Event{Type: event.DetectStart, Module: "some/buildpack@1.2.3", Offline: true})
+			recorder.Emit(event.Event{Type: event.DetectStart, Module: "other/buildpack@1.2.3", Offline: true})
+			recorder.Emit(event.Event{Type: event.GenerateStart, Module: "some/extension@1.2.3"})
+			recorder.Emit(event.Event{Type: event.BuildStart, Module: "some/buildpack@1.2.3", Offline: true})
+
+			rpt := files.Report{Network: files.NetworkReport{OfflineSteps: []string{"detect"}}}
+			recorder.Flush(&rpt)
+
+			h.AssertEq(t, rpt.Network, files.NetworkReport{OfflineSteps: []string{"detect", "build"}})
+		})
+
+		it("resets what was recorded", func() {
+			recorder.Emit(event.Event{Type: event.PhaseStart, Phase: "build", Time: start})
+			recorder.Emit(event.Event{Type: event.PhaseEnd, Phase: "build", Time: start.Add(time.Second)})
+			recorder.Emit(event.Event{Type: event.CacheHit, Size: 10})
+			recorder.Flush(&files.Report{})
+
+			var rpt files.Report
+			recorder.Flush(&rpt)
+			h.AssertEq(t, rpt, files.Report{})
+		})
+	})
+}
@@ -20,10 +20,38 @@ const (
 	FailedGenerateWithErrors                           // extension error during /bin/generate
 	GenerateError                                      // generic generate error
 	ExtendError                                        // generic extend error
+	DetectTimeout                                      // no buildpacks detected and at least one timed out
+	BuildTimeout                                       // buildpack timed out during /bin/build
+	GenerateTimeout                                    // extension timed out during /bin/generate
 )
 
+var exitErrorNames = map[LifecycleExitError]string{
+	FailedDetect:             "FailedDetect",
+	FailedDetectWithErrors:   "FailedDetectWithErrors",
+	DetectError:              "DetectError",
+	AnalyzeError:             "AnalyzeError",
+	RestoreError:             "RestoreError",
+	FailedBuildWithErrors:    "FailedBuildWithErrors",
+	BuildError:               "BuildError",
+	ExportError:              "ExportError",
+	RebaseError:              "RebaseError",
+	LaunchError:              "LaunchError",
+	FailedGenerateWithErrors: "FailedGenerateWithErrors",
+	GenerateError:            "GenerateError",
+	ExtendError:              "ExtendError",
+	DetectTimeout:            "DetectTimeout",
+	BuildTimeout:             "BuildTimeout",
+	GenerateTimeout:          "GenerateTimeout",
+}
+
+func (e LifecycleExitError) String() string {
+	return exitErrorNames[e]
+}
+
 type Exiter interface {
 	CodeFor(errType LifecycleExitError) int
+	// ErrorTypeFor returns the type of error with the provided exit code, if the code is specific to a type of error.
+	ErrorTypeFor(code int) (LifecycleExitError, bool)
 }
 
 // NewExiter configures a new Exiter according to the provided Platform API version.
@@ -38,6 +66,7 @@ var defaultExitCodes = map[LifecycleExitError]int{
 	FailedDetect:           20, // FailedDetect indicates that no buildpacks detected
 	FailedDetectWithErrors: 21, // FailedDetectWithErrors indicated that no buildpacks detected and at least one errored
 	DetectError:            22, // DetectError indicates generic detect error
+	DetectTimeout:          23, // DetectTimeout indicates that no buildpacks detected and at least one timed out
 
 	// analyze phase errors: 30-39
 	AnalyzeError: 32, // AnalyzeError indicates generic analyze error
@@ -48,6 +77,7 @@ var defaultExitCodes = map[LifecycleExitError]int{
 	// build phase errors: 50-59
 	FailedBuildWithErrors: 51, // FailedBuildWithErrors indicates buildpack error during /bin/build
 	BuildError:            52, // BuildError indicates generic build error
+	BuildTimeout:          53, // BuildTimeout indicates buildpack timed out during /bin/build
 
 	// export phase errors: 60-69
 	ExportError: 62, // ExportError indicates generic export error
@@ -61,6 +91,7 @@ var defaultExitCodes = map[LifecycleExitError]int{
 	// generate phase errors: 90-99
 	FailedGenerateWithErrors: 91, // FailedGenerateWithErrors indicates extension error during /bin/generate
 	GenerateError:            92, // GenerateError indicates generic generate error
+	GenerateTimeout:          93, // GenerateTimeout indicates extension timed out during /bin/generate
 
 	// extend phase errors: 100-109
 	ExtendError: 102, // ExtendError indicates generic extend error
@@ -70,9 +101,22 @@ func (e *DefaultExiter) CodeFor(errType LifecycleExitError) int {
 	return codeFor(errType, defaultExitCodes)
 }
 
+func (e *DefaultExiter) ErrorTypeFor(code int) (LifecycleExitError, bool) {
+	return errorTypeFor(code, defaultExitCodes)
+}
+
 func codeFor(errType LifecycleExitError, exitCodes map[LifecycleExitError]int) int {
 	if code, ok := exitCodes[errType]; ok {
 		return code
 	}
 	return CodeForFailed
 }
+
+func errorTypeFor(code int, exitCodes map[LifecycleExitError]int) (LifecycleExitError, bool) {
+	for errType, errTypeCode := range exitCodes {
+		if errTypeCode == code {
+			return errType, true
+		}
+	}
+	return 0, false
+}
@@ -61,6 +61,8 @@ func (a Analyzed) RunImageTarget() TargetMetadata {
 
 type ImageIdentifier struct {
 	Reference string `toml:"reference"` // FIXME: fix key name to be accurate in the daemon case
+	// Mirror is the reference the image was pulled from, if it was pulled from a registry mirror configured by the platform.
+	Mirror string `toml:"mirror,omitempty"`
 }
 
 // NOTE: This struct MUST be kept in sync with `LayersMetadataCompat`
@@ -132,6 +134,8 @@ type RunImage struct {
 	// Extend if true indicates that the run image should be extended by the extender.
 	Extend         bool            `toml:"extend,omitempty"`
 	TargetMetadata *TargetMetadata `json:"target,omitempty" toml:"target,omitempty"`
+	// Mirror is the reference the run image was pulled from, if it was pulled from a registry mirror configured by the platform.
+	Mirror string `toml:"mirror,omitempty"`
 }
 
 type TargetMetadata struct {
@@ -0,0 +1,63 @@
+package files
+
+// DetectReport is written by the detector to record how each group in the order was evaluated.
+// It is written whether or not detection passes, so that platforms can explain why a group was (or was not) selected
+// without re-running detection at debug level.
+// The location of the file can be specified by providing `-detect-report <path>` to the lifecycle;
+// if no location is provided, the report is not written.
+type DetectReport struct {
+	Groups []DetectGroupReport `toml:"groups"`
+}
+
+// DetectGroupReport records the evaluation of a single (flattened) group of buildpacks and image extensions.
+type DetectGroupReport struct {
+	// Result is one of "pass", "fail", or "error".
+	Result   string                `toml:"result"`
+	Elements []DetectElementReport `toml:"elements"`
+	Trials   []DetectTrialReport   `toml:"trials,omitempty"`
+}
+
+// DetectElementReport records the outcome of `./bin/detect` for a single buildpack or image extension.
+type DetectElementReport struct {
+	ID        string `toml:"id"`
+	Version   string `toml:"version"`
+	Extension bool   `toml:"extension,omitempty"`
+	Optional  bool   `toml:"optional,omitempty"`
+	// Result is one of "pass", "fail", "skip", or "error".
+	Result   string `toml:"result"`
+	ExitCode int    `toml:"exit-code"`
+	// TargetMismatch is true when the module was not run because it does not support the run image target.
+	TargetMismatch bool `toml:"target-mismatch,omitempty"`
+	// Output is an excerpt (the trailing portion) of the combined stdout and stderr of `./bin/detect`.
+	Output string `toml:"output,omitempty"`
+	Error  string `toml:"error,omitempty"`
+}
+
+// DetectTrialReport records a single attempt to resolve a build plan from the requires and provides of a group.
+type DetectTrialReport struct {
+	// Result is one of "pass" or "fail".
+	Result   string             `toml:"result"`
+	Elements []string           `toml:"elements"`
+	Unmet    []DetectUnmetEntry `toml:"unmet,omitempty"`
+}
+
+// DetectUnmetEntry records a require that was not provided, or a provide that was not required, during a trial.
+type DetectUnmetEntry struct {
+	ID       string `toml:"id"`
+	Version  string `toml:"version"`
+	Optional bool   `toml:"optional,omitempty"`
+	Requires string `toml:"requires,omitempty"`
+	Provides string `toml:"provides,omitempty"`
+}
+
+// Redact replaces the output and error of each element in the report with the result of the provided function
+// (e.g., log.Redactor.Redact), so that secrets printed by `./bin/detect` are not written to the report.
+func (r *DetectReport) Redact(redact func(string) string) {
+	for i := range r.Groups {
+		for j := range r.Groups[i].Elements {
+			element := &r.Groups[i].Elements[j]
+			element.Output = redact(element.Output)
+			element.Error = redact(element.Error)
+		}
+	}
+}
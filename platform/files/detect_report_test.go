@@ -0,0 +1,35 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestDetectReport(t *testing.T) {
+	spec.Run(t, "DetectReport", testDetectReport, spec.Report(report.Terminal{}))
+}
+
+func testDetectReport(t *testing.T, when spec.G, it spec.S) {
+	when("#Redact", func() {
+		it("redacts the output and error of each element", func() {
+			detectReport := files.DetectReport{Groups: []files.DetectGroupReport{{
+				Result: "fail",
+				Elements: []files.DetectElementReport{
+					{ID: "some/bp", Result: "pass", Output: "printed some-secret"},
+					{ID: "other/bp", Result: "error", Error: "failed with some-secret"},
+				},
+			}}}
+
+			detectReport.Redact(func(s string) string { return strings.ReplaceAll(s, "some-secret", "******") })
+
+			h.AssertEq(t, detectReport.Groups[0].Elements[0].Output, "printed ******")
+			h.AssertEq(t, detectReport.Groups[0].Elements[1].Error, "failed with ******")
+		})
+	})
+}
@@ -0,0 +1,38 @@
+package files
+
+// ErrorReport is written by any phase that fails, so that platforms can explain the failure
+// (e.g., "buildpack X failed during build" or "registry authentication was denied for Y") without parsing the logs.
+// The location of the file can be specified by providing `-error-report <path>` to the lifecycle;
+// it is written as JSON if the path ends in `.json`, and as TOML otherwise.
+type ErrorReport struct {
+	Phase    string `json:"phase" toml:"phase"`
+	ExitCode int    `json:"exitCode" toml:"exit-code"`
+	// Type is the name of the platform.LifecycleExitError that the exit code corresponds to, if any (e.g., "FailedBuildWithErrors").
+	Type    string `json:"type,omitempty" toml:"type,omitempty"`
+	Message string `json:"message" toml:"message"`
+	// Causes is the chain of wrapped errors that resulted in the failure, outermost first.
+	Causes    []string              `json:"causes,omitempty" toml:"causes,omitempty"`
+	Buildpack *ErrorReportBuildpack `json:"buildpack,omitempty" toml:"buildpack,omitempty"`
+	// Image is the image that could not be accessed, if the failure was caused by registry access.
+	Image string `json:"image,omitempty" toml:"image,omitempty"`
+	// Hint suggests how the failure may be fixed.
+	Hint string `json:"hint,omitempty" toml:"hint,omitempty"`
+}
+
+// ErrorReportBuildpack identifies the buildpack or image extension that failed.
+type ErrorReportBuildpack struct {
+	ID        string `json:"id" toml:"id"`
+	Version   string `json:"version" toml:"version"`
+	Extension bool   `json:"extension,omitempty" toml:"extension,omitempty"`
+}
+
+// Redact replaces each message in the report with the result of the provided function (e.g., log.Redactor.Redact),
+// so that secrets included in error messages are not written to the report.
+func (r *ErrorReport) Redact(redact func(string) string) {
+	r.Message = redact(r.Message)
+	for i := range r.Causes {
+		r.Causes[i] = redact(r.Causes[i])
+	}
+	r.Image = redact(r.Image)
+	r.Hint = redact(r.Hint)
+}
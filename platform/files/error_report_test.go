@@ -0,0 +1,40 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestErrorReport(t *testing.T) {
+	spec.Run(t, "ErrorReport", testErrorReport, spec.Report(report.Terminal{}))
+}
+
+func testErrorReport(t *testing.T, when spec.G, it spec.S) {
+	when("#Redact", func() {
+		it("redacts each message", func() {
+			errorReport := files.ErrorReport{
+				Phase:   "some-phase",
+				Message: "failed: some-secret",
+				Causes:  []string{"failed: some-secret", "some-secret"},
+				Image:   "some-image",
+				Hint:    "some-hint with some-secret",
+			}
+
+			errorReport.Redact(func(s string) string { return strings.ReplaceAll(s, "some-secret", "******") })
+
+			h.AssertEq(t, errorReport, files.ErrorReport{
+				Phase:   "some-phase",
+				Message: "failed: ******",
+				Causes:  []string{"failed: ******", "******"},
+				Image:   "some-image",
+				Hint:    "some-hint with ******",
+			})
+		})
+	})
+}
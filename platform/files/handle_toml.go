@@ -4,6 +4,8 @@ package files
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 
@@ -154,6 +156,19 @@ func (h *TOMLHandler) ReadProjectMetadata(path string, logger log.Logger) (Proje
 	return projectMD, nil
 }
 
+// ReadReport reads the provided report.toml file.
+// It returns an empty report if the file does not exist.
+func (h *TOMLHandler) ReadReport(path string) (Report, error) {
+	var report Report
+	if _, err := toml.DecodeFile(path, &report); err != nil {
+		if os.IsNotExist(err) {
+			return Report{}, nil
+		}
+		return Report{}, fmt.Errorf("failed to read report file: %w", err)
+	}
+	return report, nil
+}
+
 // WriteReport writes the provided report information at the provided path.
 func (h *TOMLHandler) WriteReport(path string, report *Report) error {
 	if err := encoding.WriteTOML(path, report); err != nil {
@@ -162,6 +177,26 @@ func (h *TOMLHandler) WriteReport(path string, report *Report) error {
 	return nil
 }
 
+// WriteDetectReport writes the provided detect report at the provided path.
+func (h *TOMLHandler) WriteDetectReport(path string, report *DetectReport) error {
+	if err := encoding.WriteTOML(path, report); err != nil {
+		return fmt.Errorf("failed to write detect report file: %w", err)
+	}
+	return nil
+}
+
+// WriteErrorReport writes the provided error report at the provided path, as JSON if the path ends in `.json`.
+func (h *TOMLHandler) WriteErrorReport(path string, report *ErrorReport) error {
+	write := encoding.WriteTOML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		write = encoding.WriteJSON
+	}
+	if err := write(path, report); err != nil {
+		return fmt.Errorf("failed to write error report file: %w", err)
+	}
+	return nil
+}
+
 // WriteRebaseReport writes the provided report information at the provided path.
 func (h *TOMLHandler) WriteRebaseReport(path string, report *RebaseReport) error {
 	if err := encoding.WriteTOML(path, report); err != nil {
@@ -183,6 +218,23 @@ func (h *TOMLHandler) ReadRun(path string, logger log.Logger) (Run, error) {
 	return runMD, nil
 }
 
+// ReadRegistries reads the provided registries.toml file.
+// It returns empty registries configuration (i.e., no mirrors) if the file does not exist.
+func (h *TOMLHandler) ReadRegistries(path string, logger log.Logger) (Registries, error) {
+	var registries Registries
+	if _, err := toml.DecodeFile(path, &registries); err != nil {
+		if os.IsNotExist(err) {
+			logger.Debugf("No registries configuration found at path %q", path)
+			return Registries{}, nil
+		}
+		return Registries{}, fmt.Errorf("failed to read registries file: %w", err)
+	}
+	if err := registries.validate(); err != nil {
+		return Registries{}, fmt.Errorf("invalid registries file '%s': %w", path, err)
+	}
+	return registries, nil
+}
+
 // ReadStack reads the provided stack.toml file.
 func (h *TOMLHandler) ReadStack(path string, logger log.Logger) (Stack, error) {
 	var stackMD Stack
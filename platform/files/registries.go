@@ -0,0 +1,87 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Registries is provided by the platform as registries.toml to configure mirrors that images are pulled from,
+// e.g., pull-through caches, before falling back to the registry in the image reference,
+// and the TLS configuration for connections to registries, e.g., registries with a private certificate authority.
+// The location of the file can be specified by providing `-registries <path>` to the lifecycle.
+type Registries struct {
+	Registries []Registry `toml:"registry"`
+}
+
+// Registry configures the mirrors for the images in a registry or repository, and the TLS configuration for a registry.
+type Registry struct {
+	// Prefix is the registry (e.g., `docker.io`) or repository prefix (e.g., `docker.io/library`) of the images that are mirrored.
+	// When several prefixes match an image reference, the longest prefix is used.
+	Prefix string `toml:"prefix"`
+	// Mirrors are the registries or repositories (e.g., `mirror.internal/dockerhub`) to pull the images from, in order;
+	// the part of the image reference following the prefix is appended to the mirror.
+	Mirrors []string `toml:"mirrors,omitempty"`
+	// TLS configures the connections to the registry; it can only be provided when Prefix is a registry (e.g., `registry.internal:5000`).
+	TLS *RegistryTLS `toml:"tls,omitempty"`
+}
+
+// RegistryTLS configures the TLS connections to a registry.
+type RegistryTLS struct {
+	// CA is the path to a PEM bundle of certificate authorities to trust for the registry, in addition to the system roots.
+	CA string `toml:"ca,omitempty"`
+	// Cert and Key are the paths to the PEM certificate and key presented to a registry that requires client certificates (mTLS).
+	Cert string `toml:"cert,omitempty"`
+	Key  string `toml:"key,omitempty"`
+}
+
+// Mirrors returns the mirrors for each prefix.
+func (r Registries) Mirrors() map[string][]string {
+	if len(r.Registries) == 0 {
+		return nil
+	}
+	mirrors := map[string][]string{}
+	for _, registry := range r.Registries {
+		if len(registry.Mirrors) > 0 {
+			mirrors[registry.Prefix] = registry.Mirrors
+		}
+	}
+	return mirrors
+}
+
+// TLS returns the TLS configuration for each registry.
+func (r Registries) TLS() map[string]RegistryTLS {
+	configs := map[string]RegistryTLS{}
+	for _, registry := range r.Registries {
+		if registry.TLS != nil {
+			configs[registry.Prefix] = *registry.TLS
+		}
+	}
+	return configs
+}
+
+func (r Registries) validate() error {
+	seen := map[string]bool{}
+	for _, registry := range r.Registries {
+		if registry.Prefix == "" {
+			return errors.New("registry prefix must not be empty")
+		}
+		if seen[registry.Prefix] {
+			return fmt.Errorf("registry prefix '%s' is configured more than once", registry.Prefix)
+		}
+		seen[registry.Prefix] = true
+		if len(registry.Mirrors) == 0 && registry.TLS == nil {
+			return fmt.Errorf("no mirrors or TLS configured for registry prefix '%s'", registry.Prefix)
+		}
+		if registry.TLS == nil {
+			continue
+		}
+		if strings.Contains(strings.TrimSuffix(registry.Prefix, "/"), "/") {
+			return fmt.Errorf("TLS can only be configured for a registry, not for repository prefix '%s'", registry.Prefix)
+		}
+		if (registry.TLS.Cert == "") != (registry.TLS.Key == "") {
+			return fmt.Errorf("client certificate and key must be configured together for registry '%s'", registry.Prefix)
+		}
+	}
+	return nil
+}
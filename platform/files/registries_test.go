@@ -0,0 +1,161 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/log"
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRegistries(t *testing.T) {
+	spec.Run(t, "Registries", testRegistries, spec.Report(report.Terminal{}))
+}
+
+func testRegistries(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		logger *log.DefaultLogger
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.test")
+		h.AssertNil(t, err)
+		logger = log.NewDefaultLogger(os.Stdout)
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	when("#ReadRegistries", func() {
+		when("registries.toml exists", func() {
+			it("returns the mirrors for each prefix", func() {
+				h.Mkfile(t, `
+[[registry]]
+  prefix = "docker.io"
+  mirrors = ["mirror.internal/dockerhub", "other-mirror.internal/dockerhub"]
+
+[[registry]]
+  prefix = "some.registry/some-org"
+  mirrors = ["mirror.internal/some-org"]
+`, filepath.Join(tmpDir, "registries.toml"))
+
+				registries, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+				h.AssertNil(t, err)
+				h.AssertEq(t, registries.Mirrors(), map[string][]string{
+					"docker.io":              {"mirror.internal/dockerhub", "other-mirror.internal/dockerhub"},
+					"some.registry/some-org": {"mirror.internal/some-org"},
+				})
+			})
+		})
+
+		when("registries.toml configures TLS", func() {
+			it("returns the TLS configuration for each registry", func() {
+				h.Mkfile(t, `
+[[registry]]
+  prefix = "docker.io"
+  mirrors = ["registry.internal:5000/dockerhub"]
+
+[[registry]]
+  prefix = "registry.internal:5000"
+  [registry.tls]
+    ca = "/platform/certs/ca.pem"
+    cert = "/platform/certs/client.pem"
+    key = "/platform/certs/client-key.pem"
+`, filepath.Join(tmpDir, "registries.toml"))
+
+				registries, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+				h.AssertNil(t, err)
+				h.AssertEq(t, registries.Mirrors(), map[string][]string{
+					"docker.io": {"registry.internal:5000/dockerhub"},
+				})
+				h.AssertEq(t, registries.TLS(), map[string]files.RegistryTLS{
+					"registry.internal:5000": {
+						CA:   "/platform/certs/ca.pem",
+						Cert: "/platform/certs/client.pem",
+						Key:  "/platform/certs/client-key.pem",
+					},
+				})
+			})
+
+			when("the prefix is a repository", func() {
+				it("errors", func() {
+					h.Mkfile(t, `
+[[registry]]
+  prefix = "registry.internal:5000/some-org"
+  [registry.tls]
+    ca = "/platform/certs/ca.pem"
+`, filepath.Join(tmpDir, "registries.toml"))
+
+					_, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+					h.AssertError(t, err, "TLS can only be configured for a registry, not for repository prefix 'registry.internal:5000/some-org'")
+				})
+			})
+
+			when("a client certificate is configured without a key", func() {
+				it("errors", func() {
+					h.Mkfile(t, `
+[[registry]]
+  prefix = "registry.internal:5000"
+  [registry.tls]
+    cert = "/platform/certs/client.pem"
+`, filepath.Join(tmpDir, "registries.toml"))
+
+					_, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+					h.AssertError(t, err, "client certificate and key must be configured together for registry 'registry.internal:5000'")
+				})
+			})
+		})
+
+		when("registries.toml does not exist", func() {
+			it("returns no mirrors without error", func() {
+				registries, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "nonexistent.toml"), logger)
+
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(registries.Mirrors()), 0)
+			})
+		})
+
+		when("a prefix is configured more than once", func() {
+			it("errors", func() {
+				h.Mkfile(t, `
+[[registry]]
+  prefix = "docker.io"
+  mirrors = ["mirror.internal/dockerhub"]
+
+[[registry]]
+  prefix = "docker.io"
+  mirrors = ["other-mirror.internal/dockerhub"]
+`, filepath.Join(tmpDir, "registries.toml"))
+
+				_, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+				h.AssertError(t, err, "registry prefix 'docker.io' is configured more than once")
+			})
+		})
+
+		when("a prefix has no mirrors", func() {
+			it("errors", func() {
+				h.Mkfile(t, `
+[[registry]]
+  prefix = "docker.io"
+`, filepath.Join(tmpDir, "registries.toml"))
+
+				_, err := files.NewHandler().ReadRegistries(filepath.Join(tmpDir, "registries.toml"), logger)
+
+				h.AssertError(t, err, "no mirrors or TLS configured for registry prefix 'docker.io'")
+			})
+		})
+	})
+}
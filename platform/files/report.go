@@ -1,13 +1,20 @@
 package files
 
-import "github.com/buildpacks/lifecycle/buildpack"
+import (
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
 
 // Report is written by the exporter to record information about the build.
 // It is not included in the output image, but can be saved off by the platform before the build container exits.
 // The location of the file can be specified by providing `-report <path>` to the lifecycle.
 type Report struct {
-	Build BuildReport `toml:"build,omitempty"`
-	Image ImageReport `toml:"image"`
+	Build     BuildReport     `toml:"build,omitempty"`
+	Image     ImageReport     `toml:"image"`
+	Timings   TimingsReport   `toml:"timings,omitempty"`
+	Resources ResourcesReport `toml:"resources,omitempty"`
+	Network   NetworkReport   `toml:"network,omitempty"`
 }
 
 type BuildReport struct {
@@ -21,6 +28,67 @@ type ImageReport struct {
 	ManifestSize int64    `toml:"manifest-size,omitzero"`
 }
 
+// TimingsReport records the wall time of each phase of the build,
+// and of each `./bin/detect`, `./bin/generate`, and `./bin/build` process.
+// Each phase appends to the timings in report.toml, so that the report describes the entire build.
+type TimingsReport struct {
+	Phases     []PhaseTiming  `toml:"phases,omitempty"`
+	Buildpacks []ModuleTiming `toml:"buildpacks,omitempty"`
+}
+
+type PhaseTiming struct {
+	Phase    string        `toml:"phase"`
+	WallTime time.Duration `toml:"wall-time"`
+}
+
+// ModuleTiming records the wall time of a buildpack or image extension process;
+// Step is the executable that was run, e.g., "build".
+type ModuleTiming struct {
+	ID       string        `toml:"id"`
+	Version  string        `toml:"version,omitempty"`
+	Step     string        `toml:"step"`
+	WallTime time.Duration `toml:"wall-time"`
+}
+
+// ResourcesReport records the resources used by each `./bin/detect`, `./bin/generate`, and `./bin/build` process,
+// and the bytes transferred to and from the cache and the exported image.
+// Like timings, each phase appends to the resources in report.toml.
+type ResourcesReport struct {
+	Buildpacks []ModuleResources `toml:"buildpacks,omitempty"`
+	Cache      TransferReport    `toml:"cache,omitempty"`
+	// CacheEviction records the layers evicted from the cache directory when it was committed.
+	CacheEviction EvictionReport `toml:"cache-eviction,omitempty"`
+	// Registry records the bytes read from and written to registries that are not insecure, including manifests and configs;
+	// images in a daemon or in OCI layout format are not counted.
+	Registry TransferReport `toml:"registry,omitempty"`
+}
+
+type ModuleResources struct {
+	ID         string        `toml:"id"`
+	Version    string        `toml:"version,omitempty"`
+	Step       string        `toml:"step"`
+	UserTime   time.Duration `toml:"cpu-user-time"`
+	SystemTime time.Duration `toml:"cpu-system-time"`
+	MaxRSS     int64         `toml:"max-rss-bytes,omitzero"`
+}
+
+// NetworkReport records the network access of `./bin/detect`, `./bin/generate`, and `./bin/build`.
+// Like timings, each phase adds to the network section in report.toml.
+type NetworkReport struct {
+	// OfflineSteps lists the executables that were run without network access, e.g., "build".
+	OfflineSteps []string `toml:"offline-steps,omitempty"`
+}
+
+type EvictionReport struct {
+	Layers int   `toml:"layers,omitzero"`
+	Bytes  int64 `toml:"bytes,omitzero"`
+}
+
+type TransferReport struct {
+	BytesRead    int64 `toml:"bytes-read,omitzero"`
+	BytesWritten int64 `toml:"bytes-written,omitzero"`
+}
+
 // RebaseReport is written by the rebaser to record information about the rebased image.
 type RebaseReport struct {
 	Image ImageReport `toml:"image"`
@@ -0,0 +1,64 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/platform/files"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestReport(t *testing.T) {
+	spec.Run(t, "Report", testReport, spec.Report(report.Terminal{}))
+}
+
+func testReport(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "lifecycle.test")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	when("#ReadReport", func() {
+		it("reads the timings and resources written to the report", func() {
+			path := filepath.Join(tmpDir, "report.toml")
+			expected := files.Report{
+				Image: files.ImageReport{Tags: []string{"some-tag"}},
+				Timings: files.TimingsReport{
+					Phases:     []files.PhaseTiming{{Phase: "build", WallTime: 1500 * time.Millisecond}},
+					Buildpacks: []files.ModuleTiming{{ID: "some/buildpack", Version: "1.2.3", Step: "build", WallTime: time.Second}},
+				},
+				Resources: files.ResourcesReport{
+					Buildpacks: []files.ModuleResources{{ID: "some/buildpack", Version: "1.2.3", Step: "build", UserTime: time.Second, MaxRSS: 1024}},
+					Cache:      files.TransferReport{BytesRead: 10},
+				},
+			}
+			h.AssertNil(t, files.Handler.WriteReport(path, &expected))
+
+			contents := string(h.MustReadFile(t, path))
+			h.AssertStringContains(t, contents, `wall-time = "1.5s"`)
+			h.AssertStringDoesNotContain(t, contents, "bytes-written")
+
+			actual, err := files.Handler.ReadReport(path)
+			h.AssertNil(t, err)
+			h.AssertEq(t, actual, expected)
+		})
+
+		it("returns an empty report when the file does not exist", func() {
+			actual, err := files.Handler.ReadReport(filepath.Join(tmpDir, "missing.toml"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, actual, files.Report{})
+		})
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 
 	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/internal/str"
 	"github.com/buildpacks/lifecycle/log"
 )
@@ -20,51 +21,74 @@ import (
 // LifecycleInputs holds the values of command-line flags and args i.e., platform inputs to the lifecycle.
 // Fields are the cumulative total of inputs across all lifecycle phases and all supported Platform APIs.
 type LifecycleInputs struct {
-	PlatformAPI           *api.Version
-	AnalyzedPath          string
-	AppDir                string
-	BuildConfigDir        string
-	BuildImageRef         string
-	BuildpacksDir         string
-	CacheDir              string
-	CacheImageRef         string
-	DefaultProcessType    string
-	DeprecatedRunImageRef string
-	ExecEnv               string
-	ExtendKind            string
-	ExtendedDir           string
-	ExtensionsDir         string
-	GeneratedDir          string
-	GroupPath             string
-	KanikoDir             string
-	LaunchCacheDir        string
-	LauncherPath          string
-	LauncherSBOMDir       string
-	LayersDir             string
-	LayoutDir             string
-	LogLevel              string
-	OrderPath             string
-	OutputImageRef        string
-	PlanPath              string
-	PlatformDir           string
-	PreviousImageRef      string
-	ProjectMetadataPath   string
-	ReportPath            string
-	RunImageRef           string
-	RunPath               string
-	StackPath             string
-	SystemPath            string
-	UID                   int
-	GID                   int
-	ForceRebase           bool
-	NoColor               bool
-	ParallelExport        bool
-	SkipLayers            bool
-	UseDaemon             bool
-	UseLayout             bool
-	AdditionalTags        str.Slice // str.Slice satisfies the `Value` interface required by the `flag` package
-	KanikoCacheTTL        time.Duration
-	InsecureRegistries    str.Slice
+	PlatformAPI           *api.Version      `toml:"-"`
+	AnalyzedPath          string            `toml:"analyzed-path"`
+	AppDir                string            `toml:"app-dir"`
+	BuildConfigDir        string            `toml:"build-config-dir"`
+	BuildImageRef         string            `toml:"build-image"`
+	BuildpackTimeout      string            `toml:"buildpack-timeout"`
+	BuildpacksDir         string            `toml:"buildpacks-dir"`
+	CacheCompression      string            `toml:"cache-compression"`
+	CacheDir              string            `toml:"cache-dir"`
+	CacheImageRef         string            `toml:"cache-image"`
+	CacheMaxSize          string            `toml:"cache-max-size"`
+	CacheURL              string            `toml:"cache-url"`
+	ConfigPath            string            `toml:"-"` // the lifecycle config file, see ApplyConfigFile
+	DefaultProcessType    string            `toml:"process-type"`
+	DeprecatedRunImageRef string            `toml:"-"` // the deprecated `-image` flag, see excludedInputs
+	DetectReportPath      string            `toml:"detect-report-path"`
+	ErrorReportPath       string            `toml:"error-report-path"`
+	EventsPath            string            `toml:"events-path"`
+	ExecEnv               string            `toml:"exec-env"`
+	ExtendKind            string            `toml:"extend-kind"`
+	ExtendedDir           string            `toml:"extended-dir"`
+	ExtensionsDir         string            `toml:"extensions-dir"`
+	GeneratedDir          string            `toml:"generated-dir"`
+	GroupPath             string            `toml:"group-path"`
+	KanikoDir             string            `toml:"-"` // not configurable, see excludedInputs
+	LaunchCacheDir        string            `toml:"launch-cache-dir"`
+	LauncherPath          string            `toml:"launcher-path"`
+	LauncherSBOMDir       string            `toml:"launcher-sbom-dir"`
+	LayersDir             string            `toml:"layers-dir"`
+	LayoutDir             string            `toml:"layout-dir"`
+	LogLevel              string            `toml:"log-level"`
+	OrderPath             string            `toml:"order-path"`
+	OutputImageRef        string            `toml:"output-image"`
+	PlanPath              string            `toml:"plan-path"`
+	PlatformDir           string            `toml:"platform-dir"`
+	PreviousImageRef      string            `toml:"previous-image"`
+	ProjectMetadataPath   string            `toml:"project-metadata-path"`
+	RegistriesPath        string            `toml:"registries-path"`
+	ReportPath            string            `toml:"report-path"`
+	RunImageRef           string            `toml:"run-image"`
+	RunPath               string            `toml:"run-path"`
+	StackPath             string            `toml:"stack-path"`
+	TracesPath            string            `toml:"traces-path"`
+	Traceparent           string            `toml:"traceparent"`
+	SystemPath            string            `toml:"system-path"`
+	UID                   int               `toml:"user-id"`
+	GID                   int               `toml:"group-id"`
+	EventsFD              int               `toml:"events-fd"`
+	DetectParallelism     int               `toml:"detect-parallelism"`
+	RegistryRetries       int               `toml:"registry-retries"`
+	ForceRebase           bool              `toml:"force-rebase"`
+	NoColor               bool              `toml:"no-color"`
+	Offline               bool              `toml:"offline"`
+	ParallelExport        bool              `toml:"parallel-export"`
+	RecordDigests         bool              `toml:"record-digests"`
+	SkipLayers            bool              `toml:"skip-layers"`
+	UseDaemon             bool              `toml:"use-daemon"`
+	UseLayout             bool              `toml:"use-layout"`
+	VerifyDigests         bool              `toml:"verify-digests"`
+	AdditionalTags        str.Slice         `toml:"tags"` // str.Slice satisfies the `Value` interface required by the `flag` package
+	CacheMaxAge           time.Duration     `toml:"cache-max-age"`
+	KanikoCacheTTL        time.Duration     `toml:"kaniko-cache-ttl"`
+	RegistryRetryDelay    time.Duration     `toml:"registry-retry-delay"`
+	RegistryRetryMaxDelay time.Duration     `toml:"registry-retry-max-delay"`
+	InsecureRegistries    str.Slice         `toml:"insecure-registries"`
+	SecretEnvVars         str.Slice         `toml:"secret-env-vars"`
+	RegistryMirrors       image.Mirrors     `toml:"-"` // read from RegistriesPath by ResolveInputs
+	RegistryTLS           image.RegistryTLS `toml:"-"` // read from RegistriesPath by ResolveInputs
 }
 
 const PlaceholderLayers = "<layers>"
@@ -73,6 +97,7 @@ const PlaceholderLayers = "<layers>"
 // Inputs can be specified by the platform (in order of precedence) through:
 //   - command-line flags
 //   - environment variables
+//   - the lifecycle config file (see ApplyConfigFile)
 //   - falling back to the default value
 //
 // NewLifecycleInputs provides, for each input, the value from the environment if specified, falling back to the default.
@@ -91,14 +116,25 @@ func NewLifecycleInputs(platformAPI *api.Version) *LifecycleInputs {
 	inputs := &LifecycleInputs{
 		// Operator config
 
+		ConfigPath:         os.Getenv(EnvConfigPath),
 		LogLevel:           envOrDefault(EnvLogLevel, DefaultLogLevel),
 		NoColor:            boolEnv(EnvNoColor),
+		EventsPath:         os.Getenv(EnvEventsPath),
+		EventsFD:           intEnv(EnvEventsFD),
+		TracesPath:         os.Getenv(EnvTracesPath),
+		Traceparent:        os.Getenv(EnvTraceparent),
 		PlatformAPI:        platformAPI,
 		ExtendKind:         envOrDefault(EnvExtendKind, DefaultExtendKind),
 		UseDaemon:          boolEnv(EnvUseDaemon),
 		InsecureRegistries: sliceEnv(EnvInsecureRegistries),
+		SecretEnvVars:      sliceEnv(EnvSecretEnvVars),
+		RegistriesPath:     envOrDefault(EnvRegistriesPath, DefaultRegistriesPath),
 		UseLayout:          boolEnv(EnvUseLayout),
 
+		RegistryRetries:       intEnvOrDefault(EnvRegistryRetries, DefaultRegistryRetries),
+		RegistryRetryDelay:    timeEnvOrDefault(EnvRegistryRetryDelay, DefaultRegistryRetryDelay),
+		RegistryRetryMaxDelay: timeEnvOrDefault(EnvRegistryRetryMaxDelay, DefaultRegistryRetryMaxDelay),
+
 		// Provided by the base image
 
 		UID: intEnv(EnvUID),
@@ -115,31 +151,42 @@ func NewLifecycleInputs(platformAPI *api.Version) *LifecycleInputs {
 
 		// Provided at build time
 
-		AppDir:      envOrDefault(EnvAppDir, DefaultAppDir),
-		ExecEnv:     envOrDefault(EnvExecEnv, DefaultExecEnv),
-		LayersDir:   envOrDefault(EnvLayersDir, DefaultLayersDir),
-		LayoutDir:   os.Getenv(EnvLayoutDir),
-		OrderPath:   envOrDefault(EnvOrderPath, filepath.Join(PlaceholderLayers, DefaultOrderFile)),
-		PlatformDir: envOrDefault(EnvPlatformDir, DefaultPlatformDir),
+		AppDir:            envOrDefault(EnvAppDir, DefaultAppDir),
+		BuildpackTimeout:  os.Getenv(EnvBuildpackTimeout),
+		DetectParallelism: intEnv(EnvDetectParallelism),
+		ExecEnv:           envOrDefault(EnvExecEnv, DefaultExecEnv),
+		LayersDir:         envOrDefault(EnvLayersDir, DefaultLayersDir),
+		LayoutDir:         os.Getenv(EnvLayoutDir),
+		Offline:           boolEnv(EnvOffline),
+		OrderPath:         envOrDefault(EnvOrderPath, filepath.Join(PlaceholderLayers, DefaultOrderFile)),
+		PlatformDir:       envOrDefault(EnvPlatformDir, DefaultPlatformDir),
+		RecordDigests:     boolEnv(EnvRecordDigests),
+		VerifyDigests:     boolEnv(EnvVerifyDigests),
 
 		// The following instruct the lifecycle where to write files and data during the build
 
-		AnalyzedPath: envOrDefault(EnvAnalyzedPath, filepath.Join(PlaceholderLayers, DefaultAnalyzedFile)),
-		ExtendedDir:  envOrDefault(EnvExtendedDir, filepath.Join(PlaceholderLayers, DefaultExtendedDir)),
-		GeneratedDir: envOrDefault(EnvGeneratedDir, filepath.Join(PlaceholderLayers, DefaultGeneratedDir)),
-		GroupPath:    envOrDefault(EnvGroupPath, filepath.Join(PlaceholderLayers, DefaultGroupFile)),
-		PlanPath:     envOrDefault(EnvPlanPath, filepath.Join(PlaceholderLayers, DefaultPlanFile)),
-		ReportPath:   envOrDefault(EnvReportPath, filepath.Join(PlaceholderLayers, DefaultReportFile)),
+		AnalyzedPath:     envOrDefault(EnvAnalyzedPath, filepath.Join(PlaceholderLayers, DefaultAnalyzedFile)),
+		DetectReportPath: os.Getenv(EnvDetectReportPath),
+		ErrorReportPath:  envOrDefault(EnvErrorReportPath, filepath.Join(PlaceholderLayers, DefaultErrorReportFile)),
+		ExtendedDir:      envOrDefault(EnvExtendedDir, filepath.Join(PlaceholderLayers, DefaultExtendedDir)),
+		GeneratedDir:     envOrDefault(EnvGeneratedDir, filepath.Join(PlaceholderLayers, DefaultGeneratedDir)),
+		GroupPath:        envOrDefault(EnvGroupPath, filepath.Join(PlaceholderLayers, DefaultGroupFile)),
+		PlanPath:         envOrDefault(EnvPlanPath, filepath.Join(PlaceholderLayers, DefaultPlanFile)),
+		ReportPath:       envOrDefault(EnvReportPath, filepath.Join(PlaceholderLayers, DefaultReportFile)),
 
 		// Configuration options with respect to caching
 
-		CacheDir:       os.Getenv(EnvCacheDir),
-		CacheImageRef:  os.Getenv(EnvCacheImage),
-		KanikoCacheTTL: timeEnvOrDefault(EnvKanikoCacheTTL, DefaultKanikoCacheTTL),
-		KanikoDir:      "/kaniko",
-		LaunchCacheDir: os.Getenv(EnvLaunchCacheDir),
-		SkipLayers:     skipLayers,
-		ParallelExport: boolEnv(EnvParallelExport),
+		CacheCompression: os.Getenv(EnvCacheCompression),
+		CacheDir:         os.Getenv(EnvCacheDir),
+		CacheImageRef:    os.Getenv(EnvCacheImage),
+		CacheMaxAge:      timeEnvOrDefault(EnvCacheMaxAge, 0),
+		CacheMaxSize:     os.Getenv(EnvCacheMaxSize),
+		CacheURL:         os.Getenv(EnvCacheURL),
+		KanikoCacheTTL:   timeEnvOrDefault(EnvKanikoCacheTTL, DefaultKanikoCacheTTL),
+		KanikoDir:        "/kaniko",
+		LaunchCacheDir:   os.Getenv(EnvLaunchCacheDir),
+		SkipLayers:       skipLayers,
+		ParallelExport:   boolEnv(EnvParallelExport),
 
 		// Images used by the lifecycle during the build
 
@@ -204,6 +251,10 @@ func (i *LifecycleInputs) RegistryImages() []string {
 		return ret
 	}
 	ret = appendOnce(ret, i.Images()...)
+	for _, imageRef := range ret {
+		// credentials for mirrors must also be resolved
+		ret = appendOnce(ret, i.RegistryMirrors.Candidates(imageRef)...)
+	}
 	return ret
 }
 
@@ -258,6 +309,14 @@ func intEnv(k string) int {
 	return d
 }
 
+func intEnvOrDefault(k string, defaultVal int) int {
+	d, err := strconv.Atoi(os.Getenv(k))
+	if err != nil {
+		return defaultVal
+	}
+	return d
+}
+
 func timeEnvOrDefault(key string, defaultVal time.Duration) time.Duration {
 	envTTL := os.Getenv(key)
 	if envTTL == "" {
@@ -308,6 +367,7 @@ func isPlaceholderOrder(s string) bool {
 func (i *LifecycleInputs) placeholderPaths() []*string {
 	return []*string{
 		&i.AnalyzedPath,
+		&i.ErrorReportPath,
 		&i.ExtendedDir,
 		&i.GeneratedDir,
 		&i.GroupPath,
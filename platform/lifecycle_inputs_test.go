@@ -35,10 +35,20 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, inputs.BuildConfigDir, platform.DefaultBuildConfigDir)
 			h.AssertEq(t, inputs.BuildImageRef, "")
 			h.AssertEq(t, inputs.BuildpacksDir, platform.DefaultBuildpacksDir)
+			h.AssertEq(t, inputs.BuildpackTimeout, "")
 			h.AssertEq(t, inputs.CacheDir, "")
 			h.AssertEq(t, inputs.CacheImageRef, "")
+			h.AssertEq(t, inputs.CacheMaxAge, time.Duration(0))
+			h.AssertEq(t, inputs.CacheMaxSize, "")
+			h.AssertEq(t, inputs.CacheURL, "")
+			h.AssertEq(t, inputs.CacheCompression, "")
+			h.AssertEq(t, inputs.ConfigPath, "")
 			h.AssertEq(t, inputs.DefaultProcessType, "")
 			h.AssertEq(t, inputs.DeprecatedRunImageRef, "")
+			h.AssertEq(t, inputs.DetectParallelism, 0)
+			h.AssertEq(t, inputs.DetectReportPath, "")
+			h.AssertEq(t, inputs.EventsFD, 0)
+			h.AssertEq(t, inputs.EventsPath, "")
 			h.AssertEq(t, inputs.ExtendKind, "build")
 			h.AssertEq(t, inputs.ExtensionsDir, platform.DefaultExtensionsDir)
 			h.AssertEq(t, inputs.ForceRebase, false)
@@ -50,18 +60,28 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 			h.AssertEq(t, inputs.LauncherSBOMDir, platform.DefaultBuildpacksioSBOMDir)
 			h.AssertEq(t, inputs.LayersDir, platform.DefaultLayersDir)
 			h.AssertEq(t, inputs.LogLevel, "info")
+			h.AssertEq(t, inputs.Offline, false)
 			h.AssertEq(t, inputs.OutputImageRef, "")
 			h.AssertEq(t, inputs.PlatformAPI, platformAPI) // from constructor
 			h.AssertEq(t, inputs.PlatformDir, platform.DefaultPlatformDir)
 			h.AssertEq(t, inputs.PreviousImageRef, "")
+			h.AssertEq(t, inputs.RecordDigests, false)
+			h.AssertEq(t, inputs.RegistriesPath, platform.DefaultRegistriesPath)
+			h.AssertEq(t, inputs.RegistryRetries, platform.DefaultRegistryRetries)
+			h.AssertEq(t, inputs.RegistryRetryDelay, platform.DefaultRegistryRetryDelay)
+			h.AssertEq(t, inputs.RegistryRetryMaxDelay, platform.DefaultRegistryRetryMaxDelay)
 			h.AssertEq(t, inputs.RunImageRef, "")
 			h.AssertEq(t, inputs.RunPath, platform.DefaultRunPath)
 			h.AssertEq(t, inputs.SkipLayers, false)
 			h.AssertEq(t, inputs.StackPath, platform.DefaultStackPath)
+			h.AssertEq(t, inputs.TracesPath, "")
+			h.AssertEq(t, inputs.Traceparent, "")
 			h.AssertEq(t, inputs.UID, 0)
 			h.AssertEq(t, inputs.UseDaemon, false)
 			h.AssertEq(t, inputs.UseLayout, false)
+			h.AssertEq(t, inputs.VerifyDigests, false)
 			h.AssertEq(t, inputs.InsecureRegistries, str.Slice(nil))
+			h.AssertEq(t, inputs.SecretEnvVars, str.Slice(nil))
 		})
 
 		when("env vars are set", func() {
@@ -71,8 +91,18 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertNil(t, os.Setenv(platform.EnvBuildConfigDir, "some-build-config-dir"))
 				h.AssertNil(t, os.Setenv(platform.EnvBuildImage, "some-build-image"))
 				h.AssertNil(t, os.Setenv(platform.EnvBuildpacksDir, "some-buildpacks-dir"))
+				h.AssertNil(t, os.Setenv(platform.EnvBuildpackTimeout, "15m,some/buildpack=1h"))
 				h.AssertNil(t, os.Setenv(platform.EnvCacheDir, "some-cache-dir"))
+				h.AssertNil(t, os.Setenv(platform.EnvCacheMaxAge, "168h"))
+				h.AssertNil(t, os.Setenv(platform.EnvCacheMaxSize, "10G"))
+				h.AssertNil(t, os.Setenv(platform.EnvCacheURL, "s3://some-bucket/some-prefix"))
+				h.AssertNil(t, os.Setenv(platform.EnvCacheCompression, "zstd"))
 				h.AssertNil(t, os.Setenv(platform.EnvCacheImage, "some-cache-image"))
+				h.AssertNil(t, os.Setenv(platform.EnvDetectParallelism, "8"))
+				h.AssertNil(t, os.Setenv(platform.EnvDetectReportPath, "some-detect-report-path"))
+				h.AssertNil(t, os.Setenv(platform.EnvErrorReportPath, "some-error-report-path"))
+				h.AssertNil(t, os.Setenv(platform.EnvEventsFD, "3"))
+				h.AssertNil(t, os.Setenv(platform.EnvEventsPath, "some-events-path"))
 				h.AssertNil(t, os.Setenv(platform.EnvExtendKind, "run"))
 				h.AssertNil(t, os.Setenv(platform.EnvExtensionsDir, "some-extensions-dir"))
 				h.AssertNil(t, os.Setenv(platform.EnvGID, "5678"))
@@ -84,20 +114,31 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertNil(t, os.Setenv(platform.EnvLayersDir, "some-layers-dir"))
 				h.AssertNil(t, os.Setenv(platform.EnvLayoutDir, "some-layout-dir"))
 				h.AssertNil(t, os.Setenv(platform.EnvLogLevel, "debug"))
+				h.AssertNil(t, os.Setenv(platform.EnvOffline, "true"))
 				h.AssertNil(t, os.Setenv(platform.EnvOrderPath, "some-order-path"))
 				h.AssertNil(t, os.Setenv(platform.EnvPlanPath, "some-plan-path"))
 				h.AssertNil(t, os.Setenv(platform.EnvPlatformDir, "some-platform-dir"))
 				h.AssertNil(t, os.Setenv(platform.EnvPreviousImage, "some-previous-image"))
 				h.AssertNil(t, os.Setenv(platform.EnvProcessType, "some-process-type"))
+				h.AssertNil(t, os.Setenv(platform.EnvRecordDigests, "true"))
+				h.AssertNil(t, os.Setenv(platform.EnvRegistriesPath, "some-registries-path"))
+				h.AssertNil(t, os.Setenv(platform.EnvRegistryRetries, "0"))
+				h.AssertNil(t, os.Setenv(platform.EnvRegistryRetryDelay, "1s"))
+				h.AssertNil(t, os.Setenv(platform.EnvRegistryRetryMaxDelay, "5m"))
 				h.AssertNil(t, os.Setenv(platform.EnvReportPath, "some-report-path"))
 				h.AssertNil(t, os.Setenv(platform.EnvRunImage, "some-run-image"))
 				h.AssertNil(t, os.Setenv(platform.EnvRunPath, "some-run-path"))
 				h.AssertNil(t, os.Setenv(platform.EnvSkipLayers, "true"))
 				h.AssertNil(t, os.Setenv(platform.EnvStackPath, "some-stack-path"))
+				h.AssertNil(t, os.Setenv(platform.EnvTracesPath, "some-traces-path"))
+				h.AssertNil(t, os.Setenv(platform.EnvTraceparent, "some-traceparent"))
 				h.AssertNil(t, os.Setenv(platform.EnvUID, "1234"))
 				h.AssertNil(t, os.Setenv(platform.EnvUseDaemon, "true"))
 				h.AssertNil(t, os.Setenv(platform.EnvUseLayout, "true"))
+				h.AssertNil(t, os.Setenv(platform.EnvVerifyDigests, "true"))
 				h.AssertNil(t, os.Setenv(platform.EnvInsecureRegistries, "some-insecure-registry,another-insecure-registry,just-another-registry"))
+				h.AssertNil(t, os.Setenv(platform.EnvSecretEnvVars, "SOME_SECRET,OTHER_SECRET"))
+				h.AssertNil(t, os.Setenv(platform.EnvConfigPath, "some-config-path"))
 			})
 
 			it.After(func() {
@@ -106,8 +147,18 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertNil(t, os.Unsetenv(platform.EnvBuildConfigDir))
 				h.AssertNil(t, os.Unsetenv(platform.EnvBuildImage))
 				h.AssertNil(t, os.Unsetenv(platform.EnvBuildpacksDir))
+				h.AssertNil(t, os.Unsetenv(platform.EnvBuildpackTimeout))
 				h.AssertNil(t, os.Unsetenv(platform.EnvCacheDir))
+				h.AssertNil(t, os.Unsetenv(platform.EnvCacheMaxAge))
+				h.AssertNil(t, os.Unsetenv(platform.EnvCacheMaxSize))
+				h.AssertNil(t, os.Unsetenv(platform.EnvCacheURL))
+				h.AssertNil(t, os.Unsetenv(platform.EnvCacheCompression))
 				h.AssertNil(t, os.Unsetenv(platform.EnvCacheImage))
+				h.AssertNil(t, os.Unsetenv(platform.EnvDetectParallelism))
+				h.AssertNil(t, os.Unsetenv(platform.EnvDetectReportPath))
+				h.AssertNil(t, os.Unsetenv(platform.EnvErrorReportPath))
+				h.AssertNil(t, os.Unsetenv(platform.EnvEventsFD))
+				h.AssertNil(t, os.Unsetenv(platform.EnvEventsPath))
 				h.AssertNil(t, os.Unsetenv(platform.EnvExtendKind))
 				h.AssertNil(t, os.Unsetenv(platform.EnvExtensionsDir))
 				h.AssertNil(t, os.Unsetenv(platform.EnvForceRebase))
@@ -119,20 +170,31 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertNil(t, os.Unsetenv(platform.EnvLayersDir))
 				h.AssertNil(t, os.Unsetenv(platform.EnvLayoutDir))
 				h.AssertNil(t, os.Unsetenv(platform.EnvLogLevel))
+				h.AssertNil(t, os.Unsetenv(platform.EnvOffline))
 				h.AssertNil(t, os.Unsetenv(platform.EnvOrderPath))
 				h.AssertNil(t, os.Unsetenv(platform.EnvPlanPath))
 				h.AssertNil(t, os.Unsetenv(platform.EnvPlatformDir))
 				h.AssertNil(t, os.Unsetenv(platform.EnvPreviousImage))
 				h.AssertNil(t, os.Unsetenv(platform.EnvProcessType))
+				h.AssertNil(t, os.Unsetenv(platform.EnvRecordDigests))
+				h.AssertNil(t, os.Unsetenv(platform.EnvRegistriesPath))
+				h.AssertNil(t, os.Unsetenv(platform.EnvRegistryRetries))
+				h.AssertNil(t, os.Unsetenv(platform.EnvRegistryRetryDelay))
+				h.AssertNil(t, os.Unsetenv(platform.EnvRegistryRetryMaxDelay))
 				h.AssertNil(t, os.Unsetenv(platform.EnvReportPath))
 				h.AssertNil(t, os.Unsetenv(platform.EnvRunImage))
 				h.AssertNil(t, os.Unsetenv(platform.EnvRunPath))
 				h.AssertNil(t, os.Unsetenv(platform.EnvSkipLayers))
 				h.AssertNil(t, os.Unsetenv(platform.EnvStackPath))
+				h.AssertNil(t, os.Unsetenv(platform.EnvTracesPath))
+				h.AssertNil(t, os.Unsetenv(platform.EnvTraceparent))
 				h.AssertNil(t, os.Unsetenv(platform.EnvUID))
 				h.AssertNil(t, os.Unsetenv(platform.EnvUseDaemon))
 				h.AssertNil(t, os.Unsetenv(platform.EnvUseLayout))
+				h.AssertNil(t, os.Unsetenv(platform.EnvVerifyDigests))
 				h.AssertNil(t, os.Unsetenv(platform.EnvInsecureRegistries))
+				h.AssertNil(t, os.Unsetenv(platform.EnvSecretEnvVars))
+				h.AssertNil(t, os.Unsetenv(platform.EnvConfigPath))
 			})
 
 			it("returns lifecycle inputs with env values fill in", func() {
@@ -144,10 +206,21 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, inputs.BuildConfigDir, "some-build-config-dir")
 				h.AssertEq(t, inputs.BuildImageRef, "some-build-image")
 				h.AssertEq(t, inputs.BuildpacksDir, "some-buildpacks-dir")
+				h.AssertEq(t, inputs.BuildpackTimeout, "15m,some/buildpack=1h")
 				h.AssertEq(t, inputs.CacheDir, "some-cache-dir")
 				h.AssertEq(t, inputs.CacheImageRef, "some-cache-image")
+				h.AssertEq(t, inputs.CacheMaxAge, 168*time.Hour)
+				h.AssertEq(t, inputs.CacheMaxSize, "10G")
+				h.AssertEq(t, inputs.CacheURL, "s3://some-bucket/some-prefix")
+				h.AssertEq(t, inputs.CacheCompression, "zstd")
+				h.AssertEq(t, inputs.ConfigPath, "some-config-path")
 				h.AssertEq(t, inputs.DefaultProcessType, "some-process-type")
 				h.AssertEq(t, inputs.DeprecatedRunImageRef, "")
+				h.AssertEq(t, inputs.DetectParallelism, 8)
+				h.AssertEq(t, inputs.DetectReportPath, "some-detect-report-path")
+				h.AssertEq(t, inputs.ErrorReportPath, "some-error-report-path")
+				h.AssertEq(t, inputs.EventsFD, 3)
+				h.AssertEq(t, inputs.EventsPath, "some-events-path")
 				h.AssertEq(t, inputs.ExtendKind, "run")
 				h.AssertEq(t, inputs.ExtensionsDir, "some-extensions-dir")
 				h.AssertEq(t, inputs.ForceRebase, true)
@@ -161,25 +234,35 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, inputs.LayersDir, "some-layers-dir")
 				h.AssertEq(t, inputs.LayoutDir, "some-layout-dir")
 				h.AssertEq(t, inputs.LogLevel, "debug")
+				h.AssertEq(t, inputs.Offline, true)
 				h.AssertEq(t, inputs.OrderPath, "some-order-path")
 				h.AssertEq(t, inputs.OutputImageRef, "")
 				h.AssertEq(t, inputs.PlanPath, "some-plan-path")
 				h.AssertEq(t, inputs.PlatformAPI, platformAPI) // from constructor
 				h.AssertEq(t, inputs.PlatformDir, "some-platform-dir")
 				h.AssertEq(t, inputs.PreviousImageRef, "some-previous-image")
+				h.AssertEq(t, inputs.RecordDigests, true)
+				h.AssertEq(t, inputs.RegistriesPath, "some-registries-path")
+				h.AssertEq(t, inputs.RegistryRetries, 0)
+				h.AssertEq(t, inputs.RegistryRetryDelay, 1*time.Second)
+				h.AssertEq(t, inputs.RegistryRetryMaxDelay, 5*time.Minute)
 				h.AssertEq(t, inputs.ReportPath, "some-report-path")
 				h.AssertEq(t, inputs.RunImageRef, "some-run-image")
 				h.AssertEq(t, inputs.RunPath, "some-run-path")
 				h.AssertEq(t, inputs.SkipLayers, true)
 				h.AssertEq(t, inputs.StackPath, "some-stack-path")
+				h.AssertEq(t, inputs.TracesPath, "some-traces-path")
+				h.AssertEq(t, inputs.Traceparent, "some-traceparent")
 				h.AssertEq(t, inputs.UID, 1234)
 				h.AssertEq(t, inputs.UseDaemon, true)
 				h.AssertEq(t, inputs.UseLayout, true)
+				h.AssertEq(t, inputs.VerifyDigests, true)
 				h.AssertEq(t, inputs.InsecureRegistries, str.Slice{
 					"some-insecure-registry",
 					"another-insecure-registry",
 					"just-another-registry",
 				})
+				h.AssertEq(t, inputs.SecretEnvVars, str.Slice{"SOME_SECRET", "OTHER_SECRET"})
 			})
 		})
 
@@ -187,6 +270,7 @@ func testLifecycleInputs(t *testing.T, when spec.G, it spec.S) {
 			inputs = platform.NewLifecycleInputs(platformAPI)
 
 			h.AssertEq(t, inputs.AnalyzedPath, filepath.Join("<layers>", "analyzed.toml"))
+			h.AssertEq(t, inputs.ErrorReportPath, filepath.Join("<layers>", "error.toml"))
 			h.AssertEq(t, inputs.GeneratedDir, filepath.Join("<layers>", "generated"))
 			h.AssertEq(t, inputs.GroupPath, filepath.Join("<layers>", "group.toml"))
 			h.AssertEq(t, inputs.OrderPath, filepath.Join("<layers>", "order.toml"))
@@ -1,6 +1,8 @@
 package platform
 
 import (
+	"fmt"
+
 	"github.com/buildpacks/lifecycle/api"
 )
 
@@ -17,6 +19,32 @@ const (
 	Rebase
 )
 
+var phaseNames = map[LifecyclePhase]string{
+	Analyze: "analyze",
+	Detect:  "detect",
+	Restore: "restore",
+	Extend:  "extend",
+	Build:   "build",
+	Export:  "export",
+	Create:  "create",
+	Rebase:  "rebase",
+}
+
+// String returns the name of the phase, as used for the lifecycle subcommand.
+func (p LifecyclePhase) String() string {
+	return phaseNames[p]
+}
+
+// ParseLifecyclePhase returns the phase with the provided name (e.g., `analyze`).
+func ParseLifecyclePhase(name string) (LifecyclePhase, error) {
+	for phase, phaseName := range phaseNames {
+		if phaseName == name {
+			return phase, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown phase '%s'", name)
+}
+
 // Platform holds lifecycle inputs and outputs for a given Platform API version and lifecycle phase.
 type Platform struct {
 	*LifecycleInputs
@@ -1,12 +1,14 @@
 package platform_test
 
 import (
+	"io"
 	"testing"
 
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpacks/lifecycle/api"
+	llog "github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
@@ -31,5 +33,35 @@ func testPlatform(platformAPI *api.Version) func(t *testing.T, when spec.G, it s
 				h.AssertEq(t, foundPlatform.API(), platformAPI)
 			})
 		})
+
+		when("#ParseLifecyclePhase", func() {
+			it("returns the phase with the name", func() {
+				for _, phase := range []platform.LifecyclePhase{platform.Analyze, platform.Detect, platform.Restore, platform.Extend, platform.Build, platform.Export, platform.Create, platform.Rebase} {
+					parsed, err := platform.ParseLifecyclePhase(phase.String())
+					h.AssertNil(t, err)
+					h.AssertEq(t, parsed, phase)
+				}
+			})
+
+			it("errors for an unknown phase", func() {
+				_, err := platform.ParseLifecyclePhase("launch")
+				h.AssertError(t, err, "unknown phase 'launch'")
+			})
+		})
+
+		when("#ValidateInputs", func() {
+			it("returns every error", func() {
+				inputs := platform.NewLifecycleInputs(platformAPI)
+				inputs.RunImageRef = "some-run-image"
+				inputs.BuildpackTimeout = "not-a-timeout"
+				inputs.UseDaemon = true
+
+				errs := platform.ValidateInputs(platform.Create, inputs, llog.NewDefaultLogger(io.Discard))
+
+				h.AssertEq(t, len(errs), 2)
+				h.AssertError(t, errs[0], platform.ErrOutputImageRequired)
+				h.AssertError(t, errs[1], "not-a-timeout")
+			})
+		})
 	}
 }
@@ -57,6 +57,51 @@ func testResolveCreateInputs(platformAPI string) func(t *testing.T, when spec.G,
 				})
 			})
 
+			when("buildpack timeout is invalid", func() {
+				it("errors", func() {
+					inputs.BuildpackTimeout = "some/buildpack=forever"
+					err := platform.ResolveInputs(platform.Create, inputs, logger)
+					h.AssertError(t, err, "failed to parse timeout 'some/buildpack=forever'")
+				})
+			})
+
+			when("cache max size is invalid", func() {
+				it("errors", func() {
+					inputs.CacheMaxSize = "lots"
+					err := platform.ResolveInputs(platform.Create, inputs, logger)
+					h.AssertError(t, err, "invalid cache max size: failed to parse size 'lots'")
+				})
+			})
+
+			when("cache compression is invalid", func() {
+				it("errors", func() {
+					inputs.CacheCompression = "lzma"
+					err := platform.ResolveInputs(platform.Create, inputs, logger)
+					h.AssertError(t, err, "invalid cache compression 'lzma': must be 'gzip' or 'zstd'")
+				})
+			})
+
+			when("cache URL is invalid", func() {
+				it("errors", func() {
+					inputs.CacheURL = "gs://some-bucket/some-prefix"
+					err := platform.ResolveInputs(platform.Create, inputs, logger)
+					h.AssertError(t, err, "invalid cache URL: unsupported scheme 'gs'")
+				})
+
+				it("errors when an OCI layout cache URL has no path", func() {
+					inputs.CacheURL = "oci:"
+					err := platform.ResolveInputs(platform.Create, inputs, logger)
+					h.AssertError(t, err, "invalid cache URL: missing OCI layout path")
+				})
+			})
+
+			when("cache URL is an OCI layout path", func() {
+				it("is valid", func() {
+					inputs.CacheURL = "oci:/some/layout/cache"
+					h.AssertNil(t, platform.ResolveInputs(platform.Create, inputs, logger))
+				})
+			})
+
 			when("run image", func() {
 				when("not provided", func() {
 					it.Before(func() {
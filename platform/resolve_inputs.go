@@ -2,10 +2,16 @@ package platform
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/google/go-containerregistry/pkg/name"
 
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/internal/str"
 	"github.com/buildpacks/lifecycle/log"
 	"github.com/buildpacks/lifecycle/platform/files"
 )
@@ -26,11 +32,33 @@ var (
 )
 
 func ResolveInputs(phase LifecyclePhase, i *LifecycleInputs, logger log.Logger) error {
+	for _, op := range operationsFor(phase) {
+		if err := op(i, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateInputs is like ResolveInputs, but continues after an operation fails in order to return every error,
+// e.g., so that all problems with the inputs for a phase can be reported at once.
+func ValidateInputs(phase LifecyclePhase, i *LifecycleInputs, logger log.Logger) []error {
+	var errs []error
+	for _, op := range operationsFor(phase) {
+		if err := op(i, logger); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func operationsFor(phase LifecyclePhase) []LifecycleInputsOperation {
 	// order of operations is important
 	ops := []LifecycleInputsOperation{UpdatePlaceholderPaths, ResolveAbsoluteDirPaths}
 	switch phase {
 	case Analyze:
 		ops = append(ops,
+			ReadRegistries,
 			ValidateOutputImageProvided,
 			FillAnalyzeImages,
 			CheckLaunchCache,
@@ -39,48 +67,51 @@ func ResolveInputs(phase LifecyclePhase, i *LifecycleInputs, logger log.Logger)
 			CheckParallelExport,
 		)
 	case Build:
-		// nop
+		ops = append(ops, ValidateBuildpackTimeout)
 	case Create:
 		ops = append(ops,
+			ReadRegistries,
 			ValidateOutputImageProvided,
+			ValidateBuildpackTimeout,
 			FillCreateImages,
 			CheckCache,
+			ValidateCacheURL,
+			ValidateCacheEviction,
+			ValidateCacheCompression,
 			CheckLaunchCache,
 			ValidateImageRefs,
 			ValidateTargetsAreSameRegistry,
 			CheckParallelExport,
 		)
 	case Detect:
-		// nop
+		ops = append(ops, ValidateBuildpackTimeout)
 	case Export:
 		ops = append(ops,
+			ReadRegistries,
 			ValidateOutputImageProvided,
 			FillExportRunImage,
 			CheckCache,
+			ValidateCacheURL,
+			ValidateCacheEviction,
+			ValidateCacheCompression,
 			CheckLaunchCache,
 			ValidateImageRefs,
 			ValidateTargetsAreSameRegistry,
 		)
 	case Extend:
-		// nop
+		ops = append(ops, ReadRegistries)
 	case Rebase:
 		ops = append(ops,
+			ReadRegistries,
 			ValidateOutputImageProvided,
 			ValidateRebaseRunImage,
 			ValidateImageRefs,
 			ValidateTargetsAreSameRegistry,
 		)
 	case Restore:
-		ops = append(ops, CheckCache)
-	}
-
-	var err error
-	for _, op := range ops {
-		if err = op(i, logger); err != nil {
-			return err
-		}
+		ops = append(ops, ReadRegistries, CheckCache, ValidateCacheURL)
 	}
-	return nil
+	return ops
 }
 
 // operations
@@ -88,7 +119,7 @@ func ResolveInputs(phase LifecyclePhase, i *LifecycleInputs, logger log.Logger)
 type LifecycleInputsOperation func(i *LifecycleInputs, logger log.Logger) error
 
 func CheckCache(i *LifecycleInputs, logger log.Logger) error {
-	if i.CacheImageRef == "" && i.CacheDir == "" {
+	if i.CacheImageRef == "" && i.CacheURL == "" && i.CacheDir == "" {
 		logger.Warn("No cached data will be used, no cache specified.")
 	}
 	return nil
@@ -101,6 +132,20 @@ func CheckLaunchCache(i *LifecycleInputs, logger log.Logger) error {
 	return nil
 }
 
+// ReadRegistries reads the registry mirrors and TLS configuration provided by the platform in the registries file, if it exists.
+func ReadRegistries(i *LifecycleInputs, logger log.Logger) error {
+	registries, err := files.Handler.ReadRegistries(i.RegistriesPath, logger)
+	if err != nil {
+		return err
+	}
+	i.RegistryMirrors = registries.Mirrors()
+	i.RegistryTLS = image.RegistryTLS{}
+	for registry, config := range registries.TLS() {
+		i.RegistryTLS[registry] = image.TLSConfig{CAFile: config.CA, CertFile: config.Cert, KeyFile: config.Key}
+	}
+	return nil
+}
+
 func FillAnalyzeImages(i *LifecycleInputs, logger log.Logger) error {
 	if i.PreviousImageRef == "" {
 		i.PreviousImageRef = i.OutputImageRef
@@ -209,6 +254,55 @@ func ValidateImageRefs(i *LifecycleInputs, _ log.Logger) error {
 	return nil
 }
 
+func ValidateBuildpackTimeout(i *LifecycleInputs, _ log.Logger) error {
+	_, err := buildpack.ParseTimeouts(i.BuildpackTimeout)
+	return err
+}
+
+// ValidateCacheCompression ensures the compression of the layers in the cache directory, if provided, is supported.
+func ValidateCacheCompression(i *LifecycleInputs, _ log.Logger) error {
+	switch i.CacheCompression {
+	case "", "gzip", "zstd":
+		return nil
+	default:
+		return fmt.Errorf("invalid cache compression '%s': must be 'gzip' or 'zstd'", i.CacheCompression)
+	}
+}
+
+func ValidateCacheEviction(i *LifecycleInputs, _ log.Logger) error {
+	if _, err := str.ParseSize(i.CacheMaxSize); err != nil {
+		return fmt.Errorf("invalid cache max size: %w", err)
+	}
+	if i.CacheMaxAge < 0 {
+		return errors.New("invalid cache max age: must not be negative")
+	}
+	return nil
+}
+
+// ValidateCacheURL ensures the cache URL, if provided, refers to a directory in OCI layout format or a bucket in an S3-compatible object store.
+func ValidateCacheURL(i *LifecycleInputs, _ log.Logger) error {
+	if i.CacheURL == "" {
+		return nil
+	}
+	if path, ok := strings.CutPrefix(i.CacheURL, OCILayoutCacheScheme); ok {
+		if path == "" {
+			return errors.New("invalid cache URL: missing OCI layout path")
+		}
+		return nil
+	}
+	u, err := url.Parse(i.CacheURL)
+	if err != nil {
+		return fmt.Errorf("invalid cache URL: %w", err)
+	}
+	if u.Scheme != "s3" {
+		return fmt.Errorf("invalid cache URL: unsupported scheme '%s'", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("invalid cache URL: missing bucket")
+	}
+	return nil
+}
+
 func ValidateOutputImageProvided(i *LifecycleInputs, _ log.Logger) error {
 	if i.OutputImageRef == "" {
 		return errors.New(ErrOutputImageRequired)
@@ -230,7 +324,7 @@ func ValidateRebaseRunImage(i *LifecycleInputs, _ log.Logger) error {
 
 // CheckParallelExport will warn when parallel export is enabled without a cache.
 func CheckParallelExport(i *LifecycleInputs, logger log.Logger) error {
-	if i.ParallelExport && (i.CacheImageRef == "" && i.CacheDir == "") {
+	if i.ParallelExport && (i.CacheImageRef == "" && i.CacheURL == "" && i.CacheDir == "") {
 		logger.Warn("Parallel export has been enabled, but it has not taken effect because no cache has been specified.")
 	}
 	return nil
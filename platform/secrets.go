@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/lifecycle/auth"
+)
+
+// Secrets returns the values that are redacted from the lifecycle logs and buildpack output:
+// the registry credentials in `CNB_REGISTRY_AUTH`, the values of the platform environment variables listed in `CNB_SECRET_ENV_VARS`,
+// and the values of the service bindings in `<platform>/bindings` and `$SERVICE_BINDING_ROOT`.
+func (i *LifecycleInputs) Secrets() ([]string, error) {
+	keychain, err := auth.NewEnvKeychain(auth.EnvRegistryAuth)
+	if err != nil {
+		return nil, err
+	}
+	var secrets []string
+	if envKeychain, ok := keychain.(*auth.EnvKeychain); ok {
+		secrets = append(secrets, envKeychain.Secrets()...)
+	}
+
+	for _, name := range i.SecretEnvVars {
+		if value := os.Getenv(name); value != "" {
+			secrets = append(secrets, value)
+		}
+		value, err := os.ReadFile(filepath.Join(i.PlatformDir, "env", name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("reading platform environment variable %q: %w", name, err)
+		}
+		secrets = append(secrets, string(value))
+	}
+
+	bindingRoots := []string{filepath.Join(i.PlatformDir, "bindings")}
+	if root := os.Getenv(EnvServiceBindingRoot); root != "" {
+		bindingRoots = append(bindingRoots, root)
+	}
+	for _, root := range bindingRoots {
+		bindingSecrets, err := readBindingSecrets(root)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, bindingSecrets...)
+	}
+	return secrets, nil
+}
+
+// readBindingSecrets returns the value of each entry of each service binding in the provided directory,
+// except for the `type` and `provider` entries, which are not secret.
+func readBindingSecrets(root string) ([]string, error) {
+	bindings, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading service bindings: %w", err)
+	}
+	var secrets []string
+	for _, binding := range bindings {
+		if strings.HasPrefix(binding.Name(), ".") {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(root, binding.Name()))
+		if err != nil {
+			continue // not a binding directory
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") || entry.Name() == "type" || entry.Name() == "provider" {
+				continue
+			}
+			// entries may be symlinks (e.g., in a Kubernetes volume), so directories are skipped when they fail to read
+			value, err := os.ReadFile(filepath.Join(root, binding.Name(), entry.Name()))
+			if err != nil {
+				continue
+			}
+			secrets = append(secrets, string(value))
+		}
+	}
+	return secrets, nil
+}
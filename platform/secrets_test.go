@@ -0,0 +1,73 @@
+package platform_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/auth"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSecrets(t *testing.T) {
+	spec.Run(t, "Secrets", testSecrets, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testSecrets(t *testing.T, when spec.G, it spec.S) {
+	var (
+		platformDir string
+		inputs      *platform.LifecycleInputs
+	)
+
+	it.Before(func() {
+		platformDir = t.TempDir()
+		inputs = &platform.LifecycleInputs{PlatformDir: platformDir}
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.Unsetenv(auth.EnvRegistryAuth))
+		h.AssertNil(t, os.Unsetenv(platform.EnvServiceBindingRoot))
+	})
+
+	when("#Secrets", func() {
+		it("returns the registry credentials", func() {
+			h.AssertNil(t, os.Setenv(auth.EnvRegistryAuth, `{"some-registry.com": "Bearer some-token"}`))
+
+			secrets, err := inputs.Secrets()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, secrets, []string{"Bearer some-token", "some-token"})
+		})
+
+		it("returns the values of the platform environment variables that are secret", func() {
+			h.Mkdir(t, filepath.Join(platformDir, "env"))
+			h.Mkfile(t, "some-secret-value", filepath.Join(platformDir, "env", "SOME_SECRET"))
+			h.Mkfile(t, "some-value", filepath.Join(platformDir, "env", "NOT_SECRET"))
+			inputs.SecretEnvVars = []string{"SOME_SECRET", "MISSING_SECRET"}
+
+			secrets, err := inputs.Secrets()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, secrets, []string{"some-secret-value"})
+		})
+
+		it("returns the values of service bindings except for their type and provider", func() {
+			bindingRoot := t.TempDir()
+			h.AssertNil(t, os.Setenv(platform.EnvServiceBindingRoot, bindingRoot))
+			h.Mkdir(t, filepath.Join(platformDir, "bindings", "some-binding"), filepath.Join(bindingRoot, "other-binding"))
+			h.Mkfile(t, "some-type", filepath.Join(platformDir, "bindings", "some-binding", "type"))
+			h.Mkfile(t, "some-provider", filepath.Join(platformDir, "bindings", "some-binding", "provider"))
+			h.Mkfile(t, "some-password", filepath.Join(platformDir, "bindings", "some-binding", "password"))
+			h.Mkfile(t, "other-api-key", filepath.Join(bindingRoot, "other-binding", "api-key"))
+
+			secrets, err := inputs.Secrets()
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, secrets, []string{"some-password", "other-api-key"})
+		})
+	})
+}
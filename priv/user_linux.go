@@ -3,6 +3,7 @@
 package priv
 
 import (
+	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -31,6 +32,25 @@ func EnsureOwner(uid, gid int, paths ...string) error {
 	return nil
 }
 
+// CheckOwner returns an error if the provided path exists and is not writable by the provided user and group,
+// unless the lifecycle is privileged, in which case EnsureOwner will fix its ownership.
+func CheckOwner(uid, gid int, path string) error {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok && canWrite(uid, gid, stat) {
+		return nil
+	}
+	if IsPrivileged() {
+		return nil
+	}
+	return fmt.Errorf("%s is not writable by user %d:%d and cannot be chowned without root privileges", path, uid, gid)
+}
+
 const (
 	worldWrite uint32 = 0002
 	groupWrite uint32 = 0020
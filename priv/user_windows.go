@@ -4,6 +4,10 @@ func EnsureOwner(uid, gid int, paths ...string) error {
 	return nil
 }
 
+func CheckOwner(uid, gid int, path string) error {
+	return nil
+}
+
 func IsPrivileged() bool {
 	return false
 }